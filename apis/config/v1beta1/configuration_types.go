@@ -17,6 +17,7 @@ limitations under the License.
 package v1beta1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	cfg "sigs.k8s.io/controller-runtime/pkg/config/v1alpha1"
 )
@@ -59,6 +60,10 @@ type Configuration struct {
 	// Integrations provide configuration options for AI/ML/Batch frameworks
 	// integrations (including K8S job).
 	Integrations *Integrations `json:"integrations,omitempty"`
+
+	// Cohorts provide configuration options for the cohorts of ClusterQueues,
+	// identified by the name set in the .spec.cohort of their members.
+	Cohorts []Cohort `json:"cohorts,omitempty"`
 }
 
 type WaitForPodsReady struct {
@@ -74,6 +79,13 @@ type WaitForPodsReady struct {
 	// is cancelled and requeued in the same cluster queue. Defaults to 5min.
 	// +optional
 	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// RequeuingBackoffLimitCount defines the maximum number of times a
+	// workload is requeued after PodsReady timeouts. Once the limit is
+	// exceeded, the workload is deactivated (`.spec.active`=`false`).
+	// When null, the workloads are requeued without limit.
+	// +optional
+	RequeuingBackoffLimitCount *int32 `json:"requeuingBackoffLimitCount,omitempty"`
 }
 
 type InternalCertManagement struct {
@@ -101,6 +113,38 @@ type ClientConnection struct {
 	Burst *int32 `json:"burst,omitempty"`
 }
 
+type Cohort struct {
+	// Name of the cohort.
+	Name string `json:"name"`
+
+	// Parent is the name of the cohort from which the members of this cohort
+	// can borrow once the quota in this cohort is exhausted. The parent can
+	// be a cohort without ClusterQueues of its own.
+	// +optional
+	Parent string `json:"parent,omitempty"`
+
+	// FairSharing, when true, limits the quota that the members of this
+	// cohort can borrow without preemption to their fair share of the cohort
+	// capacity, according to the fairSharingWeight of their ClusterQueues.
+	// +optional
+	FairSharing bool `json:"fairSharing,omitempty"`
+
+	// Caps are the maximum quantities of resources in flavors that the
+	// members of this cohort can use in total, even if the sum of their
+	// nominal quotas is higher, like the physical number of GPUs in the
+	// cluster.
+	// +optional
+	Caps []FlavorCaps `json:"caps,omitempty"`
+}
+
+type FlavorCaps struct {
+	// Name of the ResourceFlavor.
+	Name string `json:"name"`
+
+	// Resources are the caps of the resources in the flavor.
+	Resources corev1.ResourceList `json:"resources"`
+}
+
 type Integrations struct {
 	// List of framework names to be enabled.
 	// Possible options:
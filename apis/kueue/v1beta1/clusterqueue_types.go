@@ -88,6 +88,61 @@ type ClusterQueueSpec struct {
 	// preempt to accomomdate the pending Workload, preempting Workloads with
 	// lower priority first.
 	Preemption *ClusterQueuePreemption `json:"preemption,omitempty"`
+
+	// fairSharingWeight is the weight of this ClusterQueue when dividing the
+	// capacity of its cohort among its members. Each ClusterQueue is entitled
+	// to a share of the cohort capacity proportional to its weight.
+	// Defaults to 1.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	FairSharingWeight *int64 `json:"fairSharingWeight,omitempty"`
+
+	// borrowableFlavors restricts, per resource, the flavors in which the
+	// ClusterQueue can borrow unused quota from its cohort. Resources not
+	// listed can be borrowed in any flavor.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=16
+	BorrowableFlavors []BorrowableFlavors `json:"borrowableFlavors,omitempty"`
+
+	// flavorSelectionPolicy indicates how to choose among the flavors of a
+	// resource group that are equally good for a podset, that is, with the
+	// same assignment mode, borrowing and preference.
+	//
+	// - DeclarationOrder: the first flavor in the order of the resource group
+	// is chosen. This is the default.
+	// - MostFree: the flavor with the largest fraction of its nominal quota
+	// remaining unused is chosen, to balance the load across the flavors.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=DeclarationOrder;MostFree
+	FlavorSelectionPolicy FlavorSelectionPolicy `json:"flavorSelectionPolicy,omitempty"`
+}
+
+type FlavorSelectionPolicy string
+
+const (
+	// DeclarationOrder means that the first flavor in the order of the
+	// resource group is chosen among equally good flavors.
+	DeclarationOrder FlavorSelectionPolicy = "DeclarationOrder"
+
+	// MostFree means that the flavor with the largest fraction of its nominal
+	// quota remaining unused is chosen among equally good flavors.
+	MostFree FlavorSelectionPolicy = "MostFree"
+)
+
+type BorrowableFlavors struct {
+	// name of the resource.
+	Name corev1.ResourceName `json:"name"`
+
+	// flavors is the list of flavors in which the resource can be borrowed
+	// from the cohort.
+	//
+	// +kubebuilder:validation:MaxItems=16
+	Flavors []ResourceFlavorReference `json:"flavors"`
 }
 
 type QueueingStrategy string
@@ -276,6 +331,48 @@ type ClusterQueuePreemption struct {
 	// +kubebuilder:default=Never
 	// +kubebuilder:validation:Enum=Never;LowerPriority;LowerOrNewerEqualPriority
 	WithinClusterQueue PreemptionPolicy `json:"withinClusterQueue,omitempty"`
+
+	// borrowWithinCohort determines whether a pending Workload that fits
+	// within the nominal quota of its ClusterQueue can reclaim the quota lent
+	// to other ClusterQueues in the cohort from Workloads with a higher
+	// priority than its own.
+	//
+	// +optional
+	BorrowWithinCohort *BorrowWithinCohort `json:"borrowWithinCohort,omitempty"`
+}
+
+type BorrowWithinCohortPolicy string
+
+const (
+	BorrowWithinCohortPolicyNever           BorrowWithinCohortPolicy = "Never"
+	BorrowWithinCohortPolicyReclaimBorrowed BorrowWithinCohortPolicy = "ReclaimBorrowed"
+)
+
+// BorrowWithinCohort contains the policy to reclaim the quota lent to other
+// ClusterQueues in the cohort, regardless of the priority of the Workloads
+// borrowing it.
+type BorrowWithinCohort struct {
+	// policy determines which Workloads borrowing quota in other ClusterQueues
+	// of the cohort can be preempted, in addition to the ones allowed by
+	// reclaimWithinCohort. The possible values are:
+	//
+	// - `Never` (default): only the reclaimWithinCohort policy applies.
+	// - `ReclaimBorrowed`: if the pending Workload fits within the nominal
+	//   quota of its ClusterQueue, preempt Workloads in the cohort that are
+	//   borrowing, even if they have a higher priority than the pending
+	//   Workload, as long as their priority doesn't exceed
+	//   maxPriorityThreshold.
+	//
+	// +kubebuilder:default=Never
+	// +kubebuilder:validation:Enum=Never;ReclaimBorrowed
+	Policy BorrowWithinCohortPolicy `json:"policy,omitempty"`
+
+	// maxPriorityThreshold is the highest priority of the Workloads that can
+	// be preempted with the ReclaimBorrowed policy. If unset, Workloads of any
+	// priority can be preempted.
+	//
+	// +optional
+	MaxPriorityThreshold *int32 `json:"maxPriorityThreshold,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -20,4 +20,31 @@ const (
 	ResourceInUseFinalizerName = "kueue.x-k8s.io/resource-in-use"
 
 	DefaultPodSetName = "main"
+
+	// InterruptionTolerantLabel is the label of a Workload indicating whether
+	// it tolerates interruptions. Workloads with the value "true" prefer spot
+	// flavors, which have taints, and workloads with the value "false" prefer
+	// on-demand flavors, which don't, among flavors that fit equally.
+	InterruptionTolerantLabel = "kueue.x-k8s.io/interruption-tolerant"
+
+	// WorkloadClassLabel is the label of a Workload with its class, like
+	// training or inference, which can be limited to a share of the quota of
+	// each flavor in a ClusterQueue.
+	WorkloadClassLabel = "kueue.x-k8s.io/workload-class"
+
+	// ReservationOnlyLabel is the label of a Workload indicating, with the
+	// value "true", that it only reserves quota and doesn't run pods, like a
+	// placeholder for a future burst of workloads.
+	ReservationOnlyLabel = "kueue.x-k8s.io/reservation-only"
+
+	// DeadlineAnnotation is the annotation of a Workload with the time, in
+	// RFC 3339 format, by which it should start running. Workloads with closer
+	// deadlines are ordered first in the queue.
+	DeadlineAnnotation = "kueue.x-k8s.io/deadline"
+
+	// ColocationLabelAnnotation is the annotation of a Workload with the key
+	// of a node label, like topology.kubernetes.io/zone, whose value should be
+	// the same in the flavors assigned to the resources of each podset across
+	// resource groups.
+	ColocationLabelAnnotation = "kueue.x-k8s.io/colocation-label"
 )
@@ -26,8 +26,27 @@ import (
 type LocalQueueSpec struct {
 	// clusterQueue is a reference to a clusterQueue that backs this localQueue.
 	ClusterQueue ClusterQueueReference `json:"clusterQueue,omitempty"`
+
+	// stopPolicy - if set to a value different from None, the LocalQueue is
+	// paused and its pending workloads are not considered for admission.
+	// Admitted workloads keep running.
+	//
+	// - None: the LocalQueue is active.
+	// - Hold: no new workloads from the LocalQueue are admitted.
+	//
+	// +kubebuilder:default=None
+	// +kubebuilder:validation:Enum=None;Hold
+	// +optional
+	StopPolicy StopPolicy `json:"stopPolicy,omitempty"`
 }
 
+type StopPolicy string
+
+const (
+	None StopPolicy = "None"
+	Hold StopPolicy = "Hold"
+)
+
 // ClusterQueueReference is the name of the ClusterQueue.
 type ClusterQueueReference string
 
@@ -64,6 +64,59 @@ type ResourceFlavorSpec struct {
 	// +listType=atomic
 	// +kubebuilder:validation:MaxItems=8
 	NodeTaints []corev1.Taint `json:"nodeTaints,omitempty"`
+
+	// ignoreNodeAffinity indicates that the ResourceFlavor doesn't correspond
+	// to a set of nodes, but is a logical partition of the quota. When true,
+	// the nodeSelector and nodeAffinity of the podsets are not checked against
+	// the nodeLabels during admission.
+	//
+	// +optional
+	IgnoreNodeAffinity bool `json:"ignoreNodeAffinity,omitempty"`
+
+	// schedulerName is the name of the scheduler that schedules the pods in
+	// the nodes associated with this ResourceFlavor.
+	// When set, workloads' podsets can only get assigned this ResourceFlavor
+	// if their pods use the same scheduler. Pods that don't specify a
+	// schedulerName use the default-scheduler.
+	//
+	// +optional
+	SchedulerName string `json:"schedulerName,omitempty"`
+
+	// deprecated indicates that the ResourceFlavor is being phased out.
+	// Workloads that are already admitted keep using it, but new admissions
+	// get other flavors assigned when they are available with the same mode.
+	//
+	// +optional
+	Deprecated bool `json:"deprecated,omitempty"`
+
+	// unschedulable indicates that the nodes associated with the
+	// ResourceFlavor don't accept new pods, for example, because they are
+	// cordoned. Workloads that are already admitted keep using it, but new
+	// admissions don't get it assigned.
+	//
+	// +optional
+	Unschedulable bool `json:"unschedulable,omitempty"`
+
+	// resourceAliases maps resource names requested by pods, like
+	// product-specific GPU names, to the resource name that provides quota for
+	// them in this ResourceFlavor, like nvidia.com/gpu.
+	// A pod set requesting an aliased resource can only get assigned the
+	// ResourceFlavors that declare the alias.
+	//
+	// resourceAliases can be up to 8 elements.
+	// +optional
+	// +kubebuilder:validation:MaxProperties=8
+	ResourceAliases map[corev1.ResourceName]corev1.ResourceName `json:"resourceAliases,omitempty"`
+
+	// minRequests are the minimum quantities of resources that each pod needs
+	// to request for this ResourceFlavor to be assigned, so that pods with
+	// small requests don't use a flavor meant for large ones, like whole-node
+	// GPU machines.
+	//
+	// minRequests can be up to 16 elements.
+	// +optional
+	// +kubebuilder:validation:MaxProperties=16
+	MinRequests corev1.ResourceList `json:"minRequests,omitempty"`
 }
 
 //+kubebuilder:object:root=true
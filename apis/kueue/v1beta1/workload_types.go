@@ -52,6 +52,23 @@ type WorkloadSpec struct {
 	// The higher the value, the higher the priority.
 	// If priorityClassName is specified, priority must not be null.
 	Priority *int32 `json:"priority,omitempty"`
+
+	// active determines if a workload can be admitted into a queue.
+	// Changing active from true to false will evict any running workloads.
+	// Defaults to true.
+	//
+	// +kubebuilder:default=true
+	// +optional
+	Active *bool `json:"active,omitempty"`
+
+	// totalRequests, when specified, are the total resources that the workload
+	// needs to run, used for quota purposes instead of the sum of the requests
+	// of the pods in the podSet. It's meant for workloads whose pod requests
+	// are not meaningful, and it can only be set for workloads with a single
+	// podSet.
+	//
+	// +optional
+	TotalRequests corev1.ResourceList `json:"totalRequests,omitempty"`
 }
 
 type Admission struct {
@@ -77,6 +94,13 @@ type PodSetAssignment struct {
 	// Beside what is provided in podSet's specs, this calculation takes into account
 	// the LimitRange defaults and RuntimeClass overheads at the moment of admission.
 	ResourceUsage corev1.ResourceList `json:"resourceUsage,omitempty"`
+
+	// count is the number of pods of the podSet taken into account at
+	// admission time. It can be lower than the count in the podSet if the
+	// podSet has a minCount and the full count didn't fit.
+	//
+	// +optional
+	Count *int32 `json:"count,omitempty"`
 }
 
 type PodSet struct {
@@ -101,6 +125,22 @@ type PodSet struct {
 	// count is the number of pods for the spec.
 	// +kubebuilder:validation:Minimum=1
 	Count int32 `json:"count"`
+
+	// minCount is the minimum number of pods for the spec that can be
+	// admitted when the full count doesn't fit in the ClusterQueue.
+	// If unset, the pod set can only be admitted with its full count.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MinCount *int32 `json:"minCount,omitempty"`
+
+	// replicaGroup is the name of a group of identical podSets, like the
+	// per-rank podSets of a distributed job, that get assigned the same
+	// ResourceFlavors. The flavors are chosen for the requests of the group as
+	// a whole.
+	//
+	// +optional
+	ReplicaGroup string `json:"replicaGroup,omitempty"`
 }
 
 // WorkloadStatus defines the observed state of Workload
@@ -115,18 +155,89 @@ type WorkloadStatus struct {
 	//
 	// The type of the condition could be:
 	//
+	// - QuotaReserved: the Workload has quota reserved in a ClusterQueue.
 	// - Admitted: the Workload was admitted through a ClusterQueue.
 	// - Finished: the associated workload finished running (failed or succeeded).
 	// - PodsReady: at least `.spec.podSets[*].count` Pods are ready or have
 	// succeeded.
+	// - Deactivated: the Workload was deactivated after exceeding the limit of
+	// requeues due to PodsReady timeouts.
 	//
 	// +optional
 	// +listType=map
 	// +listMapKey=type
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// requeueState holds the state of the requeuing of the workload after
+	// being evicted due to a PodsReady timeout.
+	//
+	// +optional
+	RequeueState *RequeueState `json:"requeueState,omitempty"`
+
+	// admissionChecks list the states of the external admission checks
+	// required by the workload.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=8
+	AdmissionChecks []AdmissionCheckState `json:"admissionChecks,omitempty"`
+}
+
+type CheckState string
+
+const (
+	// CheckStatePending means that the check is still being evaluated.
+	CheckStatePending CheckState = "Pending"
+	// CheckStateReady means that the check passed.
+	CheckStateReady CheckState = "Ready"
+	// CheckStateRetry means that the check failed transiently and the
+	// workload should be evaluated again.
+	CheckStateRetry CheckState = "Retry"
+	// CheckStateRejected means that the check failed and the workload can't
+	// be admitted.
+	CheckStateRejected CheckState = "Rejected"
+)
+
+type AdmissionCheckState struct {
+	// name identifies the admission check.
+	Name string `json:"name"`
+
+	// state of the admission check, one of Pending, Ready, Retry or Rejected.
+	//
+	// +kubebuilder:validation:Enum=Pending;Ready;Retry;Rejected
+	State CheckState `json:"state"`
+
+	// lastTransitionTime is the last time the state changed.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// message is a human readable message with details about the state.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=32768
+	Message string `json:"message,omitempty"`
+}
+
+type RequeueState struct {
+	// count records the number of times a workload has been requeued after
+	// being evicted due to a PodsReady timeout.
+	//
+	// +optional
+	Count *int32 `json:"count,omitempty"`
+
+	// requeueAt records the time until which the workload is held back from
+	// the queue after being evicted due to a PodsReady timeout. The delay
+	// grows exponentially with the count.
+	//
+	// +optional
+	RequeueAt *metav1.Time `json:"requeueAt,omitempty"`
 }
 
 const (
+	// WorkloadQuotaReserved means that the Workload has reserved quota in a
+	// ClusterQueue, but it might not be admitted yet.
+	WorkloadQuotaReserved = "QuotaReserved"
+
 	// WorkloadAdmitted means that the Workload was admitted by a ClusterQueue.
 	WorkloadAdmitted = "Admitted"
 
@@ -140,6 +251,10 @@ const (
 
 	// WorkloadEvicted means that the Workload was evicted by a ClusterQueue
 	WorkloadEvicted = "Evicted"
+
+	// WorkloadDeactivated means that the Workload was deactivated, setting
+	// .spec.active to false.
+	WorkloadDeactivated = "Deactivated"
 )
 
 const (
@@ -150,6 +265,15 @@ const (
 	// WorkloadEvictedByPodsReadyTimeout indicates that the eviction took
 	// place due to a PodsReady timeout.
 	WorkloadEvictedByPodsReadyTimeout = "PodsReadyTimeout"
+
+	// WorkloadEvictedByDeactivation indicates that the eviction took place
+	// because the workload was deactivated.
+	WorkloadEvictedByDeactivation = "InactiveWorkload"
+
+	// WorkloadDeactivatedByRequeuingLimit indicates that the workload was
+	// deactivated because it exceeded the limit of requeues due to PodsReady
+	// timeouts.
+	WorkloadDeactivatedByRequeuingLimit = "RequeuingLimitExceeded"
 )
 
 // +kubebuilder:object:root=true
@@ -49,6 +49,62 @@ func (in *Admission) DeepCopy() *Admission {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdmissionCheckState) DeepCopyInto(out *AdmissionCheckState) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdmissionCheckState.
+func (in *AdmissionCheckState) DeepCopy() *AdmissionCheckState {
+	if in == nil {
+		return nil
+	}
+	out := new(AdmissionCheckState)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BorrowWithinCohort) DeepCopyInto(out *BorrowWithinCohort) {
+	*out = *in
+	if in.MaxPriorityThreshold != nil {
+		in, out := &in.MaxPriorityThreshold, &out.MaxPriorityThreshold
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BorrowWithinCohort.
+func (in *BorrowWithinCohort) DeepCopy() *BorrowWithinCohort {
+	if in == nil {
+		return nil
+	}
+	out := new(BorrowWithinCohort)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BorrowableFlavors) DeepCopyInto(out *BorrowableFlavors) {
+	*out = *in
+	if in.Flavors != nil {
+		in, out := &in.Flavors, &out.Flavors
+		*out = make([]ResourceFlavorReference, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BorrowableFlavors.
+func (in *BorrowableFlavors) DeepCopy() *BorrowableFlavors {
+	if in == nil {
+		return nil
+	}
+	out := new(BorrowableFlavors)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterQueue) DeepCopyInto(out *ClusterQueue) {
 	*out = *in
@@ -111,6 +167,11 @@ func (in *ClusterQueueList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterQueuePreemption) DeepCopyInto(out *ClusterQueuePreemption) {
 	*out = *in
+	if in.BorrowWithinCohort != nil {
+		in, out := &in.BorrowWithinCohort, &out.BorrowWithinCohort
+		*out = new(BorrowWithinCohort)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterQueuePreemption.
@@ -141,8 +202,20 @@ func (in *ClusterQueueSpec) DeepCopyInto(out *ClusterQueueSpec) {
 	if in.Preemption != nil {
 		in, out := &in.Preemption, &out.Preemption
 		*out = new(ClusterQueuePreemption)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FairSharingWeight != nil {
+		in, out := &in.FairSharingWeight, &out.FairSharingWeight
+		*out = new(int64)
 		**out = **in
 	}
+	if in.BorrowableFlavors != nil {
+		in, out := &in.BorrowableFlavors, &out.BorrowableFlavors
+		*out = make([]BorrowableFlavors, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterQueueSpec.
@@ -373,6 +446,11 @@ func (in *LocalQueueStatus) DeepCopy() *LocalQueueStatus {
 func (in *PodSet) DeepCopyInto(out *PodSet) {
 	*out = *in
 	in.Template.DeepCopyInto(&out.Template)
+	if in.MinCount != nil {
+		in, out := &in.MinCount, &out.MinCount
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodSet.
@@ -402,6 +480,11 @@ func (in *PodSetAssignment) DeepCopyInto(out *PodSetAssignment) {
 			(*out)[key] = val.DeepCopy()
 		}
 	}
+	if in.Count != nil {
+		in, out := &in.Count, &out.Count
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodSetAssignment.
@@ -414,6 +497,30 @@ func (in *PodSetAssignment) DeepCopy() *PodSetAssignment {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RequeueState) DeepCopyInto(out *RequeueState) {
+	*out = *in
+	if in.Count != nil {
+		in, out := &in.Count, &out.Count
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RequeueAt != nil {
+		in, out := &in.RequeueAt, &out.RequeueAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequeueState.
+func (in *RequeueState) DeepCopy() *RequeueState {
+	if in == nil {
+		return nil
+	}
+	out := new(RequeueState)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceFlavor) DeepCopyInto(out *ResourceFlavor) {
 	*out = *in
@@ -489,6 +596,20 @@ func (in *ResourceFlavorSpec) DeepCopyInto(out *ResourceFlavorSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ResourceAliases != nil {
+		in, out := &in.ResourceAliases, &out.ResourceAliases
+		*out = make(map[corev1.ResourceName]corev1.ResourceName, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MinRequests != nil {
+		in, out := &in.MinRequests, &out.MinRequests
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceFlavorSpec.
@@ -640,6 +761,18 @@ func (in *WorkloadSpec) DeepCopyInto(out *WorkloadSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Active != nil {
+		in, out := &in.Active, &out.Active
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TotalRequests != nil {
+		in, out := &in.TotalRequests, &out.TotalRequests
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadSpec.
@@ -667,6 +800,18 @@ func (in *WorkloadStatus) DeepCopyInto(out *WorkloadStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.RequeueState != nil {
+		in, out := &in.RequeueState, &out.RequeueState
+		*out = new(RequeueState)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdmissionChecks != nil {
+		in, out := &in.AdmissionChecks, &out.AdmissionChecks
+		*out = make([]AdmissionCheckState, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadStatus.
@@ -35,7 +35,8 @@ import (
 )
 
 const (
-	isNegativeErrorMsg string = `must be greater than or equal to 0`
+	isNegativeErrorMsg   string = `must be greater than or equal to 0`
+	limitIsEmptyErrorMsg string = `must be nil when cohort is empty`
 )
 
 type ClusterQueueWebhook struct{}
@@ -106,6 +107,7 @@ func ValidateClusterQueue(cq *kueue.ClusterQueue) field.ErrorList {
 		allErrs = append(allErrs, validateNameReference(cq.Spec.Cohort, path.Child("cohort"))...)
 	}
 	allErrs = append(allErrs, validateResourceGroups(cq.Spec.ResourceGroups, path.Child("resourceGroups"))...)
+	allErrs = append(allErrs, ValidateBorrowingConfig(cq)...)
 	allErrs = append(allErrs,
 		validation.ValidateLabelSelector(cq.Spec.NamespaceSelector, validation.LabelSelectorValidationOptions{}, path.Child("namespaceSelector"))...)
 
@@ -123,6 +125,46 @@ func ValidateClusterQueueUpdate(newObj, oldObj *kueue.ClusterQueue) field.ErrorL
 	return allErrs
 }
 
+// ValidateClusterQueueFlavors returns an error for each flavor referenced in
+// the resourceGroups of the ClusterQueue that isn't in the given flavors.
+// It's not part of the admission checks, as a ClusterQueue can be created
+// before its flavors; it stays inactive, with a FlavorNotFound condition, until
+// they exist.
+func ValidateClusterQueueFlavors(cq *kueue.ClusterQueue, flavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor) field.ErrorList {
+	var allErrs field.ErrorList
+	path := field.NewPath("spec", "resourceGroups")
+	for i, rg := range cq.Spec.ResourceGroups {
+		for j, fqs := range rg.Flavors {
+			if _, found := flavors[fqs.Name]; !found {
+				allErrs = append(allErrs, field.NotFound(path.Index(i).Child("flavors").Index(j).Child("name"), fqs.Name))
+			}
+		}
+	}
+	return allErrs
+}
+
+// ValidateBorrowingConfig returns an error for each borrowingLimit set in the
+// resourceGroups of a ClusterQueue that doesn't belong to a cohort, as there is
+// nothing to borrow from.
+func ValidateBorrowingConfig(cq *kueue.ClusterQueue) field.ErrorList {
+	if len(cq.Spec.Cohort) != 0 {
+		return nil
+	}
+	var allErrs field.ErrorList
+	path := field.NewPath("spec", "resourceGroups")
+	for i, rg := range cq.Spec.ResourceGroups {
+		for j, fqs := range rg.Flavors {
+			for k, rq := range fqs.Resources {
+				if rq.BorrowingLimit != nil {
+					path := path.Index(i).Child("flavors").Index(j).Child("resources").Index(k).Child("borrowingLimit")
+					allErrs = append(allErrs, field.Invalid(path, rq.BorrowingLimit.String(), limitIsEmptyErrorMsg))
+				}
+			}
+		}
+	}
+	return allErrs
+}
+
 func validateResourceGroups(resourceGroups []kueue.ResourceGroup, path *field.Path) field.ErrorList {
 	var allErrs field.ErrorList
 	seenResources := sets.New[corev1.ResourceName]()
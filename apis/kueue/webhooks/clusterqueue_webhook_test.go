@@ -105,6 +105,7 @@ func TestValidateClusterQueue(t *testing.T) {
 		{
 			name: "flavor quota with borrowingLimit 0",
 			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				Cohort("prod").
 				ResourceGroup(
 					*testingutil.MakeFlavorQuotas("x86").Resource("cpu", "1", "0").Obj()).
 				Obj(),
@@ -112,6 +113,7 @@ func TestValidateClusterQueue(t *testing.T) {
 		{
 			name: "flavor quota with negative borrowingLimit",
 			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				Cohort("prod").
 				ResourceGroup(
 					*testingutil.MakeFlavorQuotas("x86").Resource("cpu", "1", "-1").Obj()).
 				Obj(),
@@ -119,6 +121,16 @@ func TestValidateClusterQueue(t *testing.T) {
 				field.Invalid(resourceGroupsPath.Index(0).Child("flavors").Index(0).Child("resources").Index(0).Child("borrowingLimit"), "-1", ""),
 			},
 		},
+		{
+			name: "flavor quota with borrowingLimit without cohort",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				ResourceGroup(
+					*testingutil.MakeFlavorQuotas("x86").Resource("cpu", "1", "0").Obj()).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(resourceGroupsPath.Index(0).Child("flavors").Index(0).Child("resources").Index(0).Child("borrowingLimit"), "0", ""),
+			},
+		},
 		{
 			name: "empty queueing strategy is supported",
 			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
@@ -290,6 +302,18 @@ func TestValidateClusterQueueUpdate(t *testing.T) {
 			oldClusterQueue: testingutil.MakeClusterQueue("cluster-queue").QueueingStrategy("BestEffortFIFO").Obj(),
 			wantErr:         nil,
 		},
+		{
+			name: "borrowingLimit cannot be set without cohort",
+			newClusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				ResourceGroup(*testingutil.MakeFlavorQuotas("x86").Resource("cpu", "1", "1").Obj()).
+				Obj(),
+			oldClusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				ResourceGroup(*testingutil.MakeFlavorQuotas("x86").Resource("cpu", "1").Obj()).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(field.NewPath("spec", "resourceGroups").Index(0).Child("flavors").Index(0).Child("resources").Index(0).Child("borrowingLimit"), nil, ""),
+			},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -301,3 +325,90 @@ func TestValidateClusterQueueUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateBorrowingConfig(t *testing.T) {
+	resourceGroupsPath := field.NewPath("spec", "resourceGroups")
+	testcases := []struct {
+		name         string
+		clusterQueue *kueue.ClusterQueue
+		wantErr      field.ErrorList
+	}{
+		{
+			name: "borrowingLimit in a cohort",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				Cohort("prod").
+				ResourceGroup(*testingutil.MakeFlavorQuotas("x86").Resource("cpu", "1", "2").Obj()).
+				Obj(),
+		},
+		{
+			name: "no borrowingLimit without cohort",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				ResourceGroup(*testingutil.MakeFlavorQuotas("x86").Resource("cpu", "1").Obj()).
+				Obj(),
+		},
+		{
+			name: "borrowingLimit without cohort",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				ResourceGroup(*testingutil.MakeFlavorQuotas("x86").Resource("cpu", "1").Obj()).
+				ResourceGroup(*testingutil.MakeFlavorQuotas("default").
+					Resource("memory", "1Gi").
+					Resource("example.com/gpu", "1", "0").
+					Obj()).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(resourceGroupsPath.Index(1).Child("flavors").Index(0).Child("resources").Index(1).Child("borrowingLimit"), "0", ""),
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotErr := ValidateBorrowingConfig(tc.clusterQueue)
+			if diff := cmp.Diff(tc.wantErr, gotErr, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("ValidateBorrowingConfig() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateClusterQueueFlavors(t *testing.T) {
+	flavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"default": testingutil.MakeResourceFlavor("default").Obj(),
+		"x86":     testingutil.MakeResourceFlavor("x86").Obj(),
+	}
+	testcases := []struct {
+		name         string
+		clusterQueue *kueue.ClusterQueue
+		wantErr      field.ErrorList
+	}{
+		{
+			name: "existing flavors",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				ResourceGroup(*testingutil.MakeFlavorQuotas("default").Resource("cpu").Obj()).
+				ResourceGroup(*testingutil.MakeFlavorQuotas("x86").Resource("memory").Obj()).
+				Obj(),
+		},
+		{
+			name: "missing flavor",
+			clusterQueue: testingutil.MakeClusterQueue("cluster-queue").
+				ResourceGroup(*testingutil.MakeFlavorQuotas("default").Resource("cpu").Obj()).
+				ResourceGroup(
+					*testingutil.MakeFlavorQuotas("x86").Resource("memory").Obj(),
+					*testingutil.MakeFlavorQuotas("arm").Resource("memory").Obj(),
+				).
+				Obj(),
+			wantErr: field.ErrorList{
+				field.NotFound(field.NewPath("spec", "resourceGroups").Index(1).Child("flavors").Index(1).Child("name"), nil),
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotErr := ValidateClusterQueueFlavors(tc.clusterQueue, flavors)
+			if diff := cmp.Diff(tc.wantErr, gotErr, cmpopts.IgnoreFields(field.Error{}, "BadValue")); diff != "" {
+				t.Errorf("ValidateClusterQueueFlavors() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
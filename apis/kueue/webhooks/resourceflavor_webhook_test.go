@@ -59,6 +59,27 @@ func TestValidateResourceFlavor(t *testing.T) {
 				field.Required(field.NewPath("spec", "nodeTaints").Index(0).Child("effect"), ""),
 			},
 		},
+		{
+			name: "invalid taint effect",
+			rf: utiltesting.MakeResourceFlavor("resource-flavor").Taint(corev1.Taint{
+				Key:    "spot",
+				Value:  "true",
+				Effect: "NoRun",
+			}).Obj(),
+			wantErr: field.ErrorList{
+				field.NotSupported(field.NewPath("spec", "nodeTaints").Index(0).Child("effect"), corev1.TaintEffect("NoRun"), []string{}),
+			},
+		},
+		{
+			name: "invalid taint key",
+			rf: utiltesting.MakeResourceFlavor("resource-flavor").Taint(corev1.Taint{
+				Key:    "@spot",
+				Effect: corev1.TaintEffectNoSchedule,
+			}).Obj(),
+			wantErr: field.ErrorList{
+				field.Invalid(field.NewPath("spec", "nodeTaints").Index(0).Child("key"), "@spot", ""),
+			},
+		},
 		{
 			name: "invalid label name",
 			rf:   utiltesting.MakeResourceFlavor("resource-flavor").Label("@abc", "foo").Obj(),
@@ -99,6 +99,10 @@ func ValidateWorkload(obj *kueue.Workload) field.ErrorList {
 		allErrs = append(allErrs, validatePodSet(&obj.Spec.PodSets[i], specPath.Child("podSets").Index(i))...)
 	}
 
+	if obj.Spec.TotalRequests != nil && len(obj.Spec.PodSets) > 1 {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("totalRequests"), obj.Spec.TotalRequests, "can only be set for workloads with a single podSet"))
+	}
+
 	if len(obj.Spec.PriorityClassName) > 0 {
 		msgs := validation.IsDNS1123Subdomain(obj.Spec.PriorityClassName)
 		if len(msgs) > 0 {
@@ -132,6 +136,14 @@ func validatePodSet(ps *kueue.PodSet, path *field.Path) field.ErrorList {
 		allErrs = append(allErrs, field.Invalid(path.Child("name"), ps.Name, msg))
 	}
 
+	if ps.MinCount != nil {
+		if *ps.MinCount <= 0 {
+			allErrs = append(allErrs, field.Invalid(path.Child("minCount"), *ps.MinCount, "must be greater than 0"))
+		} else if *ps.MinCount > ps.Count {
+			allErrs = append(allErrs, field.Invalid(path.Child("minCount"), *ps.MinCount, "must not be greater than the count"))
+		}
+	}
+
 	// validate initContainers
 	icPath := path.Child("template", "spec", "initContainers")
 	for ci := range ps.Template.Spec.InitContainers {
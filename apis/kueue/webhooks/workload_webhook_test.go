@@ -27,6 +27,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/util/pointer"
 	testingutil "sigs.k8s.io/kueue/pkg/util/testing"
 )
 
@@ -110,6 +111,24 @@ func TestValidateWorkload(t *testing.T) {
 				},
 			).Obj(),
 		},
+		"total requests with a single podSet": {
+			workload: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
+				TotalRequest(corev1.ResourceCPU, "2").
+				Obj(),
+		},
+		"total requests with multiple podSets": {
+			workload: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).PodSets(
+				kueue.PodSet{
+					Name:  "driver",
+					Count: 1,
+				},
+				kueue.PodSet{
+					Name:  "workers",
+					Count: 100,
+				},
+			).TotalRequest(corev1.ResourceCPU, "2").Obj(),
+			wantErr: field.ErrorList{field.Invalid(specPath.Child("totalRequests"), nil, "")},
+		},
 		"should have a valid podSet name": {
 			workload: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).PodSets(
 				kueue.PodSet{
@@ -119,6 +138,35 @@ func TestValidateWorkload(t *testing.T) {
 			).Obj(),
 			wantErr: field.ErrorList{field.Invalid(podSetsPath.Index(0).Child("name"), nil, "")},
 		},
+		"should have a positive minCount": {
+			workload: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).PodSets(
+				kueue.PodSet{
+					Name:     "main",
+					Count:    3,
+					MinCount: pointer.Int32(0),
+				},
+			).Obj(),
+			wantErr: field.ErrorList{field.Invalid(podSetsPath.Index(0).Child("minCount"), nil, "")},
+		},
+		"should have a minCount not greater than the count": {
+			workload: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).PodSets(
+				kueue.PodSet{
+					Name:     "main",
+					Count:    3,
+					MinCount: pointer.Int32(4),
+				},
+			).Obj(),
+			wantErr: field.ErrorList{field.Invalid(podSetsPath.Index(0).Child("minCount"), nil, "")},
+		},
+		"should pass validation when minCount is within the count": {
+			workload: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).PodSets(
+				kueue.PodSet{
+					Name:     "main",
+					Count:    3,
+					MinCount: pointer.Int32(1),
+				},
+			).Obj(),
+		},
 		"should have valid priorityClassName": {
 			workload: testingutil.MakeWorkload(testWorkloadName, testWorkloadNamespace).
 				PriorityClass("invalid_class").
@@ -29,10 +29,12 @@ import (
 
 	zaplog "go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	corev1 "k8s.io/api/core/v1"
 	schedulingv1 "k8s.io/api/scheduling/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -54,6 +56,7 @@ import (
 	"sigs.k8s.io/kueue/pkg/util/cert"
 	"sigs.k8s.io/kueue/pkg/util/useragent"
 	"sigs.k8s.io/kueue/pkg/version"
+	"sigs.k8s.io/kueue/pkg/workload"
 
 	// Ensure linking of the job controllers.
 	_ "sigs.k8s.io/kueue/pkg/controller/jobs"
@@ -130,7 +133,7 @@ func main() {
 		close(certsReady)
 	}
 
-	cCache := cache.New(mgr.GetClient(), cache.WithPodsReadyTracking(waitForPodsReady(&cfg)))
+	cCache := cache.New(mgr.GetClient(), cache.WithPodsReadyTracking(waitForPodsReady(&cfg)), cache.WithCohortParents(cohortParents(&cfg)), cache.WithFairSharingCohorts(fairSharingCohorts(&cfg)), cache.WithCohortCaps(cohortCaps(&cfg)))
 	queues := queue.NewManager(mgr.GetClient(), cCache)
 
 	ctx := ctrl.SetupSignalHandler()
@@ -260,6 +263,48 @@ func waitForPodsReady(cfg *config.Configuration) bool {
 	return cfg.WaitForPodsReady != nil && cfg.WaitForPodsReady.Enable
 }
 
+// cohortParents returns the name of the parent of each cohort that has one.
+func cohortParents(cfg *config.Configuration) map[string]string {
+	parents := make(map[string]string, len(cfg.Cohorts))
+	for _, cohort := range cfg.Cohorts {
+		if cohort.Parent != "" {
+			parents[cohort.Name] = cohort.Parent
+		}
+	}
+	return parents
+}
+
+// fairSharingCohorts returns the names of the cohorts with fair sharing.
+func fairSharingCohorts(cfg *config.Configuration) sets.Set[string] {
+	names := sets.New[string]()
+	for _, cohort := range cfg.Cohorts {
+		if cohort.FairSharing {
+			names.Insert(cohort.Name)
+		}
+	}
+	return names
+}
+
+// cohortCaps returns the caps of each cohort that has them.
+func cohortCaps(cfg *config.Configuration) map[string]cache.FlavorResourceQuantities {
+	caps := make(map[string]cache.FlavorResourceQuantities, len(cfg.Cohorts))
+	for _, cohort := range cfg.Cohorts {
+		if len(cohort.Caps) == 0 {
+			continue
+		}
+		quantities := make(cache.FlavorResourceQuantities, len(cohort.Caps))
+		for _, flvCaps := range cohort.Caps {
+			resCaps := make(map[corev1.ResourceName]int64, len(flvCaps.Resources))
+			for rName, q := range flvCaps.Resources {
+				resCaps[rName] = workload.ResourceValue(rName, q)
+			}
+			quantities[kueue.ResourceFlavorReference(flvCaps.Name)] = resCaps
+		}
+		caps[cohort.Name] = quantities
+	}
+	return caps
+}
+
 func encodeConfig(cfg *config.Configuration) (string, error) {
 	codecs := serializer.NewCodecFactory(scheme)
 	const mediaType = runtime.ContentTypeYAML
@@ -310,6 +355,12 @@ func apply(configFile string) (ctrl.Options, config.Configuration, error) {
 		}
 	}
 
+	if errorlist := validateCohorts(cfg.Cohorts); len(errorlist) > 0 {
+		err := errorlist.ToAggregate()
+		setupLog.Error(err, "invalid cohorts")
+		return options, cfg, err
+	}
+
 	cfgStr, err := encodeConfig(&cfg)
 	if err != nil {
 		setupLog.Error(err, "unable to encode the config")
@@ -320,6 +371,35 @@ func apply(configFile string) (ctrl.Options, config.Configuration, error) {
 	return options, cfg, nil
 }
 
+// validateCohorts checks that the cohorts have unique names, that none of
+// them is its own parent and that their caps aren't negative.
+func validateCohorts(cohorts []config.Cohort) field.ErrorList {
+	var errorlist field.ErrorList
+	names := sets.New[string]()
+	path := field.NewPath("cohorts")
+	for i, cohort := range cohorts {
+		if cohort.Name == "" {
+			errorlist = append(errorlist, field.Required(path.Index(i).Child("name"), ""))
+			continue
+		}
+		if names.Has(cohort.Name) {
+			errorlist = append(errorlist, field.Duplicate(path.Index(i).Child("name"), cohort.Name))
+		}
+		names.Insert(cohort.Name)
+		if cohort.Parent == cohort.Name {
+			errorlist = append(errorlist, field.Invalid(path.Index(i).Child("parent"), cohort.Parent, "must be different from the name"))
+		}
+		for j, flvCaps := range cohort.Caps {
+			for rName, q := range flvCaps.Resources {
+				if q.Sign() < 0 {
+					errorlist = append(errorlist, field.Invalid(path.Index(i).Child("caps").Index(j).Child("resources").Key(string(rName)), q.String(), "must be greater than or equal to 0"))
+				}
+			}
+		}
+	}
+	return errorlist
+}
+
 func isFrameworkEnabled(cfg *config.Configuration, name string) bool {
 	for _, framework := range cfg.Integrations.Frameworks {
 		if framework == name {
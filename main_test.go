@@ -25,6 +25,8 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -189,6 +191,39 @@ integrations:
 		t.Fatal(err)
 	}
 
+	cohortsConfig := filepath.Join(tmpDir, "cohorts.yaml")
+	if err := os.WriteFile(cohortsConfig, []byte(`
+apiVersion: config.kueue.x-k8s.io/v1beta1
+kind: Configuration
+cohorts:
+- name: team-a
+  parent: org
+- name: org
+  fairSharing: true
+  caps:
+  - name: gpu
+    resources:
+      nvidia.com/gpu: 8
+`), os.FileMode(0600)); err != nil {
+		t.Fatal(err)
+	}
+
+	badCohortsConfig := filepath.Join(tmpDir, "badCohorts.yaml")
+	if err := os.WriteFile(badCohortsConfig, []byte(`
+apiVersion: config.kueue.x-k8s.io/v1beta1
+kind: Configuration
+cohorts:
+- name: team-a
+  parent: team-a
+- name: team-a
+  caps:
+  - name: default
+    resources:
+      cpu: -1
+`), os.FileMode(0600)); err != nil {
+		t.Fatal(err)
+	}
+
 	defaultControlOptions := ctrl.Options{
 		Port:                   config.DefaultWebhookPort,
 		HealthProbeBindAddress: config.DefaultHealthProbeBindAddress,
@@ -410,6 +445,44 @@ integrations:
 				MetricsBindAddress:     config.DefaultMetricsBindAddress,
 			},
 		},
+		{
+			name:       "cohorts config",
+			configFile: cohortsConfig,
+			wantConfiguration: config.Configuration{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: config.GroupVersion.String(),
+					Kind:       "Configuration",
+				},
+				Namespace:                  pointer.String(config.DefaultNamespace),
+				ManageJobsWithoutQueueName: false,
+				InternalCertManagement:     enableDefaultInternalCertManagement,
+				ClientConnection:           defaultClientConnection,
+				Integrations:               defaultIntegrations,
+				Cohorts: []config.Cohort{
+					{Name: "team-a", Parent: "org"},
+					{
+						Name:        "org",
+						FairSharing: true,
+						Caps: []config.FlavorCaps{{
+							Name: "gpu",
+							Resources: corev1.ResourceList{
+								"nvidia.com/gpu": resource.MustParse("8"),
+							},
+						}},
+					},
+				},
+			},
+			wantOptions: ctrl.Options{
+				Port:                   config.DefaultWebhookPort,
+				HealthProbeBindAddress: config.DefaultHealthProbeBindAddress,
+				MetricsBindAddress:     config.DefaultMetricsBindAddress,
+			},
+		},
+		{
+			name:       "bad cohorts config",
+			configFile: badCohortsConfig,
+			wantError:  fmt.Errorf("[cohorts[0].parent: Invalid value: \"team-a\": must be different from the name, cohorts[1].name: Duplicate value: \"team-a\", cohorts[1].caps[0].resources[cpu]: Invalid value: \"-1\": must be greater than or equal to 0]"),
+		},
 		{
 			name:       "bad integrations config",
 			configFile: badIntegrationsConfig,
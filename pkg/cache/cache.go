@@ -46,7 +46,10 @@ var (
 )
 
 type options struct {
-	podsReadyTracking bool
+	podsReadyTracking  bool
+	cohortParents      map[string]string
+	fairSharingCohorts sets.Set[string]
+	cohortCaps         map[string]FlavorResourceQuantities
 }
 
 // Option configures the reconciler.
@@ -61,6 +64,31 @@ func WithPodsReadyTracking(f bool) Option {
 	}
 }
 
+// WithCohortParents sets the name of the parent of each cohort. The members
+// of a cohort can borrow from the parent once the quota in the cohort is
+// exhausted.
+func WithCohortParents(parents map[string]string) Option {
+	return func(o *options) {
+		o.cohortParents = parents
+	}
+}
+
+// WithFairSharingCohorts sets the names of the cohorts in which the members
+// can only borrow up to their fair share of the cohort capacity.
+func WithFairSharingCohorts(names sets.Set[string]) Option {
+	return func(o *options) {
+		o.fairSharingCohorts = names
+	}
+}
+
+// WithCohortCaps sets, for each cohort, the maximum quantities of resources in
+// flavors that its members can use in total.
+func WithCohortCaps(caps map[string]FlavorResourceQuantities) Option {
+	return func(o *options) {
+		o.cohortCaps = caps
+	}
+}
+
 var defaultOptions = options{}
 
 // Cache keeps track of the Workloads that got admitted through ClusterQueues.
@@ -68,12 +96,15 @@ type Cache struct {
 	sync.RWMutex
 	podsReadyCond sync.Cond
 
-	client            client.Client
-	clusterQueues     map[string]*ClusterQueue
-	cohorts           map[string]*Cohort
-	assumedWorkloads  map[string]string
-	resourceFlavors   map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor
-	podsReadyTracking bool
+	client             client.Client
+	clusterQueues      map[string]*ClusterQueue
+	cohorts            map[string]*Cohort
+	assumedWorkloads   map[string]string
+	resourceFlavors    map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor
+	podsReadyTracking  bool
+	cohortParents      map[string]string
+	fairSharingCohorts sets.Set[string]
+	cohortCaps         map[string]FlavorResourceQuantities
 }
 
 func New(client client.Client, opts ...Option) *Cache {
@@ -82,12 +113,15 @@ func New(client client.Client, opts ...Option) *Cache {
 		opt(&options)
 	}
 	c := &Cache{
-		client:            client,
-		clusterQueues:     make(map[string]*ClusterQueue),
-		cohorts:           make(map[string]*Cohort),
-		assumedWorkloads:  make(map[string]string),
-		resourceFlavors:   make(map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor),
-		podsReadyTracking: options.podsReadyTracking,
+		client:             client,
+		clusterQueues:      make(map[string]*ClusterQueue),
+		cohorts:            make(map[string]*Cohort),
+		assumedWorkloads:   make(map[string]string),
+		resourceFlavors:    make(map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor),
+		podsReadyTracking:  options.podsReadyTracking,
+		cohortParents:      options.cohortParents,
+		fairSharingCohorts: options.fairSharingCohorts,
+		cohortCaps:         options.cohortCaps,
 	}
 	c.podsReadyCond.L = &c.RWMutex
 	return c
@@ -95,6 +129,21 @@ func New(client client.Client, opts ...Option) *Cache {
 
 type FlavorResourceQuantities map[kueue.ResourceFlavorReference]map[corev1.ResourceName]int64
 
+func (q FlavorResourceQuantities) clone() FlavorResourceQuantities {
+	if q == nil {
+		return nil
+	}
+	ret := make(FlavorResourceQuantities, len(q))
+	for fName, resQuantities := range q {
+		resCopy := make(map[corev1.ResourceName]int64, len(resQuantities))
+		for rName, v := range resQuantities {
+			resCopy[rName] = v
+		}
+		ret[fName] = resCopy
+	}
+	return ret
+}
+
 // Cohort is a set of ClusterQueues that can borrow resources from each other.
 type Cohort struct {
 	Name    string
@@ -103,6 +152,42 @@ type Cohort struct {
 	// These fields are only populated for a snapshot.
 	RequestableResources FlavorResourceQuantities
 	Usage                FlavorResourceQuantities
+	// ReservedUsage is the usage of the members within their headroom
+	// reservations, which is accounted in Usage but not in
+	// RequestableResources.
+	ReservedUsage FlavorResourceQuantities
+
+	// Parent is the optional cohort from which the members of this cohort can
+	// borrow once the quota in this cohort is exhausted. The requestable
+	// resources and usage of the parent include those of its descendants.
+	Parent *Cohort
+
+	// FairSharing, when true, limits the quota that the members can borrow
+	// without preemption to their fair share of the cohort capacity.
+	FairSharing bool
+
+	// Caps, when set for a resource in a flavor, are the maximum quantities
+	// that the members can use in total, even if the sum of their nominal
+	// quotas is higher, like the physical number of GPUs in the cluster.
+	Caps FlavorResourceQuantities
+}
+
+// Summary returns copies of the requestable resources and usage aggregated
+// across all the ClusterQueues in the cohort. They are only populated for a
+// snapshot.
+func (c *Cohort) Summary() (requestable, usage FlavorResourceQuantities) {
+	return c.RequestableResources.clone(), c.Usage.clone()
+}
+
+// ParentCanLend returns whether any of the ancestors of the cohort has enough
+// unused quota of the resource in the flavor to lend the value.
+func (c *Cohort) ParentCanLend(fName kueue.ResourceFlavorReference, rName corev1.ResourceName, val int64) bool {
+	for parent := c.Parent; parent != nil; parent = parent.Parent {
+		if parent.RequestableResources[fName][rName]+parent.ReservedUsage[fName][rName]-parent.Usage[fName][rName] >= val {
+			return true
+		}
+	}
+	return false
 }
 
 func newCohort(name string, size int) *Cohort {
@@ -131,6 +216,26 @@ type ClusterQueue struct {
 	NamespaceSelector labels.Selector
 	Preemption        kueue.ClusterQueuePreemption
 	Status            metrics.ClusterQueueStatus
+	// FairSharingWeight is the weight of the ClusterQueue when dividing the
+	// capacity of the cohort among its members.
+	FairSharingWeight int64
+	// BorrowableFlavors are, per resource, the only flavors in which the
+	// ClusterQueue can borrow from the cohort. Resources not present can be
+	// borrowed in any flavor.
+	BorrowableFlavors map[corev1.ResourceName]sets.Set[kueue.ResourceFlavorReference]
+	// FlavorSelectionPolicy is how to choose among equally good flavors.
+	FlavorSelectionPolicy kueue.FlavorSelectionPolicy
+	// UsageByClass is the usage of the admitted workloads of each class, see
+	// kueue.WorkloadClassLabel.
+	UsageByClass map[string]FlavorResourceQuantities
+	// WorkloadsByFlavor is the number of admitted workloads that have each
+	// flavor assigned to any of their resources.
+	WorkloadsByFlavor map[kueue.ResourceFlavorReference]int
+
+	// generation is incremented every time the quotas, flavors or usage of
+	// the ClusterQueue or of the other members of its cohort change, or the
+	// members of the cohort change.
+	generation int64
 
 	// The following fields are not populated in a snapshot.
 
@@ -158,11 +263,104 @@ type ResourceGroup struct {
 type FlavorQuotas struct {
 	Name      kueue.ResourceFlavorReference
 	Resources map[corev1.ResourceName]*ResourceQuota
+	// MaxConcurrentWorkloads, when set, is the maximum number of workloads
+	// of the ClusterQueue that can be admitted in the flavor at the same
+	// time, regardless of the quota. The workloads admitted in the flavor by
+	// other ClusterQueues don't count towards it.
+	MaxConcurrentWorkloads *int32
+	// ClassShares, when set, are the fractions of the nominal quota of each
+	// resource in the flavor that are reserved for the workloads of each
+	// class. Once its share is used, a class falls back to the rest of the
+	// quota, shared by all workloads.
+	ClassShares map[string]float64
 }
 
 type ResourceQuota struct {
 	Nominal        int64
 	BorrowingLimit *int64
+	// Granularity, when set, is the multiple in which the resource is
+	// allocatable. Requests are rounded up to it for quota purposes, and
+	// the rounded requests are charged as usage.
+	Granularity *int64
+	// HeadroomReservation, when set, is the part of the nominal quota that is
+	// not lent to other ClusterQueues in the cohort.
+	HeadroomReservation *int64
+	// OvercommitRatio, when set, is the factor by which the nominal quota can
+	// be exceeded when determining whether requests fit. Usage is still
+	// tracked with the real requests.
+	OvercommitRatio *float64
+}
+
+// OvercommittedNominal returns the nominal quota multiplied by the overcommit
+// ratio, if any.
+func (q *ResourceQuota) OvercommittedNominal() int64 {
+	if q.OvercommitRatio == nil {
+		return q.Nominal
+	}
+	return int64(float64(q.Nominal) * *q.OvercommitRatio)
+}
+
+// RoundUp returns the value rounded up to the next multiple of the
+// granularity, if any.
+func (q *ResourceQuota) RoundUp(val int64) int64 {
+	if q.Granularity == nil || *q.Granularity <= 1 {
+		return val
+	}
+	g := *q.Granularity
+	return (val + g - 1) / g * g
+}
+
+// lendable returns the part of the nominal quota that can be lent to the
+// cohort.
+func (q *ResourceQuota) lendable() int64 {
+	if q.HeadroomReservation == nil {
+		return q.Nominal
+	}
+	if *q.HeadroomReservation >= q.Nominal {
+		return 0
+	}
+	return q.Nominal - *q.HeadroomReservation
+}
+
+// RequestableCohortQuota returns the quota of the resource in the flavor that
+// the ClusterQueue can use from its cohort. That is, the quota lent by all the
+// members of the cohort plus the ClusterQueue's own headroom reservation.
+// The usage of the other members within their headroom reservations is added
+// as well, as it's accounted in the cohort usage.
+func (c *ClusterQueue) RequestableCohortQuota(fName kueue.ResourceFlavorReference, rName corev1.ResourceName) int64 {
+	if c.Cohort == nil {
+		return 0
+	}
+	requestable := c.Cohort.RequestableResources[fName][rName] + c.Cohort.ReservedUsage[fName][rName]
+	if rQuota := c.resourceQuota(fName, rName); rQuota != nil {
+		requestable += rQuota.Nominal - rQuota.lendable() - reservedUsage(rQuota, c.Usage[fName][rName])
+	}
+	return requestable
+}
+
+// reservedUsage returns the part of the usage that is within the headroom
+// reservation of the quota.
+func reservedUsage(rQuota *ResourceQuota, used int64) int64 {
+	reserved := rQuota.Nominal - rQuota.lendable()
+	if used < reserved {
+		return used
+	}
+	return reserved
+}
+
+// resourceQuota returns the quota for the resource in the flavor, or nil if
+// the ClusterQueue doesn't define it.
+func (c *ClusterQueue) resourceQuota(fName kueue.ResourceFlavorReference, rName corev1.ResourceName) *ResourceQuota {
+	rg := c.RGByResource[rName]
+	if rg == nil {
+		return nil
+	}
+	for _, flvQuotas := range rg.Flavors {
+		if flvQuotas.Name == fName {
+			return flvQuotas.Resources[rName]
+		}
+	}
+	return nil
 }
 
 func (c *Cache) newClusterQueue(cq *kueue.ClusterQueue) (*ClusterQueue, error) {
@@ -278,12 +476,25 @@ func (c *ClusterQueue) update(in *kueue.ClusterQueue, resourceFlavors map[kueue.
 	}
 	c.Usage = usedFlavorResources
 	c.UpdateWithFlavors(resourceFlavors)
+	c.touch()
 
 	if in.Spec.Preemption != nil {
 		c.Preemption = *in.Spec.Preemption
 	} else {
 		c.Preemption = defaultPreemption
 	}
+	c.FairSharingWeight = 1
+	if in.Spec.FairSharingWeight != nil {
+		c.FairSharingWeight = *in.Spec.FairSharingWeight
+	}
+	c.BorrowableFlavors = nil
+	if len(in.Spec.BorrowableFlavors) > 0 {
+		c.BorrowableFlavors = make(map[corev1.ResourceName]sets.Set[kueue.ResourceFlavorReference], len(in.Spec.BorrowableFlavors))
+		for _, bf := range in.Spec.BorrowableFlavors {
+			c.BorrowableFlavors[bf.Name] = sets.New(bf.Flavors...)
+		}
+	}
+	c.FlavorSelectionPolicy = in.Spec.FlavorSelectionPolicy
 
 	return nil
 }
@@ -376,7 +587,8 @@ func (c *ClusterQueue) addWorkload(w *kueue.Workload) error {
 	wi := workload.NewInfo(w)
 	c.Workloads[k] = wi
 	c.updateWorkloadUsage(wi, 1)
-	if c.podsReadyTracking && !apimeta.IsStatusConditionTrue(w.Status.Conditions, kueue.WorkloadPodsReady) {
+	// Reservation-only workloads don't have pods to wait for.
+	if c.podsReadyTracking && !workload.IsReservationOnly(w) && !apimeta.IsStatusConditionTrue(w.Status.Conditions, kueue.WorkloadPodsReady) {
 		c.WorkloadsNotReady.Insert(k)
 	}
 	reportAdmittedActiveWorkloads(wi.ClusterQueue, len(c.Workloads))
@@ -397,10 +609,166 @@ func (c *ClusterQueue) deleteWorkload(w *kueue.Workload) {
 	reportAdmittedActiveWorkloads(wi.ClusterQueue, len(c.Workloads))
 }
 
+// WorkloadsInFlavor returns the number of admitted workloads of the
+// ClusterQueue that have the flavor assigned to any of their resources.
+func (c *ClusterQueue) WorkloadsInFlavor(fName kueue.ResourceFlavorReference) int {
+	return c.WorkloadsByFlavor[fName]
+}
+
+// Generation returns a counter that changes every time the quotas, flavors or
+// usage of the ClusterQueue or its cohort change, so that computations based
+// on an older state can be detected.
+func (c *ClusterQueue) Generation() int64 {
+	return c.generation
+}
+
+// touch increments the generation of the ClusterQueue and, as they share the
+// capacity of the cohort, of the other members of its cohort.
+func (c *ClusterQueue) touch() {
+	c.generation++
+	if c.Cohort == nil {
+		return
+	}
+	for member := range c.Cohort.Members {
+		if member != c {
+			member.generation++
+		}
+	}
+}
+
+// CanBorrowInFlavor returns whether the ClusterQueue is allowed to borrow the
+// resource in the flavor from its cohort.
+func (c *ClusterQueue) CanBorrowInFlavor(fName kueue.ResourceFlavorReference, rName corev1.ResourceName) bool {
+	flavors, restricted := c.BorrowableFlavors[rName]
+	return !restricted || flavors.Has(fName)
+}
+
+// ClassQuota returns the part of the nominal quota of the resource in the
+// flavor that is reserved for the workloads of the class, or nil if the class
+// has no share.
+func (c *ClusterQueue) ClassQuota(class string, fName kueue.ResourceFlavorReference, rName corev1.ResourceName) *int64 {
+	if class == "" {
+		return nil
+	}
+	flvQuotas := c.flavorQuotas(fName)
+	if flvQuotas == nil {
+		return nil
+	}
+	share, found := flvQuotas.ClassShares[class]
+	rQuota := flvQuotas.Resources[rName]
+	if !found || rQuota == nil {
+		return nil
+	}
+	q := int64(float64(rQuota.Nominal) * share)
+	return &q
+}
+
+// ClassUsage returns the quantity of the resource in the flavor used by the
+// admitted workloads of the class.
+func (c *ClusterQueue) ClassUsage(class string, fName kueue.ResourceFlavorReference, rName corev1.ResourceName) int64 {
+	return c.UsageByClass[class][fName][rName]
+}
+
+// ReservedClassQuota returns the part of the shares of the resource in the
+// flavor that the classes, other than the given one, don't use. It is reserved
+// for them and can't be used by other workloads.
+func (c *ClusterQueue) ReservedClassQuota(class string, fName kueue.ResourceFlavorReference, rName corev1.ResourceName) int64 {
+	flvQuotas := c.flavorQuotas(fName)
+	if flvQuotas == nil {
+		return 0
+	}
+	var reserved int64
+	for other := range flvQuotas.ClassShares {
+		if other == class {
+			continue
+		}
+		if q := c.ClassQuota(other, fName, rName); q != nil {
+			if unused := *q - c.ClassUsage(other, fName, rName); unused > 0 {
+				reserved += unused
+			}
+		}
+	}
+	return reserved
+}
+
+// HasClassShare returns whether the class has a share of the quota in any of
+// the flavors of the ClusterQueue.
+func (c *ClusterQueue) HasClassShare(class string) bool {
+	if class == "" {
+		return false
+	}
+	for _, rg := range c.ResourceGroups {
+		for _, flvQuotas := range rg.Flavors {
+			if _, found := flvQuotas.ClassShares[class]; found {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// flavorQuotas returns the quotas of the flavor, or nil if the ClusterQueue
+// doesn't define it.
+func (c *ClusterQueue) flavorQuotas(fName kueue.ResourceFlavorReference) *FlavorQuotas {
+	for i := range c.ResourceGroups {
+		for j := range c.ResourceGroups[i].Flavors {
+			if c.ResourceGroups[i].Flavors[j].Name == fName {
+				return &c.ResourceGroups[i].Flavors[j]
+			}
+		}
+	}
+	return nil
+}
+
+// updateClassUsage updates the usage of the class of the workload, if any.
+func (c *ClusterQueue) updateClassUsage(wi *workload.Info, m int64) {
+	class := wi.Obj.Labels[kueue.WorkloadClassLabel]
+	if class == "" {
+		return
+	}
+	if c.UsageByClass == nil {
+		c.UsageByClass = make(map[string]FlavorResourceQuantities)
+	}
+	usage := c.UsageByClass[class]
+	if usage == nil {
+		usage = make(FlavorResourceQuantities)
+		c.UsageByClass[class] = usage
+	}
+	for _, ps := range wi.TotalRequests {
+		for rName, fName := range ps.Flavors {
+			v, found := ps.Requests[rName]
+			if !found {
+				continue
+			}
+			if usage[fName] == nil {
+				usage[fName] = make(map[corev1.ResourceName]int64)
+			}
+			usage[fName][rName] += v * m
+		}
+	}
+}
+
+// updateFlavorWorkloads updates the number of admitted workloads in each of
+// the flavors assigned to the workload.
+func (c *ClusterQueue) updateFlavorWorkloads(wi *workload.Info, m int64) {
+	for fName := range wi.AssignedFlavors() {
+		if c.WorkloadsByFlavor == nil {
+			c.WorkloadsByFlavor = make(map[kueue.ResourceFlavorReference]int)
+		}
+		c.WorkloadsByFlavor[fName] += int(m)
+		if c.WorkloadsByFlavor[fName] <= 0 {
+			delete(c.WorkloadsByFlavor, fName)
+		}
+	}
+}
+
 // updateWorkloadUsage updates the usage of the ClusterQueue for the workload
 // and the number of admitted workloads for local queues.
 func (c *ClusterQueue) updateWorkloadUsage(wi *workload.Info, m int64) {
 	updateUsage(wi, c.Usage, m)
+	c.updateClassUsage(wi, m)
+	c.updateFlavorWorkloads(wi, m)
+	c.touch()
 	qKey := workload.QueueKey(wi.Obj)
 	if _, ok := c.localQueues[qKey]; ok {
 		updateUsage(wi, c.localQueues[qKey].usage, m)
@@ -422,6 +790,25 @@ func updateUsage(wi *workload.Info, flvUsage FlavorResourceQuantities, m int64)
 	}
 }
 
+// DominantResourceShare returns the dominant resource share of the requests,
+// that is, the maximum ratio between the requested quantity and the capacity
+// in the given flavor, among all the requested resources.
+// Resources without capacity in the flavor are ignored.
+func DominantResourceShare(req workload.Requests, capacity FlavorResourceQuantities, flavor kueue.ResourceFlavorReference) float64 {
+	flvCapacity := capacity[flavor]
+	var share float64
+	for rName, v := range req {
+		c := flvCapacity[rName]
+		if c <= 0 {
+			continue
+		}
+		if s := float64(v) / float64(c); s > share {
+			share = s
+		}
+	}
+	return share
+}
+
 func (c *ClusterQueue) addLocalQueue(q *kueue.LocalQueue) error {
 	qKey := queueKey(q)
 	if _, ok := c.localQueues[qKey]; ok {
@@ -487,6 +874,7 @@ func (c *Cache) updateClusterQueues() sets.Set[string] {
 		// because it is not expensive to do so, and is not worth tracking which ClusterQueues use
 		// which flavors.
 		cq.UpdateWithFlavors(c.resourceFlavors)
+		cq.touch()
 		curStatus := cq.Status
 		if prevStatus == pending && curStatus == active {
 			cqs.Insert(cq.Name)
@@ -925,12 +1313,14 @@ func (c *Cache) addClusterQueueToCohort(cq *ClusterQueue, cohortName string) {
 	}
 	cohort.Members.Insert(cq)
 	cq.Cohort = cohort
+	cq.touch()
 }
 
 func (c *Cache) deleteClusterQueueFromCohort(cq *ClusterQueue) {
 	if cq.Cohort == nil {
 		return
 	}
+	cq.touch()
 	cq.Cohort.Members.Delete(cq)
 	if cq.Cohort.Members.Len() == 0 {
 		delete(c.cohorts, cq.Cohort.Name)
@@ -111,8 +111,9 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"default": {corev1.ResourceCPU: 0},
 					},
-					Status:     active,
-					Preemption: defaultPreemption,
+					Status:            active,
+					FairSharingWeight: 1,
+					Preemption:        defaultPreemption,
 				},
 				"b": {
 					Name: "b",
@@ -132,8 +133,9 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"default": {corev1.ResourceCPU: 0},
 					},
-					Status:     active,
-					Preemption: defaultPreemption,
+					Status:            active,
+					FairSharingWeight: 1,
+					Preemption:        defaultPreemption,
 				},
 				"c": {
 					Name:              "c",
@@ -141,6 +143,7 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					NamespaceSelector: labels.Nothing(),
 					Usage:             FlavorResourceQuantities{},
 					Status:            active,
+					FairSharingWeight: 1,
 					Preemption:        defaultPreemption,
 				},
 				"d": {
@@ -149,6 +152,7 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					NamespaceSelector: labels.Nothing(),
 					Usage:             FlavorResourceQuantities{},
 					Status:            active,
+					FairSharingWeight: 1,
 					Preemption:        defaultPreemption,
 				},
 				"e": {
@@ -168,8 +172,9 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"nonexistent-flavor": {corev1.ResourceCPU: 0},
 					},
-					Status:     pending,
-					Preemption: defaultPreemption,
+					Status:            pending,
+					FairSharingWeight: 1,
+					Preemption:        defaultPreemption,
 				},
 			},
 			wantCohorts: map[string]sets.Set[string]{
@@ -193,6 +198,7 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Name:              "foo",
 					NamespaceSelector: labels.Everything(),
 					Status:            active,
+					FairSharingWeight: 1,
 					Preemption: kueue.ClusterQueuePreemption{
 						ReclaimWithinCohort: kueue.PreemptionPolicyLowerPriority,
 						WithinClusterQueue:  kueue.PreemptionPolicyLowerPriority,
@@ -233,8 +239,9 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"default": {corev1.ResourceCPU: 0},
 					},
-					Status:     active,
-					Preemption: defaultPreemption,
+					Status:            active,
+					FairSharingWeight: 1,
+					Preemption:        defaultPreemption,
 				},
 				"b": {
 					Name: "b",
@@ -254,8 +261,9 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"default": {corev1.ResourceCPU: 0},
 					},
-					Status:     active,
-					Preemption: defaultPreemption,
+					Status:            active,
+					FairSharingWeight: 1,
+					Preemption:        defaultPreemption,
 				},
 				"c": {
 					Name:              "c",
@@ -263,6 +271,7 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					NamespaceSelector: labels.Nothing(),
 					Usage:             FlavorResourceQuantities{},
 					Status:            active,
+					FairSharingWeight: 1,
 					Preemption:        defaultPreemption,
 				},
 				"d": {
@@ -271,6 +280,7 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					NamespaceSelector: labels.Nothing(),
 					Usage:             FlavorResourceQuantities{},
 					Status:            active,
+					FairSharingWeight: 1,
 					Preemption:        defaultPreemption,
 				},
 				"e": {
@@ -292,8 +302,9 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"nonexistent-flavor": {corev1.ResourceCPU: 0},
 					},
-					Status:     pending,
-					Preemption: defaultPreemption,
+					Status:            pending,
+					FairSharingWeight: 1,
+					Preemption:        defaultPreemption,
 				},
 			},
 			wantCohorts: map[string]sets.Set[string]{
@@ -354,8 +365,9 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"default": {corev1.ResourceCPU: 0},
 					},
-					Status:     active,
-					Preemption: defaultPreemption,
+					Status:            active,
+					FairSharingWeight: 1,
+					Preemption:        defaultPreemption,
 				},
 				"b": {
 					Name:              "b",
@@ -363,6 +375,7 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					NamespaceSelector: labels.Everything(),
 					Usage:             FlavorResourceQuantities{},
 					Status:            active,
+					FairSharingWeight: 1,
 					Preemption:        defaultPreemption,
 				},
 				"c": {
@@ -371,6 +384,7 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					NamespaceSelector: labels.Nothing(),
 					Usage:             FlavorResourceQuantities{},
 					Status:            active,
+					FairSharingWeight: 1,
 					Preemption:        defaultPreemption,
 				},
 				"d": {
@@ -379,6 +393,7 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					NamespaceSelector: labels.Nothing(),
 					Usage:             FlavorResourceQuantities{},
 					Status:            active,
+					FairSharingWeight: 1,
 					Preemption:        defaultPreemption,
 				},
 				"e": {
@@ -400,8 +415,9 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"default": {corev1.ResourceCPU: 0},
 					},
-					Status:     active,
-					Preemption: defaultPreemption,
+					Status:            active,
+					FairSharingWeight: 1,
+					Preemption:        defaultPreemption,
 				},
 			},
 			wantCohorts: map[string]sets.Set[string]{
@@ -438,8 +454,9 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"default": {corev1.ResourceCPU: 0},
 					},
-					Status:     active,
-					Preemption: defaultPreemption,
+					Status:            active,
+					FairSharingWeight: 1,
+					Preemption:        defaultPreemption,
 				},
 				"c": {
 					Name:              "c",
@@ -447,6 +464,7 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					NamespaceSelector: labels.Nothing(),
 					Usage:             FlavorResourceQuantities{},
 					Status:            active,
+					FairSharingWeight: 1,
 					Preemption:        defaultPreemption,
 				},
 				"e": {
@@ -468,8 +486,9 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"nonexistent-flavor": {corev1.ResourceCPU: 0},
 					},
-					Status:     pending,
-					Preemption: defaultPreemption,
+					Status:            pending,
+					FairSharingWeight: 1,
+					Preemption:        defaultPreemption,
 				},
 			},
 			wantCohorts: map[string]sets.Set[string]{
@@ -505,8 +524,9 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"default": {corev1.ResourceCPU: 0},
 					},
-					Status:     active,
-					Preemption: defaultPreemption,
+					Status:            active,
+					FairSharingWeight: 1,
+					Preemption:        defaultPreemption,
 				},
 				"b": {
 					Name: "b",
@@ -526,8 +546,9 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					Usage: FlavorResourceQuantities{
 						"default": {corev1.ResourceCPU: 0},
 					},
-					Status:     active,
-					Preemption: defaultPreemption,
+					Status:            active,
+					FairSharingWeight: 1,
+					Preemption:        defaultPreemption,
 				},
 				"c": {
 					Name:              "c",
@@ -535,6 +556,7 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					NamespaceSelector: labels.Nothing(),
 					Usage:             FlavorResourceQuantities{},
 					Status:            active,
+					FairSharingWeight: 1,
 					Preemption:        defaultPreemption,
 				},
 				"d": {
@@ -543,6 +565,7 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					NamespaceSelector: labels.Nothing(),
 					Usage:             FlavorResourceQuantities{},
 					Status:            active,
+					FairSharingWeight: 1,
 					Preemption:        defaultPreemption,
 				},
 				"e": {
@@ -561,6 +584,7 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 					NamespaceSelector: labels.Nothing(),
 					Usage:             FlavorResourceQuantities{"nonexistent-flavor": {corev1.ResourceCPU: 0}},
 					Status:            active,
+					FairSharingWeight: 1,
 					Preemption:        defaultPreemption,
 				},
 			},
@@ -651,8 +675,9 @@ func TestCacheClusterQueueOperations(t *testing.T) {
 							"example.com/gpu": 0,
 						},
 					},
-					Status:     pending,
-					Preemption: defaultPreemption,
+					Status:            pending,
+					FairSharingWeight: 1,
+					Preemption:        defaultPreemption,
 				},
 			},
 		},
@@ -1463,6 +1488,159 @@ func TestClusterQueueUsage(t *testing.T) {
 	}
 }
 
+func TestClusterQueueClassUsage(t *testing.T) {
+	cq := utiltesting.MakeClusterQueue("foo").
+		ResourceGroup(
+			*utiltesting.MakeFlavorQuotas("default").
+				Resource(corev1.ResourceCPU, "10").
+				Obj(),
+		).Obj()
+	workloads := []*kueue.Workload{
+		utiltesting.MakeWorkload("one", "").
+			Label(kueue.WorkloadClassLabel, "inference").
+			Request(corev1.ResourceCPU, "2").
+			Admit(utiltesting.MakeAdmission("foo").Assignment(corev1.ResourceCPU, "default", "2").Obj()).
+			Obj(),
+		utiltesting.MakeWorkload("two", "").
+			Label(kueue.WorkloadClassLabel, "inference").
+			Request(corev1.ResourceCPU, "3").
+			Admit(utiltesting.MakeAdmission("foo").Assignment(corev1.ResourceCPU, "default", "3").Obj()).
+			Obj(),
+		utiltesting.MakeWorkload("three", "").
+			Request(corev1.ResourceCPU, "1").
+			Admit(utiltesting.MakeAdmission("foo").Assignment(corev1.ResourceCPU, "default", "1").Obj()).
+			Obj(),
+	}
+	cases := map[string]struct {
+		remove []string
+		want   int64
+	}{
+		"all admitted": {
+			want: 5_000,
+		},
+		"deleted workload": {
+			remove: []string{"one"},
+			want:   3_000,
+		},
+		"deleted workload without class": {
+			remove: []string{"three"},
+			want:   5_000,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cache := New(utiltesting.NewFakeClient())
+			cache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("default").Obj())
+			if err := cache.AddClusterQueue(context.Background(), cq); err != nil {
+				t.Fatalf("Adding ClusterQueue: %v", err)
+			}
+			for _, w := range workloads {
+				if added := cache.AddOrUpdateWorkload(w); !added {
+					t.Fatalf("Workload %s was not added", workload.Key(w))
+				}
+			}
+			remove := sets.New(tc.remove...)
+			for _, w := range workloads {
+				if remove.Has(w.Name) {
+					if err := cache.DeleteWorkload(w); err != nil {
+						t.Fatalf("Deleting workload %s: %v", workload.Key(w), err)
+					}
+				}
+			}
+			got := cache.clusterQueues["foo"].ClassUsage("inference", "default", corev1.ResourceCPU)
+			if got != tc.want {
+				t.Errorf("ClassUsage(inference)=%d, want %d", got, tc.want)
+			}
+			snapshot := cache.Snapshot()
+			if got := snapshot.ClusterQueues["foo"].ClassUsage("inference", "default", corev1.ResourceCPU); got != tc.want {
+				t.Errorf("ClassUsage(inference) in snapshot=%d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestClusterQueueWorkloadsInFlavor(t *testing.T) {
+	cq := utiltesting.MakeClusterQueue("foo").
+		ResourceGroup(
+			*utiltesting.MakeFlavorQuotas("one").
+				Resource(corev1.ResourceCPU, "10").
+				Obj(),
+			*utiltesting.MakeFlavorQuotas("two").
+				Resource(corev1.ResourceCPU, "10").
+				Obj(),
+		).Obj()
+	workloads := []*kueue.Workload{
+		utiltesting.MakeWorkload("a", "").
+			Request(corev1.ResourceCPU, "2").
+			Admit(utiltesting.MakeAdmission("foo").Assignment(corev1.ResourceCPU, "one", "2").Obj()).
+			Obj(),
+		utiltesting.MakeWorkload("b", "").
+			Request(corev1.ResourceCPU, "3").
+			Admit(utiltesting.MakeAdmission("foo").Assignment(corev1.ResourceCPU, "one", "3").Obj()).
+			Obj(),
+		utiltesting.MakeWorkload("c", "").
+			Request(corev1.ResourceCPU, "1").
+			Admit(utiltesting.MakeAdmission("foo").Assignment(corev1.ResourceCPU, "two", "1").Obj()).
+			Obj(),
+	}
+	cases := map[string]struct {
+		remove []string
+		want   map[kueue.ResourceFlavorReference]int
+	}{
+		"all admitted": {
+			want: map[kueue.ResourceFlavorReference]int{"one": 2, "two": 1},
+		},
+		"deleted workloads": {
+			remove: []string{"a", "c"},
+			want:   map[kueue.ResourceFlavorReference]int{"one": 1, "two": 0},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cache := New(utiltesting.NewFakeClient())
+			cache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("one").Obj())
+			cache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("two").Obj())
+			if err := cache.AddClusterQueue(context.Background(), cq); err != nil {
+				t.Fatalf("Adding ClusterQueue: %v", err)
+			}
+			for _, w := range workloads {
+				if added := cache.AddOrUpdateWorkload(w); !added {
+					t.Fatalf("Workload %s was not added", workload.Key(w))
+				}
+			}
+			remove := sets.New(tc.remove...)
+			for _, w := range workloads {
+				if remove.Has(w.Name) {
+					if err := cache.DeleteWorkload(w); err != nil {
+						t.Fatalf("Deleting workload %s: %v", workload.Key(w), err)
+					}
+				}
+			}
+			snapshot := cache.Snapshot()
+			for fName, want := range tc.want {
+				if got := cache.clusterQueues["foo"].WorkloadsInFlavor(fName); got != want {
+					t.Errorf("WorkloadsInFlavor(%s)=%d, want %d", fName, got, want)
+				}
+				if got := snapshot.ClusterQueues["foo"].WorkloadsInFlavor(fName); got != want {
+					t.Errorf("WorkloadsInFlavor(%s) in snapshot=%d, want %d", fName, got, want)
+				}
+			}
+			// The workloads removed from the snapshot, for example, by the
+			// preemption simulation, are no longer counted.
+			for _, w := range workloads {
+				if !remove.Has(w.Name) {
+					snapshot.RemoveWorkload(workload.NewInfo(w))
+				}
+			}
+			for fName := range tc.want {
+				if got := snapshot.ClusterQueues["foo"].WorkloadsInFlavor(fName); got != 0 {
+					t.Errorf("WorkloadsInFlavor(%s) in snapshot after removing the workloads=%d, want 0", fName, got)
+				}
+			}
+		})
+	}
+}
+
 func TestLocalQueueUsage(t *testing.T) {
 	cq := *utiltesting.MakeClusterQueue("foo").
 		ResourceGroup(
@@ -2235,6 +2413,17 @@ func TestCachePodsReadyForAllAdmittedWorkloads(t *testing.T) {
 			},
 			wantReady: true,
 		},
+		{
+			name: "add reservation-only Workload without PodsReady condition",
+			operation: func(cache *Cache) error {
+				wl := utiltesting.MakeWorkload("a", "").ReservationOnly().Admit(&kueue.Admission{
+					ClusterQueue: "one",
+				}).Obj()
+				cache.AddOrUpdateWorkload(wl)
+				return nil
+			},
+			wantReady: true,
+		},
 		{
 			name: "assume Workload without PodsReady condition",
 			operation: func(cache *Cache) error {
@@ -2541,6 +2730,155 @@ func TestIsAssumedOrAdmittedCheckWorkload(t *testing.T) {
 	}
 }
 
+func TestDominantResourceShare(t *testing.T) {
+	capacity := FlavorResourceQuantities{
+		"default": {
+			corev1.ResourceCPU:    10_000,
+			corev1.ResourceMemory: 64 * utiltesting.Gi,
+			"example.com/gpu":     8,
+		},
+	}
+	cases := map[string]struct {
+		req       workload.Requests
+		flavor    kueue.ResourceFlavorReference
+		wantShare float64
+	}{
+		"cpu dominates": {
+			req: workload.Requests{
+				corev1.ResourceCPU:    5_000,
+				corev1.ResourceMemory: 16 * utiltesting.Gi,
+			},
+			flavor:    "default",
+			wantShare: 0.5,
+		},
+		"gpu dominates": {
+			req: workload.Requests{
+				corev1.ResourceCPU:    1_000,
+				corev1.ResourceMemory: 8 * utiltesting.Gi,
+				"example.com/gpu":     6,
+			},
+			flavor:    "default",
+			wantShare: 0.75,
+		},
+		"resource without capacity": {
+			req: workload.Requests{
+				corev1.ResourceCPU: 1_000,
+				"example.com/tpu":  1,
+			},
+			flavor:    "default",
+			wantShare: 0.1,
+		},
+		"unknown flavor": {
+			req: workload.Requests{
+				corev1.ResourceCPU: 1_000,
+			},
+			flavor: "other",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := DominantResourceShare(tc.req, capacity, tc.flavor)
+			if got != tc.wantShare {
+				t.Errorf("DominantResourceShare() = %v, want %v", got, tc.wantShare)
+			}
+		})
+	}
+}
+
+func TestRequestableCohortQuota(t *testing.T) {
+	newCQ := func(name string, headroom *int64, used int64) *ClusterQueue {
+		cq := &ClusterQueue{
+			Name: name,
+			ResourceGroups: []ResourceGroup{{
+				CoveredResources: sets.New(corev1.ResourceCPU),
+				Flavors: []FlavorQuotas{{
+					Name: "default",
+					Resources: map[corev1.ResourceName]*ResourceQuota{
+						corev1.ResourceCPU: {Nominal: 10_000, HeadroomReservation: headroom},
+					},
+				}},
+			}},
+			Usage: FlavorResourceQuantities{
+				"default": {corev1.ResourceCPU: used},
+			},
+		}
+		cq.UpdateRGByResource()
+		return cq
+	}
+	cases := map[string]struct {
+		a, b *ClusterQueue
+		// addToB is the CPU request of a workload added to b once the
+		// cohort is built, if any.
+		addToB       int64
+		wantA, wantB int64
+	}{
+		"no headroom": {
+			a:     newCQ("a", nil, 0),
+			b:     newCQ("b", nil, 0),
+			wantA: 20_000,
+			wantB: 20_000,
+		},
+		"unused headroom is not lent": {
+			a:     newCQ("a", nil, 0),
+			b:     newCQ("b", pointer.Int64(4_000), 0),
+			wantA: 16_000,
+			wantB: 20_000,
+		},
+		"partially used headroom": {
+			a:     newCQ("a", nil, 0),
+			b:     newCQ("b", pointer.Int64(4_000), 3_000),
+			wantA: 19_000,
+			wantB: 20_000,
+		},
+		"headroom in both": {
+			a:     newCQ("a", pointer.Int64(2_000), 5_000),
+			b:     newCQ("b", pointer.Int64(4_000), 0),
+			wantA: 16_000,
+			wantB: 20_000,
+		},
+		"workload added within the headroom": {
+			a:      newCQ("a", nil, 0),
+			b:      newCQ("b", pointer.Int64(4_000), 1_000),
+			addToB: 2_000,
+			wantA:  19_000,
+			wantB:  20_000,
+		},
+		"workload added beyond the headroom": {
+			a:      newCQ("a", nil, 0),
+			b:      newCQ("b", pointer.Int64(4_000), 1_000),
+			addToB: 5_000,
+			wantA:  20_000,
+			wantB:  20_000,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cohort := newCohort("cohort", 2)
+			for _, cq := range []*ClusterQueue{tc.a, tc.b} {
+				cq.accumulateResources(cohort)
+				cq.Cohort = cohort
+				cohort.Members.Insert(cq)
+			}
+			if tc.addToB > 0 {
+				tc.b.updateSnapshotUsage(&workload.Info{
+					Obj: &kueue.Workload{},
+					TotalRequests: []workload.PodSetResources{{
+						Name:     "main",
+						Requests: workload.Requests{corev1.ResourceCPU: tc.addToB},
+						Flavors:  map[corev1.ResourceName]kueue.ResourceFlavorReference{corev1.ResourceCPU: "default"},
+					}},
+				}, 1)
+			}
+			if got := tc.a.RequestableCohortQuota("default", corev1.ResourceCPU); got != tc.wantA {
+				t.Errorf("RequestableCohortQuota for a = %d, want %d", got, tc.wantA)
+			}
+			if got := tc.b.RequestableCohortQuota("default", corev1.ResourceCPU); got != tc.wantB {
+				t.Errorf("RequestableCohortQuota for b = %d, want %d", got, tc.wantB)
+			}
+		})
+	}
+}
+
 func messageOrEmpty(err error) string {
 	if err == nil {
 		return ""
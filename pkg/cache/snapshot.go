@@ -35,10 +35,8 @@ type Snapshot struct {
 func (s *Snapshot) RemoveWorkload(wl *workload.Info) {
 	cq := s.ClusterQueues[wl.ClusterQueue]
 	delete(cq.Workloads, workload.Key(wl.Obj))
-	updateUsage(wl, cq.Usage, -1)
-	if cq.Cohort != nil {
-		updateUsage(wl, cq.Cohort.Usage, -1)
-	}
+	cq.updateSnapshotUsage(wl, -1)
+	cq.touch()
 }
 
 // AddWorkload removes a workload from its corresponding ClusterQueue and
@@ -46,9 +44,26 @@ func (s *Snapshot) RemoveWorkload(wl *workload.Info) {
 func (s *Snapshot) AddWorkload(wl *workload.Info) {
 	cq := s.ClusterQueues[wl.ClusterQueue]
 	cq.Workloads[workload.Key(wl.Obj)] = wl
-	updateUsage(wl, cq.Usage, 1)
-	if cq.Cohort != nil {
-		updateUsage(wl, cq.Cohort.Usage, 1)
+	cq.updateSnapshotUsage(wl, 1)
+	cq.touch()
+}
+
+// updateSnapshotUsage updates the usage of the ClusterQueue and its cohort,
+// including the usage within the headroom reservations, for the workload.
+func (c *ClusterQueue) updateSnapshotUsage(wl *workload.Info, m int64) {
+	c.updateClassUsage(wl, m)
+	c.updateFlavorWorkloads(wl, m)
+	if c.Cohort == nil {
+		updateUsage(wl, c.Usage, m)
+		return
+	}
+	for cohort := c.Cohort; cohort != nil; cohort = cohort.Parent {
+		c.accumulateReservedUsage(cohort, -1)
+	}
+	updateUsage(wl, c.Usage, m)
+	for cohort := c.Cohort; cohort != nil; cohort = cohort.Parent {
+		updateUsage(wl, cohort.Usage, m)
+		c.accumulateReservedUsage(cohort, 1)
 	}
 }
 
@@ -72,6 +87,7 @@ func (c *Cache) Snapshot() Snapshot {
 		// Shallow copy is enough
 		snap.ResourceFlavors[name] = rf
 	}
+	cohorts := make(map[string]*Cohort, len(c.cohorts))
 	for _, cohort := range c.cohorts {
 		cohortCopy := newCohort(cohort.Name, cohort.Members.Len())
 		for cq := range cohort.Members {
@@ -82,22 +98,74 @@ func (c *Cache) Snapshot() Snapshot {
 				cohortCopy.Members.Insert(cqCopy)
 			}
 		}
+		cohorts[cohort.Name] = cohortCopy
+	}
+	c.snapshotCohortParents(cohorts)
+	for name, cohort := range cohorts {
+		cohort.FairSharing = c.fairSharingCohorts.Has(name)
+		cohort.Caps = c.cohortCaps[name].clone()
+	}
+	for _, cq := range snap.ClusterQueues {
+		if cq.Cohort == nil {
+			continue
+		}
+		for parent := cq.Cohort.Parent; parent != nil; parent = parent.Parent {
+			cq.accumulateResources(parent)
+		}
 	}
 	return snap
 }
 
+// snapshotCohortParents sets the parents of the cohorts in the snapshot,
+// creating the parents that have no members of their own. A parent that
+// would make a cycle is ignored.
+func (c *Cache) snapshotCohortParents(cohorts map[string]*Cohort) {
+	names := sets.List(sets.KeySet(cohorts))
+	for i := 0; i < len(names); i++ {
+		parentName, found := c.cohortParents[names[i]]
+		if !found || parentName == "" {
+			continue
+		}
+		cohort := cohorts[names[i]]
+		parent := cohorts[parentName]
+		if parent == nil {
+			parent = newCohort(parentName, 0)
+			cohorts[parentName] = parent
+			names = append(names, parentName)
+		}
+		if !parent.descendsFrom(cohort) {
+			cohort.Parent = parent
+		}
+	}
+}
+
+// descendsFrom returns whether the cohort is the given cohort or one of its
+// descendants.
+func (c *Cohort) descendsFrom(ancestor *Cohort) bool {
+	for cohort := c; cohort != nil; cohort = cohort.Parent {
+		if cohort == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
 // Snapshot creates a copy of ClusterQueue that includes references to immutable
 // objects and deep copies of changing ones. A reference to the cohort is not included.
 func (c *ClusterQueue) snapshot() *ClusterQueue {
 	cc := &ClusterQueue{
-		Name:              c.Name,
-		ResourceGroups:    c.ResourceGroups, // Shallow copy is enough.
-		RGByResource:      c.RGByResource,   // Shallow copy is enough.
-		Usage:             make(FlavorResourceQuantities, len(c.Usage)),
-		Workloads:         make(map[string]*workload.Info, len(c.Workloads)),
-		Preemption:        c.Preemption,
-		NamespaceSelector: c.NamespaceSelector,
-		Status:            c.Status,
+		Name:                  c.Name,
+		ResourceGroups:        c.ResourceGroups, // Shallow copy is enough.
+		RGByResource:          c.RGByResource,   // Shallow copy is enough.
+		Usage:                 make(FlavorResourceQuantities, len(c.Usage)),
+		Workloads:             make(map[string]*workload.Info, len(c.Workloads)),
+		Preemption:            c.Preemption,
+		NamespaceSelector:     c.NamespaceSelector,
+		Status:                c.Status,
+		FairSharingWeight:     c.FairSharingWeight,
+		BorrowableFlavors:     c.BorrowableFlavors, // Shallow copy is enough.
+		FlavorSelectionPolicy: c.FlavorSelectionPolicy,
+		generation:            c.generation,
 	}
 	for fName, rUsage := range c.Usage {
 		rUsageCopy := make(map[corev1.ResourceName]int64, len(rUsage))
@@ -110,6 +178,18 @@ func (c *ClusterQueue) snapshot() *ClusterQueue {
 		// Shallow copy is enough.
 		cc.Workloads[k] = v
 	}
+	if c.UsageByClass != nil {
+		cc.UsageByClass = make(map[string]FlavorResourceQuantities, len(c.UsageByClass))
+		for class, usage := range c.UsageByClass {
+			cc.UsageByClass[class] = usage.clone()
+		}
+	}
+	if c.WorkloadsByFlavor != nil {
+		cc.WorkloadsByFlavor = make(map[kueue.ResourceFlavorReference]int, len(c.WorkloadsByFlavor))
+		for fName, count := range c.WorkloadsByFlavor {
+			cc.WorkloadsByFlavor[fName] = count
+		}
+	}
 	return cc
 }
 
@@ -125,7 +205,7 @@ func (c *ClusterQueue) accumulateResources(cohort *Cohort) {
 				cohort.RequestableResources[flvQuotas.Name] = res
 			}
 			for rName, rQuota := range flvQuotas.Resources {
-				res[rName] += rQuota.Nominal
+				res[rName] += rQuota.lendable()
 			}
 		}
 	}
@@ -142,4 +222,28 @@ func (c *ClusterQueue) accumulateResources(cohort *Cohort) {
 			used[res] += val
 		}
 	}
+	c.accumulateReservedUsage(cohort, 1)
+}
+
+// accumulateReservedUsage adds the usage of the ClusterQueue within its
+// headroom reservations to the cohort, multiplied by m.
+func (c *ClusterQueue) accumulateReservedUsage(cohort *Cohort, m int64) {
+	for _, rg := range c.ResourceGroups {
+		for _, flvQuotas := range rg.Flavors {
+			for rName, rQuota := range flvQuotas.Resources {
+				if rQuota.HeadroomReservation == nil {
+					continue
+				}
+				if cohort.ReservedUsage == nil {
+					cohort.ReservedUsage = make(FlavorResourceQuantities)
+				}
+				res := cohort.ReservedUsage[flvQuotas.Name]
+				if res == nil {
+					res = make(map[corev1.ResourceName]int64)
+					cohort.ReservedUsage[flvQuotas.Name] = res
+				}
+				res[rName] += m * reservedUsage(rQuota, c.Usage[flvQuotas.Name][rName])
+			}
+		}
+	}
 }
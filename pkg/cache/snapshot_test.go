@@ -70,7 +70,8 @@ func TestSnapshot(t *testing.T) {
 								utiltesting.MakeWorkload("alpha", "").
 									Admit(&kueue.Admission{ClusterQueue: "a"}).Obj()),
 						},
-						Preemption: defaultPreemption,
+						FairSharingWeight: 1,
+						Preemption:        defaultPreemption,
 					},
 					"b": {
 						Name:              "b",
@@ -81,7 +82,8 @@ func TestSnapshot(t *testing.T) {
 								utiltesting.MakeWorkload("beta", "").
 									Admit(&kueue.Admission{ClusterQueue: "b"}).Obj()),
 						},
-						Preemption: defaultPreemption,
+						FairSharingWeight: 1,
+						Preemption:        defaultPreemption,
 					},
 				},
 			},
@@ -244,6 +246,8 @@ func TestSnapshot(t *testing.T) {
 									Admit(utiltesting.MakeAdmission("a", "main").Assignment(corev1.ResourceCPU, "demand", "10000m").Obj()).
 									Obj()),
 							},
+							WorkloadsByFlavor: map[kueue.ResourceFlavorReference]int{"demand": 1},
+							FairSharingWeight: 1,
 							Preemption:        defaultPreemption,
 							NamespaceSelector: labels.Everything(),
 							Status:            active,
@@ -297,6 +301,8 @@ func TestSnapshot(t *testing.T) {
 									Admit(utiltesting.MakeAdmission("b", "main").Assignment(corev1.ResourceCPU, "spot", "5000m").Assignment("example.com/gpu", "default", "5").Obj()).
 									Obj()),
 							},
+							WorkloadsByFlavor: map[kueue.ResourceFlavorReference]int{"default": 2, "spot": 2},
+							FairSharingWeight: 1,
 							Preemption:        defaultPreemption,
 							NamespaceSelector: labels.Everything(),
 							Status:            active,
@@ -319,6 +325,7 @@ func TestSnapshot(t *testing.T) {
 									corev1.ResourceCPU: 0,
 								},
 							},
+							FairSharingWeight: 1,
 							Preemption:        defaultPreemption,
 							NamespaceSelector: labels.Everything(),
 							Status:            active,
@@ -347,6 +354,7 @@ func TestSnapshot(t *testing.T) {
 						NamespaceSelector: labels.Everything(),
 						Status:            active,
 						Workloads:         map[string]*workload.Info{},
+						FairSharingWeight: 1,
 						Preemption: kueue.ClusterQueuePreemption{
 							ReclaimWithinCohort: kueue.PreemptionPolicyAny,
 							WithinClusterQueue:  kueue.PreemptionPolicyLowerPriority,
@@ -483,10 +491,11 @@ func TestSnapshotAddRemoveWorkload(t *testing.T) {
 				return Snapshot{
 					ClusterQueues: map[string]*ClusterQueue{
 						"c1": {
-							Name:           "c1",
-							Cohort:         cohort,
-							Workloads:      make(map[string]*workload.Info),
-							ResourceGroups: cqCache.clusterQueues["c1"].ResourceGroups,
+							Name:              "c1",
+							Cohort:            cohort,
+							Workloads:         make(map[string]*workload.Info),
+							ResourceGroups:    cqCache.clusterQueues["c1"].ResourceGroups,
+							FairSharingWeight: 1,
 							Usage: FlavorResourceQuantities{
 								"default": {corev1.ResourceCPU: 0},
 								"alpha":   {corev1.ResourceMemory: 0},
@@ -494,10 +503,11 @@ func TestSnapshotAddRemoveWorkload(t *testing.T) {
 							},
 						},
 						"c2": {
-							Name:           "c2",
-							Cohort:         cohort,
-							Workloads:      make(map[string]*workload.Info),
-							ResourceGroups: cqCache.clusterQueues["c2"].ResourceGroups,
+							Name:              "c2",
+							Cohort:            cohort,
+							Workloads:         make(map[string]*workload.Info),
+							ResourceGroups:    cqCache.clusterQueues["c2"].ResourceGroups,
+							FairSharingWeight: 1,
 							Usage: FlavorResourceQuantities{
 								"default": {corev1.ResourceCPU: 0},
 							},
@@ -527,7 +537,9 @@ func TestSnapshotAddRemoveWorkload(t *testing.T) {
 								"/c1-memory-alpha": nil,
 								"/c1-memory-beta":  nil,
 							},
-							ResourceGroups: cqCache.clusterQueues["c1"].ResourceGroups,
+							WorkloadsByFlavor: map[kueue.ResourceFlavorReference]int{"alpha": 1, "beta": 1},
+							ResourceGroups:    cqCache.clusterQueues["c1"].ResourceGroups,
+							FairSharingWeight: 1,
 							Usage: FlavorResourceQuantities{
 								"default": {corev1.ResourceCPU: 0},
 								"alpha":   {corev1.ResourceMemory: utiltesting.Gi},
@@ -541,7 +553,9 @@ func TestSnapshotAddRemoveWorkload(t *testing.T) {
 								"/c2-cpu-1": nil,
 								"/c2-cpu-2": nil,
 							},
-							ResourceGroups: cqCache.clusterQueues["c2"].ResourceGroups,
+							WorkloadsByFlavor: map[kueue.ResourceFlavorReference]int{"default": 2},
+							ResourceGroups:    cqCache.clusterQueues["c2"].ResourceGroups,
+							FairSharingWeight: 1,
 							Usage: FlavorResourceQuantities{
 								"default": {corev1.ResourceCPU: 2_000},
 							},
@@ -571,7 +585,9 @@ func TestSnapshotAddRemoveWorkload(t *testing.T) {
 								"/c1-memory-alpha": nil,
 								"/c1-memory-beta":  nil,
 							},
-							ResourceGroups: cqCache.clusterQueues["c1"].ResourceGroups,
+							WorkloadsByFlavor: map[kueue.ResourceFlavorReference]int{"beta": 1, "default": 1},
+							ResourceGroups:    cqCache.clusterQueues["c1"].ResourceGroups,
+							FairSharingWeight: 1,
 							Usage: FlavorResourceQuantities{
 								"default": {corev1.ResourceCPU: 1_000},
 								"alpha":   {corev1.ResourceMemory: 0},
@@ -585,7 +601,9 @@ func TestSnapshotAddRemoveWorkload(t *testing.T) {
 								"/c2-cpu-1": nil,
 								"/c2-cpu-2": nil,
 							},
-							ResourceGroups: cqCache.clusterQueues["c2"].ResourceGroups,
+							WorkloadsByFlavor: map[kueue.ResourceFlavorReference]int{"default": 2},
+							ResourceGroups:    cqCache.clusterQueues["c2"].ResourceGroups,
+							FairSharingWeight: 1,
 							Usage: FlavorResourceQuantities{
 								"default": {corev1.ResourceCPU: 2_000},
 							},
@@ -614,3 +632,321 @@ func TestSnapshotAddRemoveWorkload(t *testing.T) {
 		})
 	}
 }
+
+func TestCohortSummary(t *testing.T) {
+	flavors := []*kueue.ResourceFlavor{
+		utiltesting.MakeResourceFlavor("default").Obj(),
+		utiltesting.MakeResourceFlavor("alpha").Obj(),
+	}
+	clusterQueues := []*kueue.ClusterQueue{
+		utiltesting.MakeClusterQueue("c1").
+			Cohort("cohort").
+			ResourceGroup(
+				*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "6").Obj(),
+			).
+			ResourceGroup(
+				*utiltesting.MakeFlavorQuotas("alpha").Resource(corev1.ResourceMemory, "6Gi").Obj(),
+			).
+			Obj(),
+		utiltesting.MakeClusterQueue("c2").
+			Cohort("cohort").
+			ResourceGroup(
+				*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "4").Obj(),
+			).
+			Obj(),
+	}
+	workloads := []kueue.Workload{
+		*utiltesting.MakeWorkload("c1-cpu", "").
+			Request(corev1.ResourceCPU, "1").
+			Admit(utiltesting.MakeAdmission("c1").Assignment(corev1.ResourceCPU, "default", "1000m").Obj()).
+			Obj(),
+		*utiltesting.MakeWorkload("c1-memory", "").
+			Request(corev1.ResourceMemory, "1Gi").
+			Admit(utiltesting.MakeAdmission("c1").Assignment(corev1.ResourceMemory, "alpha", "1Gi").Obj()).
+			Obj(),
+		*utiltesting.MakeWorkload("c2-cpu", "").
+			Request(corev1.ResourceCPU, "2").
+			Admit(utiltesting.MakeAdmission("c2").Assignment(corev1.ResourceCPU, "default", "2000m").Obj()).
+			Obj(),
+	}
+
+	ctx := context.Background()
+	cl := utiltesting.NewClientBuilder().WithLists(&kueue.WorkloadList{Items: workloads}).Build()
+	cqCache := New(cl)
+	for _, flv := range flavors {
+		cqCache.AddOrUpdateResourceFlavor(flv)
+	}
+	for _, cq := range clusterQueues {
+		if err := cqCache.AddClusterQueue(ctx, cq); err != nil {
+			t.Fatalf("Couldn't add ClusterQueue to cache: %v", err)
+		}
+	}
+	cohort := cqCache.Snapshot().ClusterQueues["c1"].Cohort
+
+	requestable, usage := cohort.Summary()
+	wantRequestable := FlavorResourceQuantities{
+		"default": {corev1.ResourceCPU: 10_000},
+		"alpha":   {corev1.ResourceMemory: 6 * utiltesting.Gi},
+	}
+	if diff := cmp.Diff(wantRequestable, requestable); diff != "" {
+		t.Errorf("Unexpected requestable resources (-want,+got):\n%s", diff)
+	}
+	wantUsage := FlavorResourceQuantities{
+		"default": {corev1.ResourceCPU: 3_000},
+		"alpha":   {corev1.ResourceMemory: utiltesting.Gi},
+	}
+	if diff := cmp.Diff(wantUsage, usage); diff != "" {
+		t.Errorf("Unexpected usage (-want,+got):\n%s", diff)
+	}
+
+	// The summary doesn't share memory with the cohort.
+	usage["default"][corev1.ResourceCPU] = 0
+	if got := cohort.Usage["default"][corev1.ResourceCPU]; got != 3_000 {
+		t.Errorf("Cohort usage was modified through the summary, got %d", got)
+	}
+}
+
+func TestSnapshotCohortParents(t *testing.T) {
+	clusterQueues := []*kueue.ClusterQueue{
+		utiltesting.MakeClusterQueue("c1").
+			Cohort("a").
+			ResourceGroup(
+				*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "6").Obj(),
+			).
+			Obj(),
+		utiltesting.MakeClusterQueue("c2").
+			Cohort("b").
+			ResourceGroup(
+				*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "4").Obj(),
+			).
+			Obj(),
+	}
+	workloads := []kueue.Workload{
+		*utiltesting.MakeWorkload("c1-cpu", "").
+			Request(corev1.ResourceCPU, "1").
+			Admit(utiltesting.MakeAdmission("c1").Assignment(corev1.ResourceCPU, "default", "1000m").Obj()).
+			Obj(),
+		*utiltesting.MakeWorkload("c2-cpu", "").
+			Request(corev1.ResourceCPU, "2").
+			Admit(utiltesting.MakeAdmission("c2").Assignment(corev1.ResourceCPU, "default", "2000m").Obj()).
+			Obj(),
+	}
+	cases := map[string]struct {
+		parents         map[string]string
+		remove          []string
+		wantParents     map[string]string
+		wantRequestable FlavorResourceQuantities
+		wantUsage       FlavorResourceQuantities
+	}{
+		"no parents": {
+			wantParents: map[string]string{"a": "", "b": ""},
+		},
+		"shared parent without members": {
+			parents:     map[string]string{"a": "root", "b": "root"},
+			wantParents: map[string]string{"a": "root", "b": "root"},
+			wantRequestable: FlavorResourceQuantities{
+				"default": {corev1.ResourceCPU: 10_000},
+			},
+			wantUsage: FlavorResourceQuantities{
+				"default": {corev1.ResourceCPU: 3_000},
+			},
+		},
+		"parent with members": {
+			parents:     map[string]string{"a": "b"},
+			wantParents: map[string]string{"a": "b", "b": ""},
+			wantRequestable: FlavorResourceQuantities{
+				"default": {corev1.ResourceCPU: 10_000},
+			},
+			wantUsage: FlavorResourceQuantities{
+				"default": {corev1.ResourceCPU: 3_000},
+			},
+		},
+		"usage removed from the parent": {
+			parents:     map[string]string{"a": "root", "b": "root"},
+			remove:      []string{"/c1-cpu"},
+			wantParents: map[string]string{"a": "root", "b": "root"},
+			wantRequestable: FlavorResourceQuantities{
+				"default": {corev1.ResourceCPU: 10_000},
+			},
+			wantUsage: FlavorResourceQuantities{
+				"default": {corev1.ResourceCPU: 2_000},
+			},
+		},
+		"cycle is ignored": {
+			parents:     map[string]string{"a": "b", "b": "a"},
+			wantParents: map[string]string{"a": "b", "b": ""},
+			wantRequestable: FlavorResourceQuantities{
+				"default": {corev1.ResourceCPU: 10_000},
+			},
+			wantUsage: FlavorResourceQuantities{
+				"default": {corev1.ResourceCPU: 3_000},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			cl := utiltesting.NewClientBuilder().WithLists(&kueue.WorkloadList{Items: workloads}).Build()
+			cqCache := New(cl, WithCohortParents(tc.parents))
+			cqCache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("default").Obj())
+			for _, cq := range clusterQueues {
+				if err := cqCache.AddClusterQueue(ctx, cq); err != nil {
+					t.Fatalf("Couldn't add ClusterQueue to cache: %v", err)
+				}
+			}
+			snap := cqCache.Snapshot()
+			for _, name := range tc.remove {
+				for _, cq := range snap.ClusterQueues {
+					if wl, found := cq.Workloads[name]; found {
+						snap.RemoveWorkload(wl)
+					}
+				}
+			}
+			gotParents := make(map[string]string)
+			var parent *Cohort
+			for _, cq := range snap.ClusterQueues {
+				gotParents[cq.Cohort.Name] = ""
+				if cq.Cohort.Parent != nil {
+					gotParents[cq.Cohort.Name] = cq.Cohort.Parent.Name
+					parent = cq.Cohort.Parent
+				}
+			}
+			if diff := cmp.Diff(tc.wantParents, gotParents); diff != "" {
+				t.Errorf("Unexpected parents (-want,+got):\n%s", diff)
+			}
+			var gotRequestable, gotUsage FlavorResourceQuantities
+			if parent != nil {
+				gotRequestable, gotUsage = parent.Summary()
+			}
+			if diff := cmp.Diff(tc.wantRequestable, gotRequestable); diff != "" {
+				t.Errorf("Unexpected requestable resources in the parent (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantUsage, gotUsage); diff != "" {
+				t.Errorf("Unexpected usage in the parent (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSnapshotFairSharing(t *testing.T) {
+	clusterQueues := []*kueue.ClusterQueue{
+		utiltesting.MakeClusterQueue("c1").
+			Cohort("a").
+			ResourceGroup(
+				*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "6").Obj(),
+			).
+			Obj(),
+		utiltesting.MakeClusterQueue("c2").
+			Cohort("b").
+			ResourceGroup(
+				*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "4").Obj(),
+			).
+			Obj(),
+	}
+	cases := map[string]struct {
+		parents            map[string]string
+		fairSharingCohorts sets.Set[string]
+		wantFairSharing    map[string]bool
+	}{
+		"no fair sharing": {
+			wantFairSharing: map[string]bool{"a": false, "b": false},
+		},
+		"fair sharing in one cohort": {
+			fairSharingCohorts: sets.New("a"),
+			wantFairSharing:    map[string]bool{"a": true, "b": false},
+		},
+		"fair sharing in a parent without members": {
+			parents:            map[string]string{"a": "root", "b": "root"},
+			fairSharingCohorts: sets.New("b", "root"),
+			wantFairSharing:    map[string]bool{"a": false, "b": true, "root": true},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			cl := utiltesting.NewClientBuilder().Build()
+			cqCache := New(cl, WithCohortParents(tc.parents), WithFairSharingCohorts(tc.fairSharingCohorts))
+			cqCache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("default").Obj())
+			for _, cq := range clusterQueues {
+				if err := cqCache.AddClusterQueue(ctx, cq); err != nil {
+					t.Fatalf("Couldn't add ClusterQueue to cache: %v", err)
+				}
+			}
+			snap := cqCache.Snapshot()
+			gotFairSharing := make(map[string]bool)
+			for _, cq := range snap.ClusterQueues {
+				for cohort := cq.Cohort; cohort != nil; cohort = cohort.Parent {
+					gotFairSharing[cohort.Name] = cohort.FairSharing
+				}
+			}
+			if diff := cmp.Diff(tc.wantFairSharing, gotFairSharing); diff != "" {
+				t.Errorf("Unexpected fair sharing in the cohorts (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSnapshotCohortCaps(t *testing.T) {
+	clusterQueues := []*kueue.ClusterQueue{
+		utiltesting.MakeClusterQueue("c1").
+			Cohort("a").
+			ResourceGroup(
+				*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "6").Obj(),
+			).
+			Obj(),
+		utiltesting.MakeClusterQueue("c2").
+			Cohort("b").
+			ResourceGroup(
+				*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "4").Obj(),
+			).
+			Obj(),
+	}
+	cases := map[string]struct {
+		caps     map[string]FlavorResourceQuantities
+		wantCaps map[string]FlavorResourceQuantities
+	}{
+		"no caps": {
+			wantCaps: map[string]FlavorResourceQuantities{"a": nil, "b": nil},
+		},
+		"caps in one cohort": {
+			caps: map[string]FlavorResourceQuantities{
+				"a": {"default": {corev1.ResourceCPU: 4_000}},
+				"c": {"default": {corev1.ResourceCPU: 1_000}},
+			},
+			wantCaps: map[string]FlavorResourceQuantities{
+				"a": {"default": {corev1.ResourceCPU: 4_000}},
+				"b": nil,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			cl := utiltesting.NewClientBuilder().Build()
+			cqCache := New(cl, WithCohortCaps(tc.caps))
+			cqCache.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("default").Obj())
+			for _, cq := range clusterQueues {
+				if err := cqCache.AddClusterQueue(ctx, cq); err != nil {
+					t.Fatalf("Couldn't add ClusterQueue to cache: %v", err)
+				}
+			}
+			snap := cqCache.Snapshot()
+			gotCaps := make(map[string]FlavorResourceQuantities)
+			for _, cq := range snap.ClusterQueues {
+				gotCaps[cq.Cohort.Name] = cq.Cohort.Caps
+			}
+			if diff := cmp.Diff(tc.wantCaps, gotCaps); diff != "" {
+				t.Errorf("Unexpected caps in the cohorts (-want,+got):\n%s", diff)
+			}
+			// The caps of the snapshot are copies.
+			for _, cq := range snap.ClusterQueues {
+				if cq.Cohort.Caps != nil {
+					cq.Cohort.Caps["default"][corev1.ResourceCPU] = 0
+				}
+			}
+			if tc.caps != nil && tc.caps["a"]["default"][corev1.ResourceCPU] != 4_000 {
+				t.Errorf("The caps of the cache were modified through the snapshot")
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package testing provides wrappers to build cache objects in tests. It's
+// separate from pkg/util/testing because the cache package tests import that
+// one.
+package testing
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/cache"
+	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
+	"sigs.k8s.io/kueue/pkg/workload"
+)
+
+// NewClusterQueueFromAPI returns the cache object for the ClusterQueue, with
+// its resource groups and flavor label keys updated, as used by the
+// scheduler. It's obtained from a snapshot of a cache with only the given
+// ClusterQueue and flavors. It panics if the ClusterQueue is not active, for
+// example, because some of its flavors are missing.
+func NewClusterQueueFromAPI(cq *kueue.ClusterQueue, flavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor) *cache.ClusterQueue {
+	c := cache.New(utiltesting.NewFakeClient())
+	for _, rf := range flavors {
+		c.AddOrUpdateResourceFlavor(rf)
+	}
+	if err := c.AddClusterQueue(context.Background(), cq); err != nil {
+		panic(err)
+	}
+	snapshot := c.Snapshot()
+	cqImpl := snapshot.ClusterQueues[cq.Name]
+	if cqImpl == nil {
+		panic(fmt.Sprintf("ClusterQueue %s is not active", cq.Name))
+	}
+	return cqImpl
+}
+
+// CohortWrapper wraps a Cohort.
+type CohortWrapper struct{ cache.Cohort }
+
+// MakeCohort creates a wrapper for a Cohort with the given name.
+func MakeCohort(name string) *CohortWrapper {
+	return &CohortWrapper{cache.Cohort{
+		Name:                 name,
+		Members:              sets.New[*cache.ClusterQueue](),
+		RequestableResources: make(cache.FlavorResourceQuantities),
+		Usage:                make(cache.FlavorResourceQuantities),
+	}}
+}
+
+// Obj returns the inner Cohort.
+func (c *CohortWrapper) Obj() *cache.Cohort {
+	return &c.Cohort
+}
+
+// Requestable sets the quantity of the resource in the flavor that can be
+// requested by the members of the cohort.
+func (c *CohortWrapper) Requestable(flavor kueue.ResourceFlavorReference, r corev1.ResourceName, q string) *CohortWrapper {
+	set(c.RequestableResources, flavor, r, q)
+	return c
+}
+
+// Usage sets the quantity of the resource in the flavor used by the members
+// of the cohort.
+func (c *CohortWrapper) Usage(flavor kueue.ResourceFlavorReference, r corev1.ResourceName, q string) *CohortWrapper {
+	set(c.Cohort.Usage, flavor, r, q)
+	return c
+}
+
+// Parent sets the parent cohort.
+func (c *CohortWrapper) Parent(parent *cache.Cohort) *CohortWrapper {
+	c.Cohort.Parent = parent
+	return c
+}
+
+// FairSharing enables fair sharing in the cohort.
+func (c *CohortWrapper) FairSharing() *CohortWrapper {
+	c.Cohort.FairSharing = true
+	return c
+}
+
+// Cap sets the maximum quantity of the resource in the flavor that the members
+// of the cohort can use in total.
+func (c *CohortWrapper) Cap(flavor kueue.ResourceFlavorReference, r corev1.ResourceName, q string) *CohortWrapper {
+	if c.Caps == nil {
+		c.Caps = make(cache.FlavorResourceQuantities)
+	}
+	set(c.Caps, flavor, r, q)
+	return c
+}
+
+func set(quantities cache.FlavorResourceQuantities, flavor kueue.ResourceFlavorReference, r corev1.ResourceName, q string) {
+	if quantities[flavor] == nil {
+		quantities[flavor] = make(map[corev1.ResourceName]int64)
+	}
+	quantities[flavor][r] = workload.ResourceValue(r, resource.MustParse(q))
+}
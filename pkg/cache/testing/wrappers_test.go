@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/pointer"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/cache"
+	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
+)
+
+func TestMakeCohort(t *testing.T) {
+	got := MakeCohort("cohort").
+		Requestable("one", corev1.ResourceCPU, "4").
+		Requestable("one", corev1.ResourceMemory, "1Gi").
+		Requestable("two", corev1.ResourceCPU, "2").
+		Usage("one", corev1.ResourceCPU, "1500m").
+		Parent(MakeCohort("parent").Obj()).
+		Obj()
+	want := &cache.Cohort{
+		Name:    "cohort",
+		Members: sets.New[*cache.ClusterQueue](),
+		RequestableResources: cache.FlavorResourceQuantities{
+			"one": {
+				corev1.ResourceCPU:    4_000,
+				corev1.ResourceMemory: 1024 * 1024 * 1024,
+			},
+			"two": {corev1.ResourceCPU: 2_000},
+		},
+		Usage: cache.FlavorResourceQuantities{
+			"one": {corev1.ResourceCPU: 1_500},
+		},
+		Parent: &cache.Cohort{
+			Name:                 "parent",
+			Members:              sets.New[*cache.ClusterQueue](),
+			RequestableResources: cache.FlavorResourceQuantities{},
+			Usage:                cache.FlavorResourceQuantities{},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected cohort (-want,+got):\n%s", diff)
+	}
+}
+
+func TestNewClusterQueueFromAPI(t *testing.T) {
+	flavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"one": utiltesting.MakeResourceFlavor("one").Label("type", "one").Obj(),
+		"two": utiltesting.MakeResourceFlavor("two").Obj(),
+	}
+	cq := utiltesting.MakeClusterQueue("cq").
+		ResourceGroup(
+			*utiltesting.MakeFlavorQuotas("one").Resource(corev1.ResourceCPU, "4", "2").Obj(),
+			*utiltesting.MakeFlavorQuotas("two").Resource(corev1.ResourceCPU, "8").Obj(),
+		).
+		ResourceGroup(
+			*utiltesting.MakeFlavorQuotas("one").Resource(corev1.ResourceMemory, "1Gi").Obj(),
+		).
+		Obj()
+	got := NewClusterQueueFromAPI(cq, flavors)
+	wantRGs := []cache.ResourceGroup{
+		{
+			CoveredResources: sets.New(corev1.ResourceCPU),
+			Flavors: []cache.FlavorQuotas{
+				{
+					Name: "one",
+					Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+						corev1.ResourceCPU: {Nominal: 4_000, BorrowingLimit: pointer.Int64(2_000)},
+					},
+				},
+				{
+					Name: "two",
+					Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+						corev1.ResourceCPU: {Nominal: 8_000},
+					},
+				},
+			},
+			LabelKeys: sets.New("type"),
+		},
+		{
+			CoveredResources: sets.New(corev1.ResourceMemory),
+			Flavors: []cache.FlavorQuotas{{
+				Name: "one",
+				Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+					corev1.ResourceMemory: {Nominal: utiltesting.Gi},
+				},
+			}},
+			LabelKeys: sets.New("type"),
+		},
+	}
+	if diff := cmp.Diff(wantRGs, got.ResourceGroups); diff != "" {
+		t.Errorf("Unexpected resource groups (-want,+got):\n%s", diff)
+	}
+	for rName, wantRG := range map[corev1.ResourceName]int{
+		corev1.ResourceCPU:    0,
+		corev1.ResourceMemory: 1,
+	} {
+		if got.RGByResource[rName] != &got.ResourceGroups[wantRG] {
+			t.Errorf("Resource %s not mapped to resource group %d", rName, wantRG)
+		}
+	}
+}
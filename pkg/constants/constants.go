@@ -29,6 +29,18 @@ const (
 	// before syncing a Queue and ClusterQueue objects.
 	UpdatesBatchPeriod = time.Second
 
+	// PreemptionRequeueDelay is the delay added to the eviction time of a
+	// workload evicted by preemption to order it in the queue, preventing it
+	// from being admitted again immediately.
+	PreemptionRequeueDelay = 5 * time.Second
+
+	// PodsReadyTimeoutRequeueBaseDelay is the delay before a workload evicted
+	// due to a PodsReady timeout is queued again for the first time. It
+	// doubles with each subsequent requeue, up to
+	// PodsReadyTimeoutRequeueMaxDelay.
+	PodsReadyTimeoutRequeueBaseDelay = 10 * time.Second
+	PodsReadyTimeoutRequeueMaxDelay  = time.Hour
+
 	// DefaultPriority is used to set priority of workloads
 	// that do not specify any priority class and there is no priority class
 	// marked as default.
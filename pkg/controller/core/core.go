@@ -44,12 +44,19 @@ func SetupControllers(mgr ctrl.Manager, qManager *queue.Manager, cc *cache.Cache
 	if err := cqRec.SetupWithManager(mgr); err != nil {
 		return "ClusterQueue", err
 	}
-	if err := NewWorkloadReconciler(mgr.GetClient(), qManager, cc, WithWorkloadUpdateWatchers(qRec, cqRec), WithPodsReadyTimeout(podsReadyTimeout(cfg))).SetupWithManager(mgr); err != nil {
+	if err := NewWorkloadReconciler(mgr.GetClient(), qManager, cc, WithWorkloadUpdateWatchers(qRec, cqRec), WithPodsReadyTimeout(podsReadyTimeout(cfg)), WithRequeuingBackoffLimitCount(requeuingBackoffLimitCount(cfg))).SetupWithManager(mgr); err != nil {
 		return "Workload", err
 	}
 	return "", nil
 }
 
+func requeuingBackoffLimitCount(cfg *config.Configuration) *int32 {
+	if cfg.WaitForPodsReady != nil && cfg.WaitForPodsReady.Enable {
+		return cfg.WaitForPodsReady.RequeuingBackoffLimitCount
+	}
+	return nil
+}
+
 func podsReadyTimeout(cfg *config.Configuration) *time.Duration {
 	if cfg.WaitForPodsReady != nil && cfg.WaitForPodsReady.Enable && cfg.WaitForPodsReady.Timeout != nil {
 		return &cfg.WaitForPodsReady.Timeout.Duration
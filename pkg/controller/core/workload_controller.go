@@ -56,8 +56,9 @@ var (
 )
 
 type options struct {
-	watchers         []WorkloadUpdateWatcher
-	podsReadyTimeout *time.Duration
+	watchers                   []WorkloadUpdateWatcher
+	podsReadyTimeout           *time.Duration
+	requeuingBackoffLimitCount *int32
 }
 
 // Option configures the reconciler.
@@ -71,6 +72,14 @@ func WithPodsReadyTimeout(value *time.Duration) Option {
 	}
 }
 
+// WithRequeuingBackoffLimitCount indicates the number of times a workload can
+// be requeued after PodsReady timeouts before it's deactivated.
+func WithRequeuingBackoffLimitCount(value *int32) Option {
+	return func(o *options) {
+		o.requeuingBackoffLimitCount = value
+	}
+}
+
 // WithWorkloadUpdateWatchers allows to specify the workload update watchers
 func WithWorkloadUpdateWatchers(value ...WorkloadUpdateWatcher) Option {
 	return func(o *options) {
@@ -86,12 +95,13 @@ type WorkloadUpdateWatcher interface {
 
 // WorkloadReconciler reconciles a Workload object
 type WorkloadReconciler struct {
-	log              logr.Logger
-	queues           *queue.Manager
-	cache            *cache.Cache
-	client           client.Client
-	watchers         []WorkloadUpdateWatcher
-	podsReadyTimeout *time.Duration
+	log                        logr.Logger
+	queues                     *queue.Manager
+	cache                      *cache.Cache
+	client                     client.Client
+	watchers                   []WorkloadUpdateWatcher
+	podsReadyTimeout           *time.Duration
+	requeuingBackoffLimitCount *int32
 }
 
 func NewWorkloadReconciler(client client.Client, queues *queue.Manager, cache *cache.Cache, opts ...Option) *WorkloadReconciler {
@@ -101,12 +111,13 @@ func NewWorkloadReconciler(client client.Client, queues *queue.Manager, cache *c
 	}
 
 	return &WorkloadReconciler{
-		log:              ctrl.Log.WithName("workload-reconciler"),
-		client:           client,
-		queues:           queues,
-		cache:            cache,
-		watchers:         options.watchers,
-		podsReadyTimeout: options.podsReadyTimeout,
+		log:                        ctrl.Log.WithName("workload-reconciler"),
+		client:                     client,
+		queues:                     queues,
+		cache:                      cache,
+		watchers:                   options.watchers,
+		podsReadyTimeout:           options.podsReadyTimeout,
+		requeuingBackoffLimitCount: options.requeuingBackoffLimitCount,
 	}
 }
 
@@ -131,9 +142,33 @@ func (r *WorkloadReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, nil
 	}
 	if workload.IsAdmitted(&wl) {
+		if !workload.IsActive(&wl) {
+			return r.reconcileInactive(ctx, &wl)
+		}
 		return r.reconcileNotReadyTimeout(ctx, req, &wl)
 	}
 
+	if !workload.IsActive(&wl) {
+		log.V(3).Info("Workload is inactive, not queueing it")
+		return ctrl.Result{}, nil
+	}
+
+	if resetDeactivation(&wl) {
+		log.V(2).Info("Workload was reactivated, resetting its requeue state")
+		err := workload.ApplyAdmissionStatus(ctx, r.client, &wl, true)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if delay, released := releaseRequeueHold(&wl, realClock.Now()); delay > 0 {
+		log.V(3).Info("Workload is held back from the queue after a PodsReady timeout", "requeueAfter", delay)
+		return ctrl.Result{RequeueAfter: delay}, nil
+	} else if released {
+		// Clearing the time queues the workload, as part of the update.
+		log.V(2).Info("Workload can be queued again after a PodsReady timeout")
+		err := workload.ApplyAdmissionStatus(ctx, r.client, &wl, true)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
 	if !r.queues.QueueForWorkloadExists(&wl) {
 		log.V(3).Info("Workload is inadmissible because of missing LocalQueue", "localQueue", klog.KRef(wl.Namespace, wl.Spec.QueueName))
 		workload.UnsetAdmissionWithCondition(&wl, "Inadmissible", fmt.Sprintf("LocalQueue %s doesn't exist", wl.Spec.QueueName))
@@ -169,12 +204,81 @@ func (r *WorkloadReconciler) reconcileNotReadyTimeout(ctx context.Context, req c
 		return ctrl.Result{RequeueAfter: recheckAfter}, nil
 	} else {
 		log.V(2).Info("Start the eviction of the workload due to exceeding the PodsReady timeout")
-		workload.SetEvictedCondition(wl, kueue.WorkloadEvictedByPodsReadyTimeout, fmt.Sprintf("Exceeded the PodsReady timeout %s", req.NamespacedName.String()))
+		if r.evictOnPodsReadyTimeout(wl, fmt.Sprintf("Exceeded the PodsReady timeout %s", req.NamespacedName.String()), realClock.Now()) {
+			log.V(2).Info("Deactivating the workload due to exceeding the requeuing limit")
+			// The spec is patched on a copy, as the response would override
+			// the status changes.
+			patch := client.RawPatch(types.MergePatchType, []byte(`{"spec":{"active":false}}`))
+			if err := r.client.Patch(ctx, wl.DeepCopy(), patch); err != nil {
+				return ctrl.Result{}, client.IgnoreNotFound(err)
+			}
+		}
 		err := workload.ApplyAdmissionStatus(ctx, r.client, wl, false)
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 }
 
+// reconcileInactive evicts the admitted workload once it's deactivated.
+func (r *WorkloadReconciler) reconcileInactive(ctx context.Context, wl *kueue.Workload) (ctrl.Result, error) {
+	if !evictInactive(wl) {
+		return ctrl.Result{}, nil
+	}
+	log := ctrl.LoggerFrom(ctx)
+	log.V(2).Info("Start the eviction of the workload due to its deactivation")
+	err := workload.ApplyAdmissionStatus(ctx, r.client, wl, true)
+	return ctrl.Result{}, client.IgnoreNotFound(err)
+}
+
+// evictOnPodsReadyTimeout sets the Evicted condition on the workload that
+// exceeded the PodsReady timeout and records the requeue. It returns whether
+// the workload exceeded the requeuing limit and got deactivated.
+func (r *WorkloadReconciler) evictOnPodsReadyTimeout(wl *kueue.Workload, message string, now time.Time) bool {
+	workload.SetEvictedCondition(wl, kueue.WorkloadEvictedByPodsReadyTimeout, message)
+	workload.UpdateRequeueState(wl, now)
+	return r.requeuingBackoffLimitCount != nil && workload.MaybeDeactivateOnFailureLimit(wl, int(*r.requeuingBackoffLimitCount))
+}
+
+// evictInactive sets the Evicted condition on the admitted workload that was
+// deactivated, unless it's already evicted. It returns whether the workload
+// changed.
+func evictInactive(wl *kueue.Workload) bool {
+	if apimeta.IsStatusConditionTrue(wl.Status.Conditions, kueue.WorkloadEvicted) {
+		return false
+	}
+	workload.SetEvictedCondition(wl, kueue.WorkloadEvictedByDeactivation, "The workload is deactivated")
+	return true
+}
+
+// resetDeactivation clears the Deactivated condition and the requeue state of
+// the workload that was reactivated. It returns whether the workload changed.
+func resetDeactivation(wl *kueue.Workload) bool {
+	if !apimeta.IsStatusConditionTrue(wl.Status.Conditions, kueue.WorkloadDeactivated) {
+		return false
+	}
+	apimeta.SetStatusCondition(&wl.Status.Conditions, metav1.Condition{
+		Type:    kueue.WorkloadDeactivated,
+		Status:  metav1.ConditionFalse,
+		Reason:  "Reactivated",
+		Message: "The workload was reactivated",
+	})
+	wl.Status.RequeueState = nil
+	return true
+}
+
+// releaseRequeueHold returns how long the workload is still held back from the
+// queue after a PodsReady timeout. Once the delay is over, it clears the
+// requeue time and returns true.
+func releaseRequeueHold(wl *kueue.Workload, now time.Time) (time.Duration, bool) {
+	if wl.Status.RequeueState == nil || wl.Status.RequeueState.RequeueAt == nil {
+		return 0, false
+	}
+	if delay := workload.RequeueDelay(wl, now); delay > 0 {
+		return delay, false
+	}
+	wl.Status.RequeueState.RequeueAt = nil
+	return 0, true
+}
+
 func (r *WorkloadReconciler) Create(e event.CreateEvent) bool {
 	wl, isWorkload := e.Object.(*kueue.Workload)
 	if !isWorkload {
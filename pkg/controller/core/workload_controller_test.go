@@ -20,6 +20,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	testingclock "k8s.io/utils/clock/testing"
 	"k8s.io/utils/pointer"
@@ -176,3 +178,306 @@ func TestAdmittedNotReadyWorkload(t *testing.T) {
 		})
 	}
 }
+
+var workloadCmpOpts = []cmp.Option{
+	cmpopts.EquateEmpty(),
+	cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime"),
+}
+
+func TestEvictOnPodsReadyTimeout(t *testing.T) {
+	now := time.Now()
+	testCases := map[string]struct {
+		workload                   kueue.Workload
+		requeuingBackoffLimitCount *int32
+		wantWorkload               kueue.Workload
+		wantDeactivated            bool
+	}{
+		"no requeuing limit; evicted and requeued": {
+			wantWorkload: kueue.Workload{
+				Status: kueue.WorkloadStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:    kueue.WorkloadEvicted,
+							Status:  metav1.ConditionTrue,
+							Reason:  kueue.WorkloadEvictedByPodsReadyTimeout,
+							Message: "timeout",
+						},
+					},
+					RequeueState: &kueue.RequeueState{
+						Count:     pointer.Int32(1),
+						RequeueAt: &metav1.Time{Time: now.Add(10 * time.Second)},
+					},
+				},
+			},
+		},
+		"requeuing limit reached; evicted and requeued": {
+			workload: kueue.Workload{
+				Status: kueue.WorkloadStatus{
+					RequeueState: &kueue.RequeueState{
+						Count: pointer.Int32(1),
+					},
+				},
+			},
+			requeuingBackoffLimitCount: pointer.Int32(2),
+			wantWorkload: kueue.Workload{
+				Status: kueue.WorkloadStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:    kueue.WorkloadEvicted,
+							Status:  metav1.ConditionTrue,
+							Reason:  kueue.WorkloadEvictedByPodsReadyTimeout,
+							Message: "timeout",
+						},
+					},
+					RequeueState: &kueue.RequeueState{
+						Count:     pointer.Int32(2),
+						RequeueAt: &metav1.Time{Time: now.Add(20 * time.Second)},
+					},
+				},
+			},
+		},
+		"requeuing limit exceeded; evicted and deactivated": {
+			workload: kueue.Workload{
+				Status: kueue.WorkloadStatus{
+					RequeueState: &kueue.RequeueState{
+						Count: pointer.Int32(2),
+					},
+				},
+			},
+			requeuingBackoffLimitCount: pointer.Int32(2),
+			wantWorkload: kueue.Workload{
+				Spec: kueue.WorkloadSpec{
+					Active: pointer.Bool(false),
+				},
+				Status: kueue.WorkloadStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:    kueue.WorkloadEvicted,
+							Status:  metav1.ConditionTrue,
+							Reason:  kueue.WorkloadEvictedByPodsReadyTimeout,
+							Message: "timeout",
+						},
+						{
+							Type:    kueue.WorkloadDeactivated,
+							Status:  metav1.ConditionTrue,
+							Reason:  kueue.WorkloadDeactivatedByRequeuingLimit,
+							Message: "The workload was requeued 3 times, exceeding the limit of 2",
+						},
+					},
+					RequeueState: &kueue.RequeueState{
+						Count:     pointer.Int32(3),
+						RequeueAt: &metav1.Time{Time: now.Add(40 * time.Second)},
+					},
+				},
+			},
+			wantDeactivated: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			wRec := WorkloadReconciler{requeuingBackoffLimitCount: tc.requeuingBackoffLimitCount}
+			wl := tc.workload.DeepCopy()
+			deactivated := wRec.evictOnPodsReadyTimeout(wl, "timeout", now)
+			if tc.wantDeactivated != deactivated {
+				t.Errorf("Unexpected deactivated, want=%v, got=%v", tc.wantDeactivated, deactivated)
+			}
+			if diff := cmp.Diff(tc.wantWorkload, *wl, workloadCmpOpts...); diff != "" {
+				t.Errorf("Unexpected workload (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEvictInactive(t *testing.T) {
+	testCases := map[string]struct {
+		workload     kueue.Workload
+		wantWorkload kueue.Workload
+		wantChanged  bool
+	}{
+		"not evicted; evicted due to the deactivation": {
+			workload: kueue.Workload{
+				Spec: kueue.WorkloadSpec{
+					Active: pointer.Bool(false),
+				},
+			},
+			wantWorkload: kueue.Workload{
+				Spec: kueue.WorkloadSpec{
+					Active: pointer.Bool(false),
+				},
+				Status: kueue.WorkloadStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:    kueue.WorkloadEvicted,
+							Status:  metav1.ConditionTrue,
+							Reason:  kueue.WorkloadEvictedByDeactivation,
+							Message: "The workload is deactivated",
+						},
+					},
+				},
+			},
+			wantChanged: true,
+		},
+		"already evicted; unchanged": {
+			workload: kueue.Workload{
+				Spec: kueue.WorkloadSpec{
+					Active: pointer.Bool(false),
+				},
+				Status: kueue.WorkloadStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:   kueue.WorkloadEvicted,
+							Status: metav1.ConditionTrue,
+							Reason: kueue.WorkloadEvictedByPodsReadyTimeout,
+						},
+					},
+				},
+			},
+			wantWorkload: kueue.Workload{
+				Spec: kueue.WorkloadSpec{
+					Active: pointer.Bool(false),
+				},
+				Status: kueue.WorkloadStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:   kueue.WorkloadEvicted,
+							Status: metav1.ConditionTrue,
+							Reason: kueue.WorkloadEvictedByPodsReadyTimeout,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			wl := tc.workload.DeepCopy()
+			if changed := evictInactive(wl); tc.wantChanged != changed {
+				t.Errorf("Unexpected changed, want=%v, got=%v", tc.wantChanged, changed)
+			}
+			if diff := cmp.Diff(tc.wantWorkload, *wl, workloadCmpOpts...); diff != "" {
+				t.Errorf("Unexpected workload (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResetDeactivation(t *testing.T) {
+	testCases := map[string]struct {
+		workload     kueue.Workload
+		wantWorkload kueue.Workload
+		wantChanged  bool
+	}{
+		"reactivated; condition and requeue state reset": {
+			workload: kueue.Workload{
+				Status: kueue.WorkloadStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:   kueue.WorkloadDeactivated,
+							Status: metav1.ConditionTrue,
+							Reason: kueue.WorkloadDeactivatedByRequeuingLimit,
+						},
+					},
+					RequeueState: &kueue.RequeueState{
+						Count: pointer.Int32(3),
+					},
+				},
+			},
+			wantWorkload: kueue.Workload{
+				Status: kueue.WorkloadStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:    kueue.WorkloadDeactivated,
+							Status:  metav1.ConditionFalse,
+							Reason:  "Reactivated",
+							Message: "The workload was reactivated",
+						},
+					},
+				},
+			},
+			wantChanged: true,
+		},
+		"never deactivated; unchanged": {
+			workload: kueue.Workload{
+				Status: kueue.WorkloadStatus{
+					RequeueState: &kueue.RequeueState{
+						Count: pointer.Int32(1),
+					},
+				},
+			},
+			wantWorkload: kueue.Workload{
+				Status: kueue.WorkloadStatus{
+					RequeueState: &kueue.RequeueState{
+						Count: pointer.Int32(1),
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			wl := tc.workload.DeepCopy()
+			if changed := resetDeactivation(wl); tc.wantChanged != changed {
+				t.Errorf("Unexpected changed, want=%v, got=%v", tc.wantChanged, changed)
+			}
+			if diff := cmp.Diff(tc.wantWorkload, *wl, workloadCmpOpts...); diff != "" {
+				t.Errorf("Unexpected workload (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReleaseRequeueHold(t *testing.T) {
+	now := time.Now()
+	testCases := map[string]struct {
+		requeueState     *kueue.RequeueState
+		wantRequeueState *kueue.RequeueState
+		wantDelay        time.Duration
+		wantReleased     bool
+	}{
+		"not held back": {},
+		"held back": {
+			requeueState: &kueue.RequeueState{
+				Count:     pointer.Int32(1),
+				RequeueAt: &metav1.Time{Time: now.Add(time.Minute)},
+			},
+			wantRequeueState: &kueue.RequeueState{
+				Count:     pointer.Int32(1),
+				RequeueAt: &metav1.Time{Time: now.Add(time.Minute)},
+			},
+			wantDelay: time.Minute,
+		},
+		"delay over; released keeping the count": {
+			requeueState: &kueue.RequeueState{
+				Count:     pointer.Int32(1),
+				RequeueAt: &metav1.Time{Time: now.Add(-time.Second)},
+			},
+			wantRequeueState: &kueue.RequeueState{
+				Count: pointer.Int32(1),
+			},
+			wantReleased: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			wl := &kueue.Workload{
+				Status: kueue.WorkloadStatus{
+					RequeueState: tc.requeueState,
+				},
+			}
+			delay, released := releaseRequeueHold(wl, now)
+			if tc.wantDelay != delay {
+				t.Errorf("Unexpected delay, want=%v, got=%v", tc.wantDelay, delay)
+			}
+			if tc.wantReleased != released {
+				t.Errorf("Unexpected released, want=%v, got=%v", tc.wantReleased, released)
+			}
+			if diff := cmp.Diff(tc.wantRequeueState, wl.Status.RequeueState); diff != "" {
+				t.Errorf("Unexpected requeue state (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
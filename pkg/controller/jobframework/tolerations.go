@@ -0,0 +1,42 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobframework
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+)
+
+// InjectFlavorTolerations appends to the podSpec a toleration for each of the
+// flavor's nodeTaints that is not already tolerated, so that the pods can be
+// scheduled in the nodes associated with the flavor.
+func InjectFlavorTolerations(podSpec *corev1.PodSpec, flavor *kueue.ResourceFlavor) {
+	for i := range flavor.Spec.NodeTaints {
+		taint := &flavor.Spec.NodeTaints[i]
+		if corev1helpers.TolerationsTolerateTaint(podSpec.Tolerations, taint) {
+			continue
+		}
+		podSpec.Tolerations = append(podSpec.Tolerations, corev1.Toleration{
+			Key:      taint.Key,
+			Operator: corev1.TolerationOpEqual,
+			Value:    taint.Value,
+			Effect:   taint.Effect,
+		})
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobframework
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+
+	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
+)
+
+func TestInjectFlavorTolerations(t *testing.T) {
+	flavor := utiltesting.MakeResourceFlavor("spot").
+		Taint(corev1.Taint{
+			Key:    "instance",
+			Value:  "spot",
+			Effect: corev1.TaintEffectNoSchedule,
+		}).
+		Taint(corev1.Taint{
+			Key:    "gpu",
+			Value:  "true",
+			Effect: corev1.TaintEffectNoExecute,
+		}).
+		Obj()
+
+	cases := map[string]struct {
+		tolerations     []corev1.Toleration
+		wantTolerations []corev1.Toleration
+	}{
+		"no tolerations": {
+			wantTolerations: []corev1.Toleration{
+				{
+					Key:      "instance",
+					Operator: corev1.TolerationOpEqual,
+					Value:    "spot",
+					Effect:   corev1.TaintEffectNoSchedule,
+				},
+				{
+					Key:      "gpu",
+					Operator: corev1.TolerationOpEqual,
+					Value:    "true",
+					Effect:   corev1.TaintEffectNoExecute,
+				},
+			},
+		},
+		"already tolerates one taint": {
+			tolerations: []corev1.Toleration{
+				{
+					Key:      "instance",
+					Operator: corev1.TolerationOpExists,
+				},
+			},
+			wantTolerations: []corev1.Toleration{
+				{
+					Key:      "instance",
+					Operator: corev1.TolerationOpExists,
+				},
+				{
+					Key:      "gpu",
+					Operator: corev1.TolerationOpEqual,
+					Value:    "true",
+					Effect:   corev1.TaintEffectNoExecute,
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			podSpec := corev1.PodSpec{
+				Tolerations: tc.tolerations,
+			}
+			InjectFlavorTolerations(&podSpec, flavor)
+			if diff := cmp.Diff(tc.wantTolerations, podSpec.Tolerations); diff != "" {
+				t.Errorf("Unexpected tolerations (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
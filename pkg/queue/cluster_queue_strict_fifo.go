@@ -17,6 +17,8 @@ limitations under the License.
 package queue
 
 import (
+	"time"
+
 	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
 	utilpriority "sigs.k8s.io/kueue/pkg/util/priority"
 	"sigs.k8s.io/kueue/pkg/workload"
@@ -43,20 +45,73 @@ func newClusterQueueStrictFIFO(cq *kueue.ClusterQueue) (ClusterQueue, error) {
 // queueOrdering is the function used by the clusterQueue heap algorithm
 // to sort workloads. It sorts workloads based on their priority.
 // When priorities are equal, it uses the workload's creation or eviction
-// time.
+// time and, lastly, its name.
 func queueOrdering(a, b interface{}) bool {
-	objA := a.(*workload.Info)
-	objB := b.(*workload.Info)
-	p1 := utilpriority.Priority(objA.Obj)
-	p2 := utilpriority.Priority(objB.Obj)
+	return workloadOrdering(a.(*workload.Info), b.(*workload.Info))
+}
+
+func workloadOrdering(objA, objB *workload.Info) bool {
+	// The order of the heap can't change between comparisons, so the
+	// workloads without a timestamp are ordered by the zero time, rather than
+	// by the current time.
+	return admissionOrderLess(objA.Obj, objB.Obj, time.Time{})
+}
+
+// AdmissionOrderKey returns the attributes that determine the order in which
+// workloads are considered for admission: higher priorities first, then
+// earlier queue-order timestamps, see workload.GetQueueOrderTimestamp, and then
+// the namespaced name, so that no two workloads are ever equivalent. Workloads
+// that weren't created yet are ordered as if they were created at now.
+func AdmissionOrderKey(w *kueue.Workload, now time.Time) (priority int32, ts time.Time, name string) {
+	ts = workload.GetQueueOrderTimestamp(w).Time
+	if ts.IsZero() {
+		ts = now
+	}
+	return utilpriority.Priority(w), ts, w.Namespace + "/" + w.Name
+}
 
-	if p1 != p2 {
-		return p1 > p2
+func admissionOrderLess(a, b *kueue.Workload, now time.Time) bool {
+	pA, tA, nameA := AdmissionOrderKey(a, now)
+	pB, tB, nameB := AdmissionOrderKey(b, now)
+	if pA != pB {
+		return pA > pB
 	}
+	if !tA.Equal(tB) {
+		return tA.Before(tB)
+	}
+	return nameA < nameB
+}
 
-	tA := workload.GetQueueOrderTimestamp(objA.Obj)
-	tB := workload.GetQueueOrderTimestamp(objB.Obj)
-	return !tB.Before(tA)
+// DeadlineAwareLess orders the workloads with the closest deadlines first,
+// ahead of the workloads without deadlines, and falls back to the priority
+// and the creation or eviction time. Deadlines that already passed at now
+// can't be met anymore, so they are ignored.
+func DeadlineAwareLess(a, b *workload.Info, now time.Time) bool {
+	dA, hasA := workload.Deadline(a.Obj)
+	hasA = hasA && dA.After(now)
+	dB, hasB := workload.Deadline(b.Obj)
+	hasB = hasB && dB.After(now)
+	if hasA != hasB {
+		return hasA
+	}
+	if hasA && !dA.Equal(dB) {
+		return dA.Before(dB)
+	}
+	return admissionOrderLess(a.Obj, b.Obj, now)
+}
+
+// OrderingComparator returns the less function used to order the workloads
+// of a ClusterQueue with the given queueing strategy, or nil if the strategy
+// is unknown. Both strategies order workloads by priority and then by
+// creation or eviction time. They only differ in how a workload at the head
+// that can't be admitted is handled: StrictFIFO keeps it at the head,
+// blocking the workloads behind it, see RequeueIfNotPresent.
+func OrderingComparator(strategy kueue.QueueingStrategy) func(a, b *workload.Info) bool {
+	switch strategy {
+	case kueue.StrictFIFO, kueue.BestEffortFIFO:
+		return workloadOrdering
+	}
+	return nil
 }
 
 // RequeueIfNotPresent requeues if the workload is not present.
@@ -17,9 +17,11 @@ limitations under the License.
 package queue
 
 import (
+	"sort"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
 
@@ -261,3 +263,169 @@ func TestStrictFIFORequeueIfNotPresent(t *testing.T) {
 		})
 	}
 }
+
+func TestDeadlineAwareLess(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	older := now.Add(-time.Minute)
+	cases := map[string]struct {
+		a, b *kueue.Workload
+		want bool
+	}{
+		"closer deadline first": {
+			a:    utiltesting.MakeWorkload("a", "").Creation(now).Deadline(now.Add(time.Hour)).Obj(),
+			b:    utiltesting.MakeWorkload("b", "").Creation(older).Deadline(now.Add(2 * time.Hour)).Obj(),
+			want: true,
+		},
+		"farther deadline last": {
+			a: utiltesting.MakeWorkload("a", "").Creation(older).Deadline(now.Add(2 * time.Hour)).Obj(),
+			b: utiltesting.MakeWorkload("b", "").Creation(now).Deadline(now.Add(time.Hour)).Obj(),
+		},
+		"deadline before higher priority without deadline": {
+			a:    utiltesting.MakeWorkload("a", "").Creation(now).Deadline(now.Add(time.Hour)).Obj(),
+			b:    utiltesting.MakeWorkload("b", "").Creation(older).Priority(highPriority).Obj(),
+			want: true,
+		},
+		"passed deadline is ignored": {
+			a: utiltesting.MakeWorkload("a", "").Creation(now).Deadline(now.Add(-time.Hour)).Obj(),
+			b: utiltesting.MakeWorkload("b", "").Creation(older).Obj(),
+		},
+		"invalid deadline is ignored": {
+			a: utiltesting.MakeWorkload("a", "").Creation(now).Annotation(kueue.DeadlineAnnotation, "tomorrow").Obj(),
+			b: utiltesting.MakeWorkload("b", "").Creation(older).Obj(),
+		},
+		"same deadline falls back to priority": {
+			a:    utiltesting.MakeWorkload("a", "").Creation(now).Priority(highPriority).Deadline(now.Add(time.Hour)).Obj(),
+			b:    utiltesting.MakeWorkload("b", "").Creation(older).Deadline(now.Add(time.Hour)).Obj(),
+			want: true,
+		},
+		"no deadlines falls back to priority": {
+			a:    utiltesting.MakeWorkload("a", "").Creation(now).Priority(highPriority).Obj(),
+			b:    utiltesting.MakeWorkload("b", "").Creation(older).Obj(),
+			want: true,
+		},
+		"no deadlines and same priority falls back to creation time": {
+			a: utiltesting.MakeWorkload("a", "").Creation(now).Obj(),
+			b: utiltesting.MakeWorkload("b", "").Creation(older).Obj(),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := DeadlineAwareLess(workload.NewInfo(tc.a), workload.NewInfo(tc.b), now)
+			if got != tc.want {
+				t.Errorf("DeadlineAwareLess()=%t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAdmissionOrderKey(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	older := now.Add(-time.Minute)
+	cases := map[string]struct {
+		workload     *kueue.Workload
+		wantPriority int32
+		wantTime     time.Time
+		wantName     string
+	}{
+		"created": {
+			workload:     utiltesting.MakeWorkload("a", "ns").Creation(older).Priority(highPriority).Obj(),
+			wantPriority: highPriority,
+			wantTime:     older,
+			wantName:     "ns/a",
+		},
+		"not created yet": {
+			workload: utiltesting.MakeWorkload("a", "ns").Obj(),
+			wantTime: now,
+			wantName: "ns/a",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			priority, ts, wlName := AdmissionOrderKey(tc.workload, now)
+			if priority != tc.wantPriority {
+				t.Errorf("Got priority %d, want %d", priority, tc.wantPriority)
+			}
+			if !ts.Equal(tc.wantTime) {
+				t.Errorf("Got timestamp %v, want %v", ts, tc.wantTime)
+			}
+			if wlName != tc.wantName {
+				t.Errorf("Got name %q, want %q", wlName, tc.wantName)
+			}
+		})
+	}
+}
+
+func TestAdmissionOrderTotal(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	infos := []*workload.Info{
+		workload.NewInfo(utiltesting.MakeWorkload("c", "ns").Creation(now).Obj()),
+		workload.NewInfo(utiltesting.MakeWorkload("a", "ns").Creation(now).Obj()),
+		workload.NewInfo(utiltesting.MakeWorkload("b", "ns").Creation(now).Obj()),
+		workload.NewInfo(utiltesting.MakeWorkload("a", "other").Creation(now).Obj()),
+	}
+	for _, a := range infos {
+		for _, b := range infos {
+			if a == b {
+				if workloadOrdering(a, b) {
+					t.Errorf("Workload %s ordered before itself", workload.Key(a.Obj))
+				}
+				continue
+			}
+			if workloadOrdering(a, b) == workloadOrdering(b, a) {
+				t.Errorf("Workloads %s and %s are not strictly ordered", workload.Key(a.Obj), workload.Key(b.Obj))
+			}
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return workloadOrdering(infos[i], infos[j])
+	})
+	got := make([]string, len(infos))
+	for i, info := range infos {
+		got[i] = workload.Key(info.Obj)
+	}
+	want := []string{"ns/a", "ns/b", "ns/c", "other/a"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected order (-want,+got):\n%s", diff)
+	}
+}
+
+func TestWorkloadOrderingStable(t *testing.T) {
+	notCreated := workload.NewInfo(utiltesting.MakeWorkload("a", "ns").Obj())
+	created := workload.NewInfo(utiltesting.MakeWorkload("b", "ns").Creation(time.Now().Add(-time.Hour)).Obj())
+	// The order doesn't depend on the current time, so the workload without a
+	// timestamp is ordered first, regardless of when the other was created.
+	if !workloadOrdering(notCreated, created) || workloadOrdering(created, notCreated) {
+		t.Errorf("The workload without a timestamp isn't ordered first")
+	}
+}
+
+func TestOrderingComparator(t *testing.T) {
+	now := time.Now()
+	older := workload.NewInfo(utiltesting.MakeWorkload("older", "").
+		Creation(now.Add(-time.Second)).
+		Obj())
+	newer := workload.NewInfo(utiltesting.MakeWorkload("newer", "").
+		Creation(now).
+		Obj())
+	newerHighPriority := workload.NewInfo(utiltesting.MakeWorkload("newer-high", "").
+		Creation(now).
+		Priority(highPriority).
+		Obj())
+	for _, strategy := range []kueue.QueueingStrategy{kueue.StrictFIFO, kueue.BestEffortFIFO} {
+		t.Run(string(strategy), func(t *testing.T) {
+			less := OrderingComparator(strategy)
+			if less == nil {
+				t.Fatalf("OrderingComparator(%s) is nil", strategy)
+			}
+			if !less(older, newer) || less(newer, older) {
+				t.Errorf("Workload %q not ordered before %q", "older", "newer")
+			}
+			if !less(newerHighPriority, older) || less(older, newerHighPriority) {
+				t.Errorf("Workload %q not ordered before %q", "newer-high", "older")
+			}
+		})
+	}
+	if less := OrderingComparator("Unknown"); less != nil {
+		t.Error("OrderingComparator for an unknown strategy is not nil")
+	}
+}
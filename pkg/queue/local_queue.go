@@ -33,10 +33,18 @@ func Key(q *kueue.LocalQueue) string {
 	return fmt.Sprintf("%s/%s", q.Namespace, q.Name)
 }
 
+// IsActive returns true if the LocalQueue is not paused by its stop policy.
+func IsActive(q *kueue.LocalQueue) bool {
+	return q.Spec.StopPolicy == "" || q.Spec.StopPolicy == kueue.None
+}
+
 // LocalQueue is the internal implementation of kueue.LocalQueue.
 type LocalQueue struct {
 	Key          string
 	ClusterQueue string
+	// active is false when the LocalQueue is paused, in which case its
+	// workloads are not pushed to the ClusterQueue.
+	active bool
 
 	items map[string]*workload.Info
 }
@@ -52,6 +60,7 @@ func newLocalQueue(q *kueue.LocalQueue) *LocalQueue {
 
 func (q *LocalQueue) update(apiQueue *kueue.LocalQueue) {
 	q.ClusterQueue = string(apiQueue.Spec.ClusterQueue)
+	q.active = IsActive(apiQueue)
 }
 
 func (q *LocalQueue) AddOrUpdate(info *workload.Info) {
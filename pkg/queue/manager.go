@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -92,7 +93,7 @@ func (m *Manager) AddClusterQueue(ctx context.Context, cq *kueue.ClusterQueue) e
 	addedWorkloads := false
 	for _, q := range queues.Items {
 		qImpl := m.localQueues[Key(&q)]
-		if qImpl != nil {
+		if qImpl != nil && qImpl.active {
 			added := cqImpl.AddFromLocalQueue(qImpl)
 			addedWorkloads = addedWorkloads || added
 		}
@@ -165,13 +166,13 @@ func (m *Manager) AddLocalQueue(ctx context.Context, q *kueue.LocalQueue) error
 	}
 	for _, w := range workloads.Items {
 		w := w
-		if workload.IsAdmitted(&w) {
+		if workload.IsAdmitted(&w) || !workload.IsQueueable(&w, time.Now()) {
 			continue
 		}
 		qImpl.AddOrUpdate(workload.NewInfo(&w))
 	}
 	cq := m.clusterQueues[qImpl.ClusterQueue]
-	if cq != nil && cq.AddFromLocalQueue(qImpl) {
+	if cq != nil && qImpl.active && cq.AddFromLocalQueue(qImpl) {
 		m.Broadcast()
 	}
 	return nil
@@ -184,13 +185,13 @@ func (m *Manager) UpdateLocalQueue(q *kueue.LocalQueue) error {
 	if !ok {
 		return errQueueDoesNotExist
 	}
-	if qImpl.ClusterQueue != string(q.Spec.ClusterQueue) {
+	if qImpl.ClusterQueue != string(q.Spec.ClusterQueue) || qImpl.active != IsActive(q) {
 		oldCQ := m.clusterQueues[qImpl.ClusterQueue]
-		if oldCQ != nil {
+		if oldCQ != nil && qImpl.active {
 			oldCQ.DeleteFromLocalQueue(qImpl)
 		}
 		newCQ := m.clusterQueues[string(q.Spec.ClusterQueue)]
-		if newCQ != nil && newCQ.AddFromLocalQueue(qImpl) {
+		if newCQ != nil && IsActive(q) && newCQ.AddFromLocalQueue(qImpl) {
 			m.Broadcast()
 		}
 	}
@@ -266,12 +267,23 @@ func (m *Manager) addOrUpdateWorkload(w *kueue.Workload) bool {
 	if q == nil {
 		return false
 	}
+	if !workload.IsQueueable(w, time.Now()) {
+		// Inactive workloads and workloads held back after a PodsReady
+		// timeout are kept out of the queues.
+		m.deleteWorkloadFromQueueAndClusterQueue(w, qKey)
+		return true
+	}
 	wInfo := workload.NewInfo(w)
 	q.AddOrUpdate(wInfo)
 	cq := m.clusterQueues[q.ClusterQueue]
 	if cq == nil {
 		return false
 	}
+	if !q.active {
+		// The workload is pushed to the ClusterQueue once the LocalQueue is
+		// resumed.
+		return true
+	}
 	cq.PushOrUpdate(wInfo)
 	m.reportPendingWorkloads(q.ClusterQueue, cq)
 	m.Broadcast()
@@ -289,7 +301,7 @@ func (m *Manager) RequeueWorkload(ctx context.Context, info *workload.Info, reas
 	// Always get the newest workload to avoid requeuing the out-of-date obj.
 	err := m.client.Get(ctx, client.ObjectKeyFromObject(info.Obj), &w)
 	// Since the client is cached, the only possible error is NotFound
-	if apierrors.IsNotFound(err) || workload.IsAdmitted(&w) {
+	if apierrors.IsNotFound(err) || workload.IsAdmitted(&w) || !workload.IsQueueable(&w, time.Now()) {
 		return false
 	}
 
@@ -300,7 +312,7 @@ func (m *Manager) RequeueWorkload(ctx context.Context, info *workload.Info, reas
 	info.Update(&w)
 	q.AddOrUpdate(info)
 	cq := m.clusterQueues[q.ClusterQueue]
-	if cq == nil {
+	if cq == nil || !q.active {
 		return false
 	}
 
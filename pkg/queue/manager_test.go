@@ -32,6 +32,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/util/pointer"
 	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
 	"sigs.k8s.io/kueue/pkg/workload"
 )
@@ -239,6 +240,94 @@ func TestUpdateLocalQueue(t *testing.T) {
 	}
 }
 
+// TestPauseLocalQueue tests that the workloads of a paused LocalQueue are not
+// listed in the ClusterQueue until the LocalQueue is resumed.
+func TestPauseLocalQueue(t *testing.T) {
+	cq := utiltesting.MakeClusterQueue("cq").Obj()
+	queues := []*kueue.LocalQueue{
+		utiltesting.MakeLocalQueue("foo", "").ClusterQueue("cq").StopPolicy(kueue.Hold).Obj(),
+		utiltesting.MakeLocalQueue("bar", "").ClusterQueue("cq").Obj(),
+	}
+	now := time.Now()
+	workloads := []*kueue.Workload{
+		utiltesting.MakeWorkload("a", "").Queue("foo").Creation(now).Obj(),
+		utiltesting.MakeWorkload("b", "").Queue("bar").Creation(now.Add(time.Second)).Obj(),
+	}
+
+	ctx := context.Background()
+	manager := NewManager(utiltesting.NewFakeClient(), nil)
+	if err := manager.AddClusterQueue(ctx, cq); err != nil {
+		t.Fatalf("Failed adding clusterQueue %s: %v", cq.Name, err)
+	}
+	for _, q := range queues {
+		if err := manager.AddLocalQueue(ctx, q); err != nil {
+			t.Fatalf("Failed adding queue %s: %v", q.Name, err)
+		}
+	}
+	for _, w := range workloads {
+		if !manager.AddOrUpdateWorkload(w) {
+			t.Errorf("Workload %s not added", w.Name)
+		}
+	}
+	wantDump := map[string]sets.Set[string]{
+		"cq": sets.New("/b"),
+	}
+	if diff := cmp.Diff(wantDump, manager.Dump()); diff != "" {
+		t.Errorf("Unexpected workloads while paused (-want,+got):\n%s", diff)
+	}
+	if pending, err := manager.PendingWorkloads(queues[0]); err != nil || pending != 1 {
+		t.Errorf("PendingWorkloads(%s)=(%d, %v), want (1, nil)", queues[0].Name, pending, err)
+	}
+
+	queues[0].Spec.StopPolicy = kueue.None
+	if err := manager.UpdateLocalQueue(queues[0]); err != nil {
+		t.Fatalf("Failed updating queue: %v", err)
+	}
+	wantDump = map[string]sets.Set[string]{
+		"cq": sets.New("/a", "/b"),
+	}
+	if diff := cmp.Diff(wantDump, manager.Dump()); diff != "" {
+		t.Errorf("Unexpected workloads after resuming (-want,+got):\n%s", diff)
+	}
+
+	queues[0].Spec.StopPolicy = kueue.Hold
+	if err := manager.UpdateLocalQueue(queues[0]); err != nil {
+		t.Fatalf("Failed updating queue: %v", err)
+	}
+	wantDump = map[string]sets.Set[string]{
+		"cq": sets.New("/b"),
+	}
+	if diff := cmp.Diff(wantDump, manager.Dump()); diff != "" {
+		t.Errorf("Unexpected workloads after pausing again (-want,+got):\n%s", diff)
+	}
+}
+
+func TestLocalQueueIsActive(t *testing.T) {
+	cases := map[string]struct {
+		queue *kueue.LocalQueue
+		want  bool
+	}{
+		"no stop policy": {
+			queue: utiltesting.MakeLocalQueue("foo", "").Obj(),
+			want:  true,
+		},
+		"stop policy None": {
+			queue: utiltesting.MakeLocalQueue("foo", "").StopPolicy(kueue.None).Obj(),
+			want:  true,
+		},
+		"stop policy Hold": {
+			queue: utiltesting.MakeLocalQueue("foo", "").StopPolicy(kueue.Hold).Obj(),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := IsActive(tc.queue); got != tc.want {
+				t.Errorf("IsActive()=%t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
 // TestDeleteLocalQueue tests that when a LocalQueue is deleted, all its
 // workloads are not listed in the ClusterQueue.
 func TestDeleteLocalQueue(t *testing.T) {
@@ -337,6 +426,59 @@ func TestAddWorkload(t *testing.T) {
 	}
 }
 
+func TestAddWorkloadNotQueueable(t *testing.T) {
+	now := time.Now()
+	cases := map[string]struct {
+		active       *bool
+		requeueAfter time.Duration
+		wantDump     map[string]sets.Set[string]
+	}{
+		"active": {
+			wantDump: map[string]sets.Set[string]{"cq": sets.New("/a")},
+		},
+		"inactive": {
+			active: pointer.Bool(false),
+		},
+		"held back after a PodsReady timeout": {
+			requeueAfter: time.Minute,
+		},
+		"requeue time passed": {
+			requeueAfter: -time.Minute,
+			wantDump:     map[string]sets.Set[string]{"cq": sets.New("/a")},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			manager := NewManager(utiltesting.NewFakeClient(), nil)
+			ctx := context.Background()
+			if err := manager.AddClusterQueue(ctx, utiltesting.MakeClusterQueue("cq").Obj()); err != nil {
+				t.Fatalf("Failed adding clusterQueue: %v", err)
+			}
+			if err := manager.AddLocalQueue(ctx, utiltesting.MakeLocalQueue("foo", "").ClusterQueue("cq").Obj()); err != nil {
+				t.Fatalf("Failed adding queue: %v", err)
+			}
+			// The workload is first added as queueable, to verify that it's
+			// removed from the queue by the update.
+			wl := utiltesting.MakeWorkload("a", "").Queue("foo").Obj()
+			manager.AddOrUpdateWorkload(wl)
+			wl = wl.DeepCopy()
+			wl.Spec.Active = tc.active
+			if tc.requeueAfter != 0 {
+				wl.Status.RequeueState = &kueue.RequeueState{
+					Count:     pointer.Int32(1),
+					RequeueAt: &metav1.Time{Time: now.Add(tc.requeueAfter)},
+				}
+			}
+			if !manager.AddOrUpdateWorkload(wl) {
+				t.Fatalf("AddOrUpdateWorkload returned false, the queue exists")
+			}
+			if diff := cmp.Diff(tc.wantDump, manager.Dump()); diff != "" {
+				t.Errorf("Unexpected elements in the queues (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestStatus(t *testing.T) {
 	ctx := context.Background()
 	scheme := runtime.NewScheme()
@@ -0,0 +1,111 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// AdmissionRate configures the rate of admissions for a ClusterQueue.
+type AdmissionRate struct {
+	// QPS is the number of admissions per second allowed in steady state.
+	QPS float64
+	// Burst is the maximum number of admissions allowed at once.
+	Burst int
+}
+
+// AdmissionLimiter limits the number of admissions per second for each
+// ClusterQueue, using a token bucket per ClusterQueue. ClusterQueues without
+// a configured rate are not limited.
+type AdmissionLimiter struct {
+	clock clock.PassiveClock
+
+	sync.Mutex
+	rates   map[string]AdmissionRate
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+// NewAdmissionLimiter creates an AdmissionLimiter with the given rates, keyed
+// by ClusterQueue name.
+func NewAdmissionLimiter(clock clock.PassiveClock, rates map[string]AdmissionRate) *AdmissionLimiter {
+	l := &AdmissionLimiter{
+		clock:   clock,
+		rates:   make(map[string]AdmissionRate, len(rates)),
+		buckets: make(map[string]*tokenBucket, len(rates)),
+	}
+	for cq, r := range rates {
+		l.rates[cq] = r
+	}
+	return l
+}
+
+// SetRate configures the rate of admissions for the ClusterQueue, resetting
+// its bucket.
+func (l *AdmissionLimiter) SetRate(cq string, rate AdmissionRate) {
+	l.Lock()
+	defer l.Unlock()
+	l.rates[cq] = rate
+	delete(l.buckets, cq)
+}
+
+// RemoveRate removes the limit for the ClusterQueue.
+func (l *AdmissionLimiter) RemoveRate(cq string) {
+	l.Lock()
+	defer l.Unlock()
+	delete(l.rates, cq)
+	delete(l.buckets, cq)
+}
+
+// Allow returns whether an admission in the ClusterQueue can proceed now,
+// consuming a token if so.
+func (l *AdmissionLimiter) Allow(cq string) bool {
+	l.Lock()
+	defer l.Unlock()
+	rate, found := l.rates[cq]
+	if !found {
+		return true
+	}
+	now := l.clock.Now()
+	b := l.buckets[cq]
+	if b == nil {
+		b = &tokenBucket{
+			tokens:     float64(rate.Burst),
+			lastRefill: now,
+		}
+		l.buckets[cq] = b
+	}
+	if elapsed := now.Sub(b.lastRefill); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * rate.QPS
+		if b.tokens > float64(rate.Burst) {
+			b.tokens = float64(rate.Burst)
+		}
+	}
+	b.lastRefill = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
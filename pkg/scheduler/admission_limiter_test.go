@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+func TestAdmissionLimiter(t *testing.T) {
+	type step struct {
+		advance time.Duration
+		cq      string
+		want    bool
+	}
+	cases := map[string]struct {
+		rates map[string]AdmissionRate
+		steps []step
+	}{
+		"burst": {
+			rates: map[string]AdmissionRate{
+				"cq": {QPS: 1, Burst: 3},
+			},
+			steps: []step{
+				{cq: "cq", want: true},
+				{cq: "cq", want: true},
+				{cq: "cq", want: true},
+				{cq: "cq", want: false},
+			},
+		},
+		"steady state": {
+			rates: map[string]AdmissionRate{
+				"cq": {QPS: 2, Burst: 1},
+			},
+			steps: []step{
+				{cq: "cq", want: true},
+				{cq: "cq", want: false},
+				{advance: 250 * time.Millisecond, cq: "cq", want: false},
+				{advance: 250 * time.Millisecond, cq: "cq", want: true},
+				{cq: "cq", want: false},
+				{advance: 500 * time.Millisecond, cq: "cq", want: true},
+			},
+		},
+		"tokens don't accumulate beyond burst": {
+			rates: map[string]AdmissionRate{
+				"cq": {QPS: 10, Burst: 2},
+			},
+			steps: []step{
+				{cq: "cq", want: true},
+				{advance: time.Minute, cq: "cq", want: true},
+				{cq: "cq", want: true},
+				{cq: "cq", want: false},
+			},
+		},
+		"independent ClusterQueues": {
+			rates: map[string]AdmissionRate{
+				"a": {QPS: 1, Burst: 1},
+				"b": {QPS: 1, Burst: 1},
+			},
+			steps: []step{
+				{cq: "a", want: true},
+				{cq: "a", want: false},
+				{cq: "b", want: true},
+				{cq: "b", want: false},
+			},
+		},
+		"ClusterQueue without rate is unlimited": {
+			rates: map[string]AdmissionRate{
+				"a": {QPS: 1, Burst: 1},
+			},
+			steps: []step{
+				{cq: "other", want: true},
+				{cq: "other", want: true},
+				{cq: "other", want: true},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fakeClock := testingclock.NewFakePassiveClock(time.Now())
+			limiter := NewAdmissionLimiter(fakeClock, tc.rates)
+			var want, got []bool
+			for _, s := range tc.steps {
+				fakeClock.SetTime(fakeClock.Now().Add(s.advance))
+				want = append(want, s.want)
+				got = append(got, limiter.Allow(s.cq))
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Unexpected results of Allow (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdmissionLimiterSetRate(t *testing.T) {
+	fakeClock := testingclock.NewFakePassiveClock(time.Now())
+	limiter := NewAdmissionLimiter(fakeClock, nil)
+	if !limiter.Allow("cq") {
+		t.Fatal("Allow returned false for a ClusterQueue without rate")
+	}
+	limiter.SetRate("cq", AdmissionRate{QPS: 1, Burst: 1})
+	if !limiter.Allow("cq") {
+		t.Error("Allow returned false for the first admission after setting the rate")
+	}
+	if limiter.Allow("cq") {
+		t.Error("Allow returned true after exhausting the burst")
+	}
+	limiter.RemoveRate("cq")
+	if !limiter.Allow("cq") {
+		t.Error("Allow returned false after removing the rate")
+	}
+}
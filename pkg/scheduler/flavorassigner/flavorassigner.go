@@ -17,10 +17,13 @@ limitations under the License.
 package flavorassigner
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/go-logr/logr"
 	"github.com/google/go-cmp/cmp"
@@ -30,15 +33,22 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
 	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
+	"k8s.io/utils/pointer"
 
 	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
 	"sigs.k8s.io/kueue/pkg/cache"
+	"sigs.k8s.io/kueue/pkg/util/api"
+	utilpriority "sigs.k8s.io/kueue/pkg/util/priority"
+	"sigs.k8s.io/kueue/pkg/util/tolerations"
 	"sigs.k8s.io/kueue/pkg/workload"
 )
 
 type Assignment struct {
 	PodSets     []PodSetAssignment
 	TotalBorrow cache.FlavorResourceQuantities
+	// Epoch is the generation of the ClusterQueue that the assignment was
+	// computed against.
+	Epoch int64
 
 	// usedResources is the accumulated usage of resources as pod sets get
 	// flavors assigned.
@@ -46,6 +56,10 @@ type Assignment struct {
 
 	// representativeMode is the cached representative mode for this assignment.
 	representativeMode *FlavorAssignmentMode
+
+	// status holds the reasons why the workload as a whole can't be assigned
+	// flavors, regardless of its pod sets.
+	status *Status
 }
 
 func (a *Assignment) Borrows() bool {
@@ -73,10 +87,38 @@ func (a *Assignment) RepresentativeMode() FlavorAssignmentMode {
 	return mode
 }
 
+// IsStale returns true if the ClusterQueue changed since the assignment was
+// computed, in which case the assignment might no longer be valid.
+func (a *Assignment) IsStale(cq *cache.ClusterQueue) bool {
+	return a.Epoch != cq.Generation()
+}
+
+// ModesByPodSet returns the representative mode of each pod set in the
+// assignment, keyed by the pod set name.
+func (a *Assignment) ModesByPodSet() map[string]FlavorAssignmentMode {
+	modes := make(map[string]FlavorAssignmentMode, len(a.PodSets))
+	for i := range a.PodSets {
+		modes[a.PodSets[i].Name] = a.PodSets[i].RepresentativeMode()
+	}
+	return modes
+}
+
+// Err returns the error that prevented the flavors from being assigned to the
+// workload as a whole, if any.
+func (a *Assignment) Err() error {
+	if a.status == nil {
+		return nil
+	}
+	return a.status.err
+}
+
 func (a *Assignment) Message() string {
+	if a.status != nil {
+		return a.status.Message()
+	}
 	var builder strings.Builder
 	for _, ps := range a.PodSets {
-		if ps.Status == nil {
+		if !ps.Status.failed() {
 			continue
 		}
 		if ps.Status.IsError() {
@@ -93,6 +135,179 @@ func (a *Assignment) Message() string {
 	return builder.String()
 }
 
+// Bottleneck returns the resource and flavor with the largest shortfall of
+// quota among the reasons why the pod sets couldn't be assigned flavors. The
+// shortfalls are normalized by the requests of the pod set, so that different
+// resources can be compared. It returns empty values if no flavor was rejected
+// due to quota.
+func (a *Assignment) Bottleneck() (corev1.ResourceName, kueue.ResourceFlavorReference) {
+	var rName corev1.ResourceName
+	var fName kueue.ResourceFlavorReference
+	var maxShortfall float64
+	for _, ps := range a.PodSets {
+		if ps.Status == nil {
+			continue
+		}
+		for _, r := range ps.Status.rejections {
+			if r.lack <= 0 {
+				continue
+			}
+			shortfall := float64(r.lack)
+			if q, found := ps.Requests[r.resource]; found {
+				if v := workload.ResourceValue(r.resource, q); v > 0 {
+					shortfall /= float64(v)
+				}
+			}
+			if shortfall > maxShortfall {
+				maxShortfall = shortfall
+				rName, fName = r.resource, r.flavor
+			}
+		}
+	}
+	return rName, fName
+}
+
+// ShortSummary returns a one-line summary of the assignment, suitable for
+// events, like "Fit: main[cpu=two,memory=two]; borrow cpu=2000". The borrowed
+// quantities are aggregated across flavors.
+func (a *Assignment) ShortSummary() string {
+	var builder strings.Builder
+	builder.WriteString(a.RepresentativeMode().String())
+	builder.WriteString(":")
+	for i, ps := range a.PodSets {
+		if i > 0 {
+			builder.WriteString(",")
+		}
+		builder.WriteString(" ")
+		builder.WriteString(ps.Name)
+		builder.WriteString("[")
+		resources := make([]string, 0, len(ps.Flavors))
+		for rName, flvAssignment := range ps.Flavors {
+			resources = append(resources, fmt.Sprintf("%s=%s", rName, flvAssignment.Name))
+		}
+		sort.Strings(resources)
+		builder.WriteString(strings.Join(resources, ","))
+		builder.WriteString("]")
+	}
+	if a.Borrows() {
+		borrow := make(map[corev1.ResourceName]int64)
+		for _, resBorrow := range a.TotalBorrow {
+			for rName, v := range resBorrow {
+				borrow[rName] += v
+			}
+		}
+		entries := make([]string, 0, len(borrow))
+		for rName, v := range borrow {
+			entries = append(entries, fmt.Sprintf("%s=%d", rName, v))
+		}
+		sort.Strings(entries)
+		builder.WriteString("; borrow ")
+		builder.WriteString(strings.Join(entries, ","))
+	}
+	return api.TruncateEventMessage(builder.String())
+}
+
+// Equal returns whether both assignments have the same pod sets, with the
+// same flavors, modes and borrowing, and the same total borrowing. The cached
+// representative mode and the accumulated usage are ignored.
+func (a *Assignment) Equal(o *Assignment) bool {
+	if a == nil || o == nil {
+		return a == o
+	}
+	if len(a.PodSets) != len(o.PodSets) {
+		return false
+	}
+	for i := range a.PodSets {
+		if !a.PodSets[i].equal(&o.PodSets[i]) {
+			return false
+		}
+	}
+	if !a.status.Equal(o.status) {
+		return false
+	}
+	return cmp.Equal(a.TotalBorrow, o.TotalBorrow, cmpopts.EquateEmpty())
+}
+
+// The condition reasons used by ToConditions, besides the RejectionReasons.
+const (
+	ConditionReasonFit         = "Fit"
+	ConditionReasonPreemptable = "Preemptable"
+	ConditionReasonNoFit       = "NoFit"
+	ConditionReasonError       = "AssignmentError"
+)
+
+// noFitReasonsPriority is the order in which rejection reasons are chosen as
+// the reason of the conditions for assignments that don't fit.
+var noFitReasonsPriority = []RejectionReason{
+	ResourceUnavailable,
+	NodeNamePinning,
+	InsufficientQuota,
+	BorrowingLimitExceeded,
+	FairShareExceeded,
+	MaxConcurrentWorkloadsReached,
+	UntoleratedTaint,
+	NodeAffinityMismatch,
+	SchedulerNameMismatch,
+	RequestBelowMinimum,
+	FlavorUnschedulable,
+	FlavorNotFound,
+}
+
+// ToConditions returns the QuotaReserved condition describing the result of
+// the assignment, with a machine-parseable reason: Fit, Preemptable,
+// AssignmentError or, for assignments that don't fit, the most relevant
+// RejectionReason. The Admitted condition is not included, as admission is
+// decided by the scheduler once the quota is reserved.
+func (a *Assignment) ToConditions() []metav1.Condition {
+	reason := a.conditionReason()
+	status := metav1.ConditionFalse
+	message := a.Message()
+	if reason == ConditionReasonFit {
+		status = metav1.ConditionTrue
+		message = "The workload fits in the available quota"
+	}
+	message = api.TruncateConditionMessage(message)
+	return []metav1.Condition{{
+		Type:               kueue.WorkloadQuotaReserved,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}}
+}
+
+func (a *Assignment) conditionReason() string {
+	if a.Err() != nil {
+		return ConditionReasonError
+	}
+	for _, ps := range a.PodSets {
+		if ps.Status.IsError() {
+			return ConditionReasonError
+		}
+	}
+	switch a.RepresentativeMode() {
+	case Fit:
+		return ConditionReasonFit
+	case Preempt:
+		return ConditionReasonPreemptable
+	}
+	reasons := sets.New[RejectionReason]()
+	for _, ps := range a.PodSets {
+		if ps.Status == nil {
+			continue
+		}
+		for _, r := range ps.Status.rejections {
+			reasons.Insert(r.reason)
+		}
+	}
+	for _, r := range noFitReasonsPriority {
+		if reasons.Has(r) {
+			return string(r)
+		}
+	}
+	return ConditionReasonNoFit
+}
+
 func (a *Assignment) ToAPI() []kueue.PodSetAssignment {
 	psFlavors := make([]kueue.PodSetAssignment, len(a.PodSets))
 	for i := range psFlavors {
@@ -101,17 +316,587 @@ func (a *Assignment) ToAPI() []kueue.PodSetAssignment {
 	return psFlavors
 }
 
+type jsonAssignment struct {
+	PodSets            []jsonPodSetAssignment         `json:"podSets"`
+	TotalBorrow        cache.FlavorResourceQuantities `json:"totalBorrow,omitempty"`
+	RepresentativeMode string                         `json:"representativeMode"`
+}
+
+type jsonPodSetAssignment struct {
+	Name     string                                       `json:"name"`
+	Flavors  map[corev1.ResourceName]jsonFlavorAssignment `json:"flavors,omitempty"`
+	Status   string                                       `json:"status,omitempty"`
+	Requests corev1.ResourceList                          `json:"requests,omitempty"`
+	Count    int32                                        `json:"count,omitempty"`
+}
+
+type jsonFlavorAssignment struct {
+	Name   kueue.ResourceFlavorReference `json:"name"`
+	Mode   string                        `json:"mode"`
+	Borrow int64                         `json:"borrow,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler, including the unexported borrow of
+// each flavor assignment. It's meant for debugging purposes.
+func (a *Assignment) MarshalJSON() ([]byte, error) {
+	out := jsonAssignment{
+		PodSets:            make([]jsonPodSetAssignment, len(a.PodSets)),
+		TotalBorrow:        a.TotalBorrow,
+		RepresentativeMode: a.RepresentativeMode().String(),
+	}
+	for i, ps := range a.PodSets {
+		psOut := jsonPodSetAssignment{
+			Name:     ps.Name,
+			Status:   ps.Status.Message(),
+			Requests: ps.Requests,
+			Count:    ps.count,
+		}
+		if len(ps.Flavors) > 0 {
+			psOut.Flavors = make(map[corev1.ResourceName]jsonFlavorAssignment, len(ps.Flavors))
+			for rName, flvAssignment := range ps.Flavors {
+				psOut.Flavors[rName] = jsonFlavorAssignment{
+					Name:   flvAssignment.Name,
+					Mode:   flvAssignment.Mode.String(),
+					Borrow: flvAssignment.borrow,
+				}
+			}
+		}
+		out.PodSets[i] = psOut
+	}
+	return json.Marshal(out)
+}
+
+// WhyNotFlavor returns the reasons why the flavor couldn't be assigned
+// immediately to the pod set, such as untolerated taints, unmatched node
+// affinity or insufficient quota. It returns an empty string if the flavor
+// wasn't rejected or it wasn't evaluated for the pod set.
+func (a *Assignment) WhyNotFlavor(podSet string, flavor kueue.ResourceFlavorReference) string {
+	for _, ps := range a.PodSets {
+		if ps.Name != podSet {
+			continue
+		}
+		var reasons []string
+		for _, r := range ps.rejections {
+			if r.flavor == flavor {
+				reasons = append(reasons, r.message)
+			}
+		}
+		sort.Strings(reasons)
+		return strings.Join(reasons, ", ")
+	}
+	return ""
+}
+
+// OversizedResources returns the names of the resources for which a pod set
+// requests more than the largest single flavor in the ClusterQueue can
+// provide, considering its nominal quota and borrowing limit. Workloads with
+// oversized resources can never be admitted, regardless of the usage.
+func (a *Assignment) OversizedResources(cq *cache.ClusterQueue) []string {
+	oversized := sets.New[string]()
+	for _, ps := range a.PodSets {
+		for rName, q := range ps.Requests {
+			rg, found := cq.RGByResource[rName]
+			if !found {
+				continue
+			}
+			val := workload.ResourceValue(rName, q)
+			fits := false
+			for _, flvQuotas := range rg.Flavors {
+				if val <= maxCapacity(flvQuotas.Name, rName, cq, flvQuotas.Resources[rName]) {
+					fits = true
+					break
+				}
+			}
+			if !fits {
+				oversized.Insert(string(rName))
+			}
+		}
+	}
+	if oversized.Len() == 0 {
+		return nil
+	}
+	return sets.List(oversized)
+}
+
+// StructurallyFits returns whether the workload could fit in the ClusterQueue
+// if nothing else was running in the ClusterQueue or its cohort, considering
+// the nominal quota and what can be borrowed from the cohort, see maxCapacity.
+// The flavors are taken in order for each pod set and resource group, as
+// AssignFlavors does. Only the quota is considered: the taints, node labels
+// and resource aliases of the flavors are ignored.
+func StructurallyFits(wl *workload.Info, cq *cache.ClusterQueue) bool {
+	_, podsTracked := cq.RGByResource[corev1.ResourcePods]
+	used := make(cache.FlavorResourceQuantities)
+	for i, ps := range wl.TotalRequests {
+		requests := make(workload.Requests, len(ps.Requests)+1)
+		for rName, v := range dropZeroRequests(ps.Requests) {
+			requests[rName] = v
+		}
+		if podsTracked {
+			requests[corev1.ResourcePods] = int64(wl.Obj.Spec.PodSets[i].Count)
+		}
+		for _, rName := range sets.List(sets.KeySet(requests)) {
+			if _, found := cq.RGByResource[rName]; !found {
+				return false
+			}
+		}
+		for j := range cq.ResourceGroups {
+			rg := &cq.ResourceGroups[j]
+			rgRequests := filterRequestedResources(requests, rg.CoveredResources)
+			if len(rgRequests) == 0 {
+				continue
+			}
+			fits := false
+			for _, flvQuotas := range rg.Flavors {
+				fits = true
+				for rName, v := range rgRequests {
+					if used[flvQuotas.Name][rName]+v > maxCapacity(flvQuotas.Name, rName, cq, flvQuotas.Resources[rName]) {
+						fits = false
+						break
+					}
+				}
+				if fits {
+					if used[flvQuotas.Name] == nil {
+						used[flvQuotas.Name] = make(map[corev1.ResourceName]int64)
+					}
+					for rName, v := range rgRequests {
+						used[flvQuotas.Name][rName] += v
+					}
+					break
+				}
+			}
+			if !fits {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// maxCapacity returns the maximum quantity of the resource in the flavor that
+// the ClusterQueue could use if nothing else was running in the cohort.
+func maxCapacity(fName kueue.ResourceFlavorReference, rName corev1.ResourceName, cq *cache.ClusterQueue, rQuota *cache.ResourceQuota) int64 {
+	if rQuota == nil {
+		return 0
+	}
+	nominal := rQuota.OvercommittedNominal()
+	if cq.Cohort == nil {
+		return nominal
+	}
+	return nominal + EffectiveBorrowingLimit(rQuota, cq.RequestableCohortQuota(fName, rName)+nominal-rQuota.Nominal, nominal)
+}
+
+// EffectiveBorrowingLimit returns the maximum quantity that the ClusterQueue
+// can borrow from the cohort for the resource quota. An explicit borrowing
+// limit is returned as is, while a nil borrowing limit is capped by the quota
+// available in the cohort beyond the nominal quota.
+func EffectiveBorrowingLimit(rQuota *cache.ResourceQuota, cohortAvailable, nominal int64) int64 {
+	if rQuota != nil && rQuota.BorrowingLimit != nil {
+		return *rQuota.BorrowingLimit
+	}
+	if cohortAvailable <= nominal {
+		return 0
+	}
+	return cohortAvailable - nominal
+}
+
+// CurrentBorrow returns the quantities that the ClusterQueue is currently
+// borrowing from its cohort, for each flavor and resource in which its usage
+// is above the nominal quota.
+func CurrentBorrow(cq *cache.ClusterQueue) cache.FlavorResourceQuantities {
+	var borrow cache.FlavorResourceQuantities
+	for _, rg := range cq.ResourceGroups {
+		for _, flvQuotas := range rg.Flavors {
+			for rName, rQuota := range flvQuotas.Resources {
+				b := cq.Usage[flvQuotas.Name][rName] - rQuota.Nominal
+				if b <= 0 {
+					continue
+				}
+				if borrow == nil {
+					borrow = make(cache.FlavorResourceQuantities)
+				}
+				if borrow[flvQuotas.Name] == nil {
+					borrow[flvQuotas.Name] = make(map[corev1.ResourceName]int64)
+				}
+				borrow[flvQuotas.Name][rName] = b
+			}
+		}
+	}
+	return borrow
+}
+
+// Usage returns the quantities of each flavor and resource used by the pod
+// sets of the assignment.
+func (a *Assignment) Usage() cache.FlavorResourceQuantities {
+	usage := make(cache.FlavorResourceQuantities)
+	for _, psa := range a.PodSets {
+		for rName, flvAssignment := range psa.Flavors {
+			q, found := psa.Requests[rName]
+			if !found {
+				continue
+			}
+			if usage[flvAssignment.Name] == nil {
+				usage[flvAssignment.Name] = make(map[corev1.ResourceName]int64)
+			}
+			usage[flvAssignment.Name][rName] += workload.ResourceValue(rName, q)
+		}
+	}
+	return usage
+}
+
+// CanFitByWaiting returns whether the assignment needs preemption only because
+// of quota in use by the workloads of the ClusterQueue itself, as opposed to
+// quota that needs to be reclaimed from the cohort. In that case, waiting for
+// the running workloads to finish would eventually make the assignment fit.
+func (a *Assignment) CanFitByWaiting() bool {
+	if a.RepresentativeMode() != Preempt {
+		return false
+	}
+	for _, psa := range a.PodSets {
+		for rName, flvAssignment := range psa.Flavors {
+			if flvAssignment.Mode != Preempt {
+				continue
+			}
+			if !psa.Status.heldByClusterQueue(flvAssignment.Name, rName) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// PreemptionTargets returns, for each flavor assigned in Preempt mode, the
+// quantities of the resources that need to be freed for the assignment to fit.
+// When several pod sets need preemption in the same flavor, the largest
+// quantity is reported, given that the lack accounts for the usage of the
+// previous pod sets.
+func (a *Assignment) PreemptionTargets() map[kueue.ResourceFlavorReference]corev1.ResourceList {
+	lacks := make(cache.FlavorResourceQuantities)
+	for _, psa := range a.PodSets {
+		if psa.Status == nil {
+			continue
+		}
+		for rName, flvAssignment := range psa.Flavors {
+			if flvAssignment.Mode != Preempt {
+				continue
+			}
+			for _, r := range psa.Status.rejections {
+				if r.flavor != flvAssignment.Name || r.resource != rName || r.lack <= 0 {
+					continue
+				}
+				if lacks[r.flavor] == nil {
+					lacks[r.flavor] = make(map[corev1.ResourceName]int64)
+				}
+				if r.lack > lacks[r.flavor][rName] {
+					lacks[r.flavor][rName] = r.lack
+				}
+			}
+		}
+	}
+	targets := make(map[kueue.ResourceFlavorReference]corev1.ResourceList, len(lacks))
+	for fName, resLacks := range lacks {
+		targets[fName] = make(corev1.ResourceList, len(resLacks))
+		for rName, v := range resLacks {
+			targets[fName][rName] = workload.ResourceQuantity(rName, v)
+		}
+	}
+	return targets
+}
+
+// UsageDelta returns the quantities that need to be added to and subtracted
+// from the usage of a ClusterQueue when the assignment of a workload changes
+// from old to new. Only the flavors and resources whose usage changes are
+// included. A nil assignment has no usage.
+func UsageDelta(old, new *Assignment) (add, sub cache.FlavorResourceQuantities) {
+	var oldUsage, newUsage cache.FlavorResourceQuantities
+	if old != nil {
+		oldUsage = old.Usage()
+	}
+	if new != nil {
+		newUsage = new.Usage()
+	}
+	record := func(m *cache.FlavorResourceQuantities, fName kueue.ResourceFlavorReference, rName corev1.ResourceName, v int64) {
+		if *m == nil {
+			*m = make(cache.FlavorResourceQuantities)
+		}
+		if (*m)[fName] == nil {
+			(*m)[fName] = make(map[corev1.ResourceName]int64)
+		}
+		(*m)[fName][rName] = v
+	}
+	for fName, resUsage := range newUsage {
+		for rName, v := range resUsage {
+			if d := v - oldUsage[fName][rName]; d > 0 {
+				record(&add, fName, rName, d)
+			} else if d < 0 {
+				record(&sub, fName, rName, -d)
+			}
+		}
+	}
+	for fName, resUsage := range oldUsage {
+		for rName, v := range resUsage {
+			if _, found := newUsage[fName][rName]; !found && v > 0 {
+				record(&sub, fName, rName, v)
+			}
+		}
+	}
+	return add, sub
+}
+
+// FormatQuantities renders the quantities as a table with a row per flavor and
+// resource, sorted by flavor and then by resource, with the values in the
+// units of each resource.
+func FormatQuantities(q cache.FlavorResourceQuantities) string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FLAVOR\tRESOURCE\tQUANTITY")
+	fNames := sets.List(sets.KeySet(q))
+	for _, fName := range fNames {
+		rNames := sets.List(sets.KeySet(q[fName]))
+		for _, rName := range rNames {
+			quantity := workload.ResourceQuantity(rName, q[fName][rName])
+			fmt.Fprintf(w, "%s\t%s\t%s\n", fName, rName, &quantity)
+		}
+	}
+	w.Flush()
+	return sb.String()
+}
+
+// Utilization returns the usage of the resource in the flavor in the
+// ClusterQueue as a fraction of its nominal quota, scaled by the overcommit
+// ratio. It's above 1 when the ClusterQueue is borrowing from its cohort. It's 0
+// if the ClusterQueue has no nominal quota for the resource in the flavor.
+func Utilization(cq *cache.ClusterQueue, fName kueue.ResourceFlavorReference, rName corev1.ResourceName) float64 {
+	for _, rg := range cq.ResourceGroups {
+		for _, flvQuotas := range rg.Flavors {
+			if flvQuotas.Name != fName {
+				continue
+			}
+			rQuota := flvQuotas.Resources[rName]
+			if rQuota == nil || rQuota.OvercommittedNominal() <= 0 {
+				return 0
+			}
+			used := cq.Usage[fName][rName]
+			if used <= 0 {
+				return 0
+			}
+			return float64(used) / float64(rQuota.OvercommittedNominal())
+		}
+	}
+	return 0
+}
+
+// RemainingAfter returns, for each flavor and resource with quota in the
+// ClusterQueue, the nominal quota that would remain unused if the assignment
+// was admitted, clamped at 0. Quota that could be borrowed from the cohort
+// isn't included.
+func RemainingAfter(cq *cache.ClusterQueue, a *Assignment) cache.FlavorResourceQuantities {
+	usage := a.Usage()
+	remaining := make(cache.FlavorResourceQuantities)
+	for _, rg := range cq.ResourceGroups {
+		for _, flvQuotas := range rg.Flavors {
+			resRemaining := make(map[corev1.ResourceName]int64, len(flvQuotas.Resources))
+			for rName, rQuota := range flvQuotas.Resources {
+				v := rQuota.Nominal - cq.Usage[flvQuotas.Name][rName] - usage[flvQuotas.Name][rName]
+				if v < 0 {
+					v = 0
+				}
+				resRemaining[rName] = v
+			}
+			remaining[flvQuotas.Name] = resRemaining
+		}
+	}
+	return remaining
+}
+
+// CohortFlows classifies the quota of the members of the cohort, keyed by
+// ClusterQueue name, into what they borrow, the usage above their nominal
+// quota, and what they lend, the nominal quota they don't use. A member can
+// borrow some resources and lend others. Members that don't belong to the
+// cohort are ignored.
+func CohortFlows(cohort *cache.Cohort, members []*cache.ClusterQueue) (borrowers, lenders map[string]cache.FlavorResourceQuantities) {
+	borrowers = make(map[string]cache.FlavorResourceQuantities)
+	lenders = make(map[string]cache.FlavorResourceQuantities)
+	record := func(m map[string]cache.FlavorResourceQuantities, cqName string, fName kueue.ResourceFlavorReference, rName corev1.ResourceName, v int64) {
+		if m[cqName] == nil {
+			m[cqName] = make(cache.FlavorResourceQuantities)
+		}
+		if m[cqName][fName] == nil {
+			m[cqName][fName] = make(map[corev1.ResourceName]int64)
+		}
+		m[cqName][fName][rName] = v
+	}
+	for _, cq := range members {
+		if cohort == nil || !cohort.Members.Has(cq) {
+			continue
+		}
+		for _, rg := range cq.ResourceGroups {
+			for _, flvQuotas := range rg.Flavors {
+				for rName, rQuota := range flvQuotas.Resources {
+					diff := cq.Usage[flvQuotas.Name][rName] - rQuota.Nominal
+					if diff > 0 {
+						record(borrowers, cq.Name, flvQuotas.Name, rName, diff)
+					} else if diff < 0 {
+						record(lenders, cq.Name, flvQuotas.Name, rName, -diff)
+					}
+				}
+			}
+		}
+	}
+	return borrowers, lenders
+}
+
+// FairShare returns the quantity of the resource, across all flavors, that the
+// ClusterQueue is entitled to, as its share of the cohort capacity
+// proportional to its weight among the weights of the cohort members. Without
+// a cohort, it's the nominal quota of the ClusterQueue.
+func FairShare(cq *cache.ClusterQueue, rName corev1.ResourceName) int64 {
+	if cq.Cohort == nil {
+		var nominal int64
+		if rg := cq.RGByResource[rName]; rg != nil {
+			for _, flvQuotas := range rg.Flavors {
+				if rQuota := flvQuotas.Resources[rName]; rQuota != nil {
+					nominal += rQuota.Nominal
+				}
+			}
+		}
+		return nominal
+	}
+	var capacity int64
+	for _, resources := range cq.Cohort.RequestableResources {
+		capacity += resources[rName]
+	}
+	var totalWeight int64
+	for member := range cq.Cohort.Members {
+		totalWeight += member.FairSharingWeight
+	}
+	if totalWeight == 0 {
+		return 0
+	}
+	return capacity * cq.FairSharingWeight / totalWeight
+}
+
+// totalUsage returns the usage of the resource in the ClusterQueue, across all
+// flavors.
+func totalUsage(cq *cache.ClusterQueue, rName corev1.ResourceName) int64 {
+	var used int64
+	for _, resources := range cq.Usage {
+		used += resources[rName]
+	}
+	return used
+}
+
+// RejectionReason is the category of the reason why a flavor couldn't be
+// assigned to a pod set.
+type RejectionReason string
+
+const (
+	// FlavorNotFound means that the ResourceFlavor doesn't exist.
+	FlavorNotFound RejectionReason = "FlavorNotFound"
+	// FlavorUnschedulable means that the ResourceFlavor is marked as not
+	// accepting new pods.
+	FlavorUnschedulable RejectionReason = "FlavorUnschedulable"
+	// UntoleratedTaint means that the pod set doesn't tolerate a taint of the
+	// flavor.
+	UntoleratedTaint RejectionReason = "UntoleratedTaint"
+	// NodeAffinityMismatch means that the flavor doesn't match the node
+	// selector or affinity of the pod set.
+	NodeAffinityMismatch RejectionReason = "NodeAffinityMismatch"
+	// SchedulerNameMismatch means that the pods of the pod set use a different
+	// scheduler than the one for the flavor.
+	SchedulerNameMismatch RejectionReason = "SchedulerNameMismatch"
+	// RequestBelowMinimum means that the pods of the pod set request less of a
+	// resource than the minimum of the flavor.
+	RequestBelowMinimum RejectionReason = "RequestBelowMinimum"
+	// InsufficientQuota means that there is not enough unused quota in the
+	// ClusterQueue or cohort.
+	InsufficientQuota RejectionReason = "InsufficientQuota"
+	// BorrowingLimitExceeded means that the ClusterQueue would need to borrow
+	// more than its borrowing limit.
+	BorrowingLimitExceeded RejectionReason = "BorrowingLimitExceeded"
+	// FairShareExceeded means that borrowing would take the usage of the
+	// ClusterQueue above its fair share of the cohort.
+	FairShareExceeded RejectionReason = "FairShareExceeded"
+	// MaxConcurrentWorkloadsReached means that the flavor already hosts the
+	// maximum number of concurrent workloads.
+	MaxConcurrentWorkloadsReached RejectionReason = "MaxConcurrentWorkloadsReached"
+	// ResourceUnavailable means that the ClusterQueue doesn't provide quota for
+	// a resource requested by the pod set, in any flavor, or that the flavor
+	// doesn't alias the requested resource like other flavors do.
+	ResourceUnavailable RejectionReason = "ResourceUnavailable"
+	// NodeNamePinning means that the pod set is pinned to a node and the
+	// NodeNamePolicy doesn't allow assigning flavors to it.
+	NodeNamePinning RejectionReason = "NodeNamePinning"
+)
+
+// rejection holds why a flavor couldn't be assigned immediately to a pod set.
+// flavor is empty for rejections that apply to all the flavors.
+type rejection struct {
+	flavor   kueue.ResourceFlavorReference
+	resource corev1.ResourceName
+	reason   RejectionReason
+	message  string
+	// lack is the quantity of the resource missing in the flavor, for
+	// rejections due to quota.
+	lack int64
+	// heldByClusterQueue means that the missing quota is in use by the
+	// workloads of the ClusterQueue itself, so it is freed when they finish.
+	heldByClusterQueue bool
+}
+
 type Status struct {
-	reasons []string
-	err     error
+	reasons    []string
+	rejections []rejection
+	err        error
+	// infos are informational notes that don't prevent the assignment, like
+	// the quota borrowed from the cohort by a pod set that fits.
+	infos []string
 }
 
 func (s *Status) IsError() bool {
 	return s != nil && s.err != nil
 }
 
-func (s *Status) append(r ...string) *Status {
-	s.reasons = append(s.reasons, r...)
+// failed returns whether the status holds an error or reasons why flavors
+// couldn't be assigned, as opposed to only informational notes.
+func (s *Status) failed() bool {
+	return s != nil && (s.err != nil || len(s.reasons) > 0)
+}
+
+// Infos returns the informational notes of the status, which don't prevent
+// the assignment.
+func (s *Status) Infos() []string {
+	if s == nil {
+		return nil
+	}
+	return s.infos
+}
+
+// heldByClusterQueue returns whether the status has rejections for the
+// resource in the flavor and all of them are due to quota in use by the
+// workloads of the ClusterQueue.
+func (s *Status) heldByClusterQueue(fName kueue.ResourceFlavorReference, rName corev1.ResourceName) bool {
+	if s == nil {
+		return false
+	}
+	found := false
+	for _, r := range s.rejections {
+		if r.flavor != fName || r.resource != rName {
+			continue
+		}
+		if !r.heldByClusterQueue {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+func (s *Status) reject(r ...rejection) *Status {
+	for _, rej := range r {
+		s.reasons = append(s.reasons, rej.message)
+	}
+	s.rejections = append(s.rejections, r...)
 	return s
 }
 
@@ -126,9 +911,12 @@ func (s *Status) Message() string {
 	return strings.Join(s.reasons, ", ")
 }
 
+// Equal returns whether both statuses have the same error or reasons.
+// Informational notes are ignored, so a status with only notes is equal to a
+// nil status.
 func (s *Status) Equal(o *Status) bool {
-	if s == nil || o == nil {
-		return s == o
+	if !s.failed() || !o.failed() {
+		return s.failed() == o.failed()
 	}
 	if s.err != nil {
 		return errors.Is(s.err, o.err)
@@ -142,7 +930,8 @@ func (s *Status) Equal(o *Status) bool {
 // the resources that the pod set requests. Each assigned flavor is accompanied
 // with an AssignmentMode.
 // Empty .Flavors can be interpreted as NoFit mode for all the resources.
-// Empty .Status can be interpreted as Fit mode for all the resources.
+// Empty .Status, or a .Status with only informational notes, can be
+// interpreted as Fit mode for all the resources.
 // .Flavors and .Status can't be empty at the same time, once PodSetAssignment
 // is fully calculated.
 type PodSetAssignment struct {
@@ -150,6 +939,39 @@ type PodSetAssignment struct {
 	Flavors  ResourceAssignment
 	Status   *Status
 	Requests corev1.ResourceList
+
+	// count is the number of pods in the pod set. It's recorded regardless of
+	// whether the ClusterQueue tracks quota for the pods resource.
+	count int32
+
+	// rejections holds the reasons why each flavor couldn't be assigned
+	// immediately to the pod set, even if another flavor was assigned.
+	rejections []rejection
+}
+
+func (psa *PodSetAssignment) equal(o *PodSetAssignment) bool {
+	if psa.Name != o.Name || psa.count != o.count || len(psa.Flavors) != len(o.Flavors) {
+		return false
+	}
+	for rName, flvAssignment := range psa.Flavors {
+		oFlvAssignment, found := o.Flavors[rName]
+		if !found || *flvAssignment != *oFlvAssignment {
+			return false
+		}
+	}
+	if !psa.Status.Equal(o.Status) {
+		return false
+	}
+	return cmp.Equal(psa.Requests, o.Requests, cmpopts.EquateEmpty())
+}
+
+// Count returns the number of pods in the pod set.
+func (psa *PodSetAssignment) Count() int32 {
+	return psa.count
+}
+
+func (psa *PodSetAssignment) reject(r ...rejection) {
+	psa.rejections = append(psa.rejections, r...)
 }
 
 // RepresentativeMode calculates the representative mode for this assignment as
@@ -181,6 +1003,7 @@ func (psa *PodSetAssignment) toAPI() kueue.PodSetAssignment {
 		Name:          psa.Name,
 		Flavors:       flavors,
 		ResourceUsage: psa.Requests,
+		Count:         pointer.Int32(psa.count),
 	}
 }
 
@@ -218,54 +1041,254 @@ type FlavorAssignment struct {
 	Name   kueue.ResourceFlavorReference
 	Mode   FlavorAssignmentMode
 	borrow int64
+	// usage is the request rounded up to the granularity of the resource in
+	// the flavor, which is charged as usage.
+	usage int64
+}
+
+// ErrNoPodSets is returned by Assignment.Err for workloads without pod sets,
+// which can never be admitted.
+var ErrNoPodSets = errors.New("workload has no pod sets")
+
+// NodeNamePolicy describes how to assign flavors to pod sets whose pod
+// template sets .spec.nodeName, pinning the pods to a node.
+type NodeNamePolicy int
+
+const (
+	// AssignNodeName means that pod sets pinned to a node are assigned flavors
+	// like any other pod set, matching the flavors' node labels against the
+	// node selector and affinity of the pods.
+	AssignNodeName NodeNamePolicy = iota
+	// RejectNodeName means that pod sets pinned to a node can't be assigned
+	// flavors.
+	RejectNodeName
+	// IgnoreAffinityForNodeName means that pod sets pinned to a node can be
+	// assigned any flavor, regardless of the flavors' node labels.
+	IgnoreAffinityForNodeName
+)
+
+const nodeNamePinningReason = "NodeName pinning incompatible with flavor assignment"
+
+type options struct {
+	nodeNamePolicy NodeNamePolicy
+	warmingFlavors sets.Set[kueue.ResourceFlavorReference]
+	// borrowingAvoidanceThreshold is the minimum priority of the workloads
+	// that prefer flavors that don't require borrowing. If nil, all the
+	// workloads do.
+	borrowingAvoidanceThreshold *int32
+	resourceNameFormatter       ResourceNameFormatter
+}
+
+// Option configures the flavor assignment.
+type Option func(*options)
+
+// WithNodeNamePolicy sets how to assign flavors to pod sets pinned to a node.
+// Defaults to AssignNodeName.
+func WithNodeNamePolicy(p NodeNamePolicy) Option {
+	return func(o *options) {
+		o.nodeNamePolicy = p
+	}
+}
+
+// WithWarmingFlavors sets the flavors whose nodes are still being provisioned,
+// for example, by a pending scale-up of the cluster-autoscaler. They are
+// ranked below the other flavors with the same mode, as pods would need to
+// wait for the nodes.
+func WithWarmingFlavors(flavors sets.Set[kueue.ResourceFlavorReference]) Option {
+	return func(o *options) {
+		o.warmingFlavors = flavors
+	}
+}
+
+// WithBorrowingAvoidanceThreshold sets the minimum priority of the workloads
+// that prefer flavors in which they fit within the nominal quota of the
+// ClusterQueue over flavors that require borrowing from the cohort, even if
+// they are less preferred, to reserve the cohort capacity for bursts. Workloads
+// with a lower priority get the flavors in order and by preference,
+// regardless of borrowing. By default, all the workloads avoid borrowing.
+func WithBorrowingAvoidanceThreshold(threshold int32) Option {
+	return func(o *options) {
+		o.borrowingAvoidanceThreshold = &threshold
+	}
+}
+
+// ResourceNameFormatter returns the name of a resource as displayed in the
+// messages of the flavor assignment.
+type ResourceNameFormatter func(corev1.ResourceName) string
+
+// format returns the display name of the resource, or the resource name if
+// there is no formatter.
+func (f ResourceNameFormatter) format(rName corev1.ResourceName) string {
+	if f == nil {
+		return string(rName)
+	}
+	return f(rName)
+}
+
+// WithResourceNameFormatter sets how resources are named in the reasons why
+// flavors can't be assigned, for example, to display "GPUs" instead of
+// "example.com/gpu". By default, the resource names are used.
+func WithResourceNameFormatter(f ResourceNameFormatter) Option {
+	return func(o *options) {
+		o.resourceNameFormatter = f
+	}
+}
+
+var defaultOptions = options{
+	nodeNamePolicy: AssignNodeName,
+}
+
+// AssignFlavors assigns flavors for each of the resources requested in each pod set.
+// The result for each pod set is accompanied with reasons why the flavor can't
+// be assigned immediately. Each assigned flavor is accompanied with a
+// FlavorAssignmentMode.
+// If the pod sets don't fit with their full count and some of them have a
+// minCount, the assignment is done for the largest counts that fit or can be
+// admitted after preemption, as reported by PodSetAssignment.Count.
+// The pod sets in the same replica group get the same flavors, chosen for the
+// requests of the group as a whole.
+func AssignFlavors(log logr.Logger, wl *workload.Info, resourceFlavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor, cq *cache.ClusterQueue, opts ...Option) Assignment {
+	options := defaultOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	assignment := assignFlavors(log, wl, resourceFlavors, cq, &options)
+	if assignment.RepresentativeMode() != NoFit {
+		return assignment
+	}
+	reducer := newPodSetReducer(wl.Obj.Spec.PodSets)
+	if reducer == nil {
+		return assignment
+	}
+	// The number of pods that fit decreases as the reduction grows, so the
+	// smallest reduction that doesn't result in NoFit is binary searched.
+	lo, hi := int32(1), reducer.maxReduction
+	for lo <= hi {
+		mid := lo + (hi-lo)/2
+		partial := assignFlavors(log, reducer.reduce(wl, mid), resourceFlavors, cq, &options)
+		if partial.RepresentativeMode() == NoFit {
+			lo = mid + 1
+		} else {
+			assignment = partial
+			hi = mid - 1
+		}
+	}
+	return assignment
+}
+
+// AssignFlavorsExcluding assigns flavors like AssignFlavors, as if the
+// excluded flavors weren't in the resource groups of the ClusterQueue. It can
+// be used to compare the assignment with and without a flavor, for example,
+// before rolling out a new flavor. The ClusterQueue is not modified.
+func AssignFlavorsExcluding(log logr.Logger, wl *workload.Info, resourceFlavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor, cq *cache.ClusterQueue, exclude sets.Set[kueue.ResourceFlavorReference], opts ...Option) Assignment {
+	if exclude.Len() == 0 {
+		return AssignFlavors(log, wl, resourceFlavors, cq, opts...)
+	}
+	reduced := *cq
+	reduced.ResourceGroups = make([]cache.ResourceGroup, len(cq.ResourceGroups))
+	for i := range cq.ResourceGroups {
+		rg := cq.ResourceGroups[i]
+		rg.Flavors = nil
+		for _, flvQuotas := range cq.ResourceGroups[i].Flavors {
+			if !exclude.Has(flvQuotas.Name) {
+				rg.Flavors = append(rg.Flavors, flvQuotas)
+			}
+		}
+		reduced.ResourceGroups[i] = rg
+	}
+	reduced.UpdateRGByResource()
+	return AssignFlavors(log, wl, resourceFlavors, &reduced, opts...)
 }
 
-// AssignFlavors assigns flavors for each of the resources requested in each pod set.
-// The result for each pod set is accompanied with reasons why the flavor can't
-// be assigned immediately. Each assigned flavor is accompanied with a
-// FlavorAssignmentMode.
-func AssignFlavors(log logr.Logger, wl *workload.Info, resourceFlavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor, cq *cache.ClusterQueue) Assignment {
+func assignFlavors(log logr.Logger, wl *workload.Info, resourceFlavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor, cq *cache.ClusterQueue, options *options) Assignment {
 	assignment := Assignment{
 		TotalBorrow: make(cache.FlavorResourceQuantities),
 		PodSets:     make([]PodSetAssignment, 0, len(wl.TotalRequests)),
+		Epoch:       cq.Generation(),
 		usage:       make(cache.FlavorResourceQuantities),
 	}
+	if len(wl.TotalRequests) == 0 {
+		assignment.status = &Status{err: ErrNoPodSets}
+		return assignment
+	}
+	wlCtx := newWorkloadContext(wl, options)
+	groups := newReplicaGroups(wl, cq)
 	for i, podSet := range wl.TotalRequests {
+		count := wl.Obj.Spec.PodSets[i].Count
 		if _, found := cq.RGByResource[corev1.ResourcePods]; found {
-			podSet.Requests[corev1.ResourcePods] = int64(wl.Obj.Spec.PodSets[i].Count)
+			podSet.Requests[corev1.ResourcePods] = int64(count)
 		}
+		requests, aliases := resolveAliases(dropZeroRequests(podSet.Requests), cq, resourceFlavors)
 
 		psAssignment := PodSetAssignment{
 			Name:     podSet.Name,
-			Flavors:  make(ResourceAssignment, len(podSet.Requests)),
-			Requests: podSet.Requests.ToResourceList(),
+			Flavors:  make(ResourceAssignment, len(requests)),
+			Requests: requests.ToResourceList(),
+			count:    count,
 		}
 
-		for resName := range podSet.Requests {
-			if _, found := psAssignment.Flavors[resName]; found {
-				// This resource got assigned the same flavor as its resource group.
-				// No need to compute again.
-				continue
+		spec := &wl.Obj.Spec.PodSets[i].Template.Spec
+		if spec.NodeName != "" && options.nodeNamePolicy == RejectNodeName {
+			psAssignment.Flavors = nil
+			psAssignment.Status = (&Status{}).reject(rejection{
+				reason:  NodeNamePinning,
+				message: nodeNamePinningReason,
+			})
+			assignment.append(requests, &psAssignment)
+			assignment.TotalBorrow = nil
+			return assignment
+		}
+
+		group := groups[wl.Obj.Spec.PodSets[i].ReplicaGroup]
+		var groupFlavors map[*cache.ResourceGroup]kueue.ResourceFlavorReference
+		if group != nil && wlCtx.colocationKey != "" {
+			groupFlavors = cloneGroupFlavors(group.flavors)
+		}
+		assignment.assignResourceGroups(log, &psAssignment, requests, aliases, group, resourceFlavors, cq, spec, wlCtx, nil)
+		if wlCtx.colocationKey != "" && !isColocated(&psAssignment, resourceFlavors, wlCtx.colocationKey) {
+			// The flavors preferred for the first resource groups might have no
+			// match in the next ones. Retry with the flavors of each value of the
+			// colocation label, keeping the split assignment if none of them is
+			// as good.
+			var splitGroupFlavors map[*cache.ResourceGroup]kueue.ResourceFlavorReference
+			if group != nil {
+				splitGroupFlavors = group.flavors
 			}
-			rg, found := cq.RGByResource[resName]
-			if !found {
-				psAssignment.Flavors = nil
-				psAssignment.Status = &Status{
-					reasons: []string{fmt.Sprintf("resource %s unavailable in ClusterQueue", resName)},
+			colocated := false
+			for _, value := range colocationValues(requests, cq, resourceFlavors, wlCtx.colocationKey) {
+				attempt := PodSetAssignment{
+					Name:     podSet.Name,
+					Flavors:  make(ResourceAssignment, len(requests)),
+					Requests: requests.ToResourceList(),
+					count:    count,
+				}
+				if group != nil {
+					group.flavors = cloneGroupFlavors(groupFlavors)
+				}
+				restrict := func(rg *cache.ResourceGroup) *cache.ResourceGroup {
+					return restrictToLabelValue(rg, resourceFlavors, wlCtx.colocationKey, value)
+				}
+				assignment.assignResourceGroups(log, &attempt, requests, aliases, group, resourceFlavors, cq, spec, wlCtx, restrict)
+				if len(attempt.Flavors) > 0 && attempt.RepresentativeMode() >= psAssignment.RepresentativeMode() {
+					psAssignment = attempt
+					colocated = true
+					break
 				}
-				break
 			}
-			flavors, status := assignment.findFlavorForResourceGroup(log, rg, podSet.Requests, resourceFlavors, cq, &wl.Obj.Spec.PodSets[i].Template.Spec)
-			if status.IsError() || len(flavors) == 0 {
-				psAssignment.Flavors = nil
-				psAssignment.Status = status
-				break
+			if !colocated && group != nil {
+				group.flavors = splitGroupFlavors
 			}
-			psAssignment.append(flavors, status)
+		}
+		if psAssignment.RepresentativeMode() == Fit {
+			psAssignment.noteBorrowing()
 		}
 
-		assignment.append(podSet.Requests, &psAssignment)
-		if psAssignment.Status.IsError() || (len(podSet.Requests) > 0 && len(psAssignment.Flavors) == 0) {
+		if charged := chargedRequests(requests, psAssignment.Flavors); charged != nil {
+			requests = charged
+			psAssignment.Requests = requests.ToResourceList()
+		}
+		assignment.append(requests, &psAssignment)
+		if psAssignment.Status.IsError() || (len(requests) > 0 && len(psAssignment.Flavors) == 0) {
 			// This assignment failed, no need to continue tracking.
 			assignment.TotalBorrow = nil
 			return assignment
@@ -277,6 +1300,305 @@ func AssignFlavors(log logr.Logger, wl *workload.Info, resourceFlavors map[kueue
 	return assignment
 }
 
+// assignResourceGroups assigns flavors to the requests of the pod set, one
+// resource group at a time. The resources are visited in order, so that the
+// resource groups assigned first determine the value of the colocation label.
+// If restrict is not nil, only the flavors of the resource groups it returns
+// are considered. On failure, the flavors of the pod set are cleared.
+func (a *Assignment) assignResourceGroups(
+	log logr.Logger,
+	psAssignment *PodSetAssignment,
+	requests workload.Requests,
+	aliases map[corev1.ResourceName]corev1.ResourceName,
+	group *replicaGroup,
+	resourceFlavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor,
+	cq *cache.ClusterQueue,
+	spec *corev1.PodSpec,
+	wlCtx *workloadContext,
+	restrict func(*cache.ResourceGroup) *cache.ResourceGroup) {
+	for _, resName := range sets.List(sets.KeySet(requests)) {
+		if _, found := psAssignment.Flavors[resName]; found {
+			// This resource got assigned the same flavor as its resource group.
+			// No need to compute again.
+			continue
+		}
+		rg, found := cq.RGByResource[resName]
+		if !found {
+			psAssignment.Flavors = nil
+			psAssignment.Status = (&Status{}).reject(rejection{
+				resource: resName,
+				reason:   ResourceUnavailable,
+				message:  fmt.Sprintf("resource %s unavailable in ClusterQueue", wlCtx.resourceNameFormatter.format(resName)),
+			})
+			return
+		}
+		candidates := rg
+		if restrict != nil {
+			candidates = restrict(rg)
+		}
+		if group != nil {
+			// The members of a replica group get the flavor chosen for the
+			// requests of the whole group.
+			fName, found := group.flavors[rg]
+			if !found {
+				groupRequests, groupAliases := resolveAliases(group.requests, cq, resourceFlavors)
+				groupFlavors, status := a.findFlavorForResourceGroup(log, &PodSetAssignment{count: group.count}, candidates, groupRequests, groupAliases, resourceFlavors, cq, spec, wlCtx)
+				if status.IsError() || len(groupFlavors) == 0 {
+					psAssignment.Flavors = nil
+					psAssignment.Status = status
+					return
+				}
+				for _, flvAssignment := range groupFlavors {
+					fName = flvAssignment.Name
+					break
+				}
+				group.flavors[rg] = fName
+			}
+			candidates = restrictToFlavor(candidates, fName)
+		}
+		flavors, status := a.findFlavorForResourceGroup(log, psAssignment, candidates, requests, aliases, resourceFlavors, cq, spec, wlCtx)
+		if status.IsError() || len(flavors) == 0 {
+			psAssignment.Flavors = nil
+			psAssignment.Status = status
+			return
+		}
+		psAssignment.append(flavors, status)
+	}
+}
+
+// workloadContext holds the properties of the workload that apply to the
+// flavor assignment of all its pod sets, along with the options.
+type workloadContext struct {
+	*options
+	// running means that the workload is admitted and it's being re-evaluated
+	// while its pods are running.
+	running   bool
+	tolerance interruptionTolerance
+	class     string
+	// avoidBorrowing means that flavors that don't require borrowing are
+	// preferred, see WithBorrowingAvoidanceThreshold.
+	avoidBorrowing bool
+	colocationKey  string
+}
+
+func newWorkloadContext(wl *workload.Info, options *options) *workloadContext {
+	return &workloadContext{
+		options:        options,
+		running:        workload.IsAdmitted(wl.Obj),
+		tolerance:      interruptionToleranceOf(wl.Obj),
+		class:          wl.Obj.Labels[kueue.WorkloadClassLabel],
+		avoidBorrowing: options.borrowingAvoidanceThreshold == nil || utilpriority.Priority(wl.Obj) >= *options.borrowingAvoidanceThreshold,
+		colocationKey:  wl.Obj.Annotations[kueue.ColocationLabelAnnotation],
+	}
+}
+
+// ignoresNodeAffinity returns whether the flavors' labels are not checked
+// against the node selector and affinity of the pods, because they are pinned
+// to a node and the node name policy says so.
+func (c *workloadContext) ignoresNodeAffinity(spec *corev1.PodSpec) bool {
+	return spec.NodeName != "" && c.nodeNamePolicy == IgnoreAffinityForNodeName
+}
+
+// replicaGroup holds the aggregated requests of the pod sets in a replica
+// group and the flavor chosen for them in each resource group.
+type replicaGroup struct {
+	requests workload.Requests
+	count    int32
+	flavors  map[*cache.ResourceGroup]kueue.ResourceFlavorReference
+}
+
+// newReplicaGroups returns the replica groups of the pod sets of the workload,
+// keyed by name.
+func newReplicaGroups(wl *workload.Info, cq *cache.ClusterQueue) map[string]*replicaGroup {
+	var groups map[string]*replicaGroup
+	_, podsTracked := cq.RGByResource[corev1.ResourcePods]
+	for i, podSet := range wl.TotalRequests {
+		ps := &wl.Obj.Spec.PodSets[i]
+		if ps.ReplicaGroup == "" {
+			continue
+		}
+		if groups == nil {
+			groups = make(map[string]*replicaGroup)
+		}
+		group := groups[ps.ReplicaGroup]
+		if group == nil {
+			group = &replicaGroup{
+				requests: make(workload.Requests),
+				flavors:  make(map[*cache.ResourceGroup]kueue.ResourceFlavorReference),
+			}
+			groups[ps.ReplicaGroup] = group
+		}
+		for rName, v := range dropZeroRequests(podSet.Requests) {
+			if rName == corev1.ResourcePods {
+				continue
+			}
+			group.requests[rName] += v
+		}
+		group.count += ps.Count
+		if podsTracked {
+			group.requests[corev1.ResourcePods] = int64(group.count)
+		}
+	}
+	return groups
+}
+
+// assignedLabelValue returns the value of the label in the flavors already
+// assigned to the pod set and whether any of them is assigned. The flavors
+// without the label have an empty value.
+func assignedLabelValue(psAssignment *PodSetAssignment, resourceFlavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor, key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	// The resources are visited in order, so that the value doesn't depend on
+	// the iteration order of the map.
+	for _, resName := range sets.List(sets.KeySet(psAssignment.Flavors)) {
+		if flavor, found := resourceFlavors[psAssignment.Flavors[resName].Name]; found {
+			return flavor.Spec.NodeLabels[key], true
+		}
+	}
+	return "", false
+}
+
+// isColocated returns whether all the flavors assigned to the pod set have the
+// same value for the label.
+func isColocated(psAssignment *PodSetAssignment, resourceFlavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor, key string) bool {
+	values := sets.New[string]()
+	for _, flvAssignment := range psAssignment.Flavors {
+		if flavor, found := resourceFlavors[flvAssignment.Name]; found {
+			values.Insert(flavor.Spec.NodeLabels[key])
+		}
+	}
+	return values.Len() <= 1
+}
+
+// colocationValues returns the values of the label in the flavors of the
+// resource groups that cover the requests, in the order in which the flavors
+// are listed, visiting the resources by name.
+func colocationValues(requests workload.Requests, cq *cache.ClusterQueue, resourceFlavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor, key string) []string {
+	var values []string
+	seen := sets.New[string]()
+	for _, resName := range sets.List(sets.KeySet(requests)) {
+		rg, found := cq.RGByResource[resName]
+		if !found {
+			continue
+		}
+		for _, flvQuotas := range rg.Flavors {
+			flavor, found := resourceFlavors[flvQuotas.Name]
+			if !found {
+				continue
+			}
+			if value := flavor.Spec.NodeLabels[key]; !seen.Has(value) {
+				seen.Insert(value)
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// restrictToLabelValue returns a copy of the resource group with only the
+// flavors that have the given value for the label.
+func restrictToLabelValue(rg *cache.ResourceGroup, resourceFlavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor, key, value string) *cache.ResourceGroup {
+	restricted := *rg
+	restricted.Flavors = nil
+	for _, flvQuotas := range rg.Flavors {
+		if flavor, found := resourceFlavors[flvQuotas.Name]; found && flavor.Spec.NodeLabels[key] == value {
+			restricted.Flavors = append(restricted.Flavors, flvQuotas)
+		}
+	}
+	return &restricted
+}
+
+func cloneGroupFlavors(flavors map[*cache.ResourceGroup]kueue.ResourceFlavorReference) map[*cache.ResourceGroup]kueue.ResourceFlavorReference {
+	clone := make(map[*cache.ResourceGroup]kueue.ResourceFlavorReference, len(flavors))
+	for rg, fName := range flavors {
+		clone[rg] = fName
+	}
+	return clone
+}
+
+// restrictToFlavor returns a copy of the resource group with only the given
+// flavor.
+func restrictToFlavor(rg *cache.ResourceGroup, fName kueue.ResourceFlavorReference) *cache.ResourceGroup {
+	restricted := *rg
+	restricted.Flavors = nil
+	for _, flvQuotas := range rg.Flavors {
+		if flvQuotas.Name == fName {
+			restricted.Flavors = append(restricted.Flavors, flvQuotas)
+		}
+	}
+	return &restricted
+}
+
+// QuickReject returns the first resource requested by the workload, in pod set
+// order and then by name, that the ClusterQueue doesn't provide in any of its
+// resource groups, and true if there is such a resource. It's a cheap check
+// that can be done before AssignFlavors. Like AssignFlavors, it ignores the
+// resources requested with a zero quantity, see dropZeroRequests. It doesn't
+// consider resource aliases, see resolveAliases, so it shouldn't be used when
+// the flavors declare any.
+func QuickReject(wl *workload.Info, cq *cache.ClusterQueue) (corev1.ResourceName, bool) {
+	for _, ps := range wl.TotalRequests {
+		for _, rName := range sets.List(sets.KeySet(dropZeroRequests(ps.Requests))) {
+			if _, found := cq.RGByResource[rName]; !found {
+				return rName, true
+			}
+		}
+	}
+	return "", false
+}
+
+// podSetReducer reduces the counts of the pod sets that have a minCount.
+// A reduction of maxReduction takes all of them to their minCount, and smaller
+// reductions scale down each pod set proportionally.
+type podSetReducer struct {
+	counts       []int32
+	deltas       []int32
+	maxReduction int32
+}
+
+// newPodSetReducer returns a reducer for the pod sets, or nil if none of them
+// can be reduced.
+func newPodSetReducer(podSets []kueue.PodSet) *podSetReducer {
+	r := &podSetReducer{
+		counts: make([]int32, len(podSets)),
+		deltas: make([]int32, len(podSets)),
+	}
+	for i := range podSets {
+		ps := &podSets[i]
+		r.counts[i] = ps.Count
+		if ps.MinCount != nil && *ps.MinCount < ps.Count {
+			r.deltas[i] = ps.Count - *ps.MinCount
+			if r.deltas[i] > r.maxReduction {
+				r.maxReduction = r.deltas[i]
+			}
+		}
+	}
+	if r.maxReduction == 0 {
+		return nil
+	}
+	return r
+}
+
+// reduce returns a copy of the workload info with the pod counts and the
+// requests of the pod sets reduced by the given amount.
+func (r *podSetReducer) reduce(wl *workload.Info, reduction int32) *workload.Info {
+	obj := *wl.Obj
+	obj.Spec.PodSets = make([]kueue.PodSet, len(wl.Obj.Spec.PodSets))
+	copy(obj.Spec.PodSets, wl.Obj.Spec.PodSets)
+	counts := make(map[string]int32, len(obj.Spec.PodSets))
+	for i := range obj.Spec.PodSets {
+		count := r.counts[i] - int32(int64(r.deltas[i])*int64(reduction)/int64(r.maxReduction))
+		obj.Spec.PodSets[i].Count = count
+		counts[obj.Spec.PodSets[i].Name] = count
+	}
+	return &workload.Info{
+		Obj:           &obj,
+		TotalRequests: wl.ScaledRequests(counts),
+		ClusterQueue:  wl.ClusterQueue,
+	}
+}
+
 func (psa *PodSetAssignment) append(flavors ResourceAssignment, status *Status) {
 	for resource, assignment := range flavors {
 		psa.Flavors[resource] = assignment
@@ -285,7 +1607,29 @@ func (psa *PodSetAssignment) append(flavors ResourceAssignment, status *Status)
 		psa.Status = status
 	} else if status != nil {
 		psa.Status.reasons = append(psa.Status.reasons, status.reasons...)
+		psa.Status.rejections = append(psa.Status.rejections, status.rejections...)
+		psa.Status.infos = append(psa.Status.infos, status.infos...)
+	}
+}
+
+// noteBorrowing adds informational notes to the status for the resources that
+// fit by borrowing quota from the cohort.
+func (psa *PodSetAssignment) noteBorrowing() {
+	var notes []string
+	for rName, flvAssignment := range psa.Flavors {
+		if flvAssignment.borrow > 0 {
+			q := workload.ResourceQuantity(rName, flvAssignment.borrow)
+			notes = append(notes, fmt.Sprintf("borrowing %s of %s in flavor %s from the cohort", &q, rName, flvAssignment.Name))
+		}
+	}
+	if len(notes) == 0 {
+		return
+	}
+	sort.Strings(notes)
+	if psa.Status == nil {
+		psa.Status = &Status{}
 	}
+	psa.Status.infos = append(psa.Status.infos, notes...)
 }
 
 func (a *Assignment) append(requests workload.Requests, psAssignment *PodSetAssignment) {
@@ -309,19 +1653,48 @@ func (a *Assignment) append(requests workload.Requests, psAssignment *PodSetAssi
 // findFlavorForResourceGroup finds the flavor which can satisfy the resource
 // request, along with the information about resources that need to be borrowed.
 // If the flavor cannot be immediately assigned, it returns a status with
-// reasons or failure.
+// reasons or failure. The reasons for each rejected flavor are recorded in
+// the pod set assignment. The flavors' labels are not checked against the
+// pod's node selector and affinity if the pods are pinned to a node and the
+// node name policy ignores the affinity. If the workload is already admitted,
+// only NoExecute taints are considered. Among flavors with the same mode and
+// borrowing, the ones preferred according to the interruption tolerance of the
+// workload are chosen. Borrowing is ignored in the ranking unless the workload
+// avoids borrowing. If the workload has a colocation key, the flavors with the same value for that label as
+// the flavors already assigned to the pod set are preferred among the flavors
+// with the same mode.
+// Deprecated flavors are only chosen when no other flavor has the same
+// mode, and a note is added to the status when they are. Then, warming flavors
+// are only chosen when no other flavor has the same mode. The quota is checked
+// for the class of the workload, if any. Requests for resources aliased by some
+// flavors, see resolveAliases, can only be assigned those flavors. Flavors with
+// a minimum request above the request of each pod are skipped, as well as
+// unschedulable flavors, unless the workload is already running.
 func (a *Assignment) findFlavorForResourceGroup(
 	log logr.Logger,
+	psAssignment *PodSetAssignment,
 	rg *cache.ResourceGroup,
 	requests workload.Requests,
+	aliases map[corev1.ResourceName]corev1.ResourceName,
 	resourceFlavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor,
 	cq *cache.ClusterQueue,
-	spec *corev1.PodSpec) (ResourceAssignment, *Status) {
+	spec *corev1.PodSpec,
+	wlCtx *workloadContext) (ResourceAssignment, *Status) {
 	status := &Status{}
+	reject := func(r ...rejection) {
+		status.reject(r...)
+		psAssignment.reject(r...)
+	}
 	requests = filterRequestedResources(requests, rg.CoveredResources)
 
 	var bestAssignment ResourceAssignment
-	bestAssignmentMode := NoFit
+	var bestFlavor kueue.ResourceFlavorReference
+	best := flavorRank{mode: NoFit}
+	mostFree := cq.FlavorSelectionPolicy == kueue.MostFree
+	colocationKey := wlCtx.colocationKey
+	colocationValue, colocated := assignedLabelValue(psAssignment, resourceFlavors, colocationKey)
+	formatName := wlCtx.resourceNameFormatter
+	ignoreNodeAffinity := wlCtx.ignoresNodeAffinity(spec)
 
 	// We will only check against the flavors' labels for the resource.
 	selector := flavorSelector(spec, rg.LabelKeys)
@@ -329,62 +1702,312 @@ func (a *Assignment) findFlavorForResourceGroup(
 		flavor, exist := resourceFlavors[flvQuotas.Name]
 		if !exist {
 			log.Error(nil, "Flavor not found", "Flavor", flvQuotas.Name)
-			status.append(fmt.Sprintf("flavor %s not found", flvQuotas.Name))
+			reject(rejection{
+				flavor:  flvQuotas.Name,
+				reason:  FlavorNotFound,
+				message: fmt.Sprintf("flavor %s not found", flvQuotas.Name),
+			})
 			continue
 		}
-		taint, untolerated := corev1helpers.FindMatchingUntoleratedTaint(flavor.Spec.NodeTaints, spec.Tolerations, func(t *corev1.Taint) bool {
-			return t.Effect == corev1.TaintEffectNoSchedule || t.Effect == corev1.TaintEffectNoExecute
-		})
-		if untolerated {
-			status.append(fmt.Sprintf("untolerated taint %s in flavor %s", taint, flvQuotas.Name))
+		if flavor.Spec.Unschedulable && !wlCtx.running {
+			reject(rejection{
+				flavor:  flvQuotas.Name,
+				reason:  FlavorUnschedulable,
+				message: fmt.Sprintf("flavor %s is unschedulable", flvQuotas.Name),
+			})
+			continue
+		}
+		if schedulerName := podSchedulerName(spec); flavor.Spec.SchedulerName != "" && flavor.Spec.SchedulerName != schedulerName {
+			reject(rejection{
+				flavor:  flvQuotas.Name,
+				reason:  SchedulerNameMismatch,
+				message: fmt.Sprintf("flavor %s is for scheduler %s, pods use %s", flvQuotas.Name, flavor.Spec.SchedulerName, schedulerName),
+			})
+			continue
+		}
+		if taint, untolerated := findUntoleratedTaint(flavor, spec, wlCtx.running); untolerated {
+			reject(rejection{
+				flavor:  flvQuotas.Name,
+				reason:  UntoleratedTaint,
+				message: fmt.Sprintf("untolerated taint %s in flavor %s", taint, flvQuotas.Name),
+			})
 			continue
 		}
-		if match, err := selector.Match(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: flavor.Spec.NodeLabels}}); !match || err != nil {
-			if err != nil {
-				status.err = err
-				return nil, status
+		if !ignoreNodeAffinity && !flavor.Spec.IgnoreNodeAffinity {
+			if match, err := selector.Match(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: flavor.Spec.NodeLabels}}); !match || err != nil {
+				if err != nil {
+					status.err = err
+					return nil, status
+				}
+				reject(rejection{
+					flavor:  flvQuotas.Name,
+					reason:  NodeAffinityMismatch,
+					message: fmt.Sprintf("flavor %s doesn't match node affinity", flvQuotas.Name),
+				})
+				continue
 			}
-			status.append(fmt.Sprintf("flavor %s doesn't match node affinity", flvQuotas.Name))
+		}
+
+		if rName, missing := missingAlias(flavor, aliases, requests); missing {
+			reject(rejection{
+				flavor:   flvQuotas.Name,
+				resource: rName,
+				reason:   ResourceUnavailable,
+				message:  fmt.Sprintf("flavor %s doesn't alias %s to %s", flvQuotas.Name, formatName.format(rName), formatName.format(aliases[rName])),
+			})
+			continue
+		}
+
+		if rName, below := belowMinRequest(flavor, requests, psAssignment.count); below {
+			reject(rejection{
+				flavor:   flvQuotas.Name,
+				resource: rName,
+				reason:   RequestBelowMinimum,
+				message:  fmt.Sprintf("request below flavor minimum for %s in flavor %s", formatName.format(rName), flvQuotas.Name),
+			})
+			continue
+		}
+
+		if maxWorkloads := flvQuotas.MaxConcurrentWorkloads; maxWorkloads != nil && cq.WorkloadsInFlavor(flvQuotas.Name) >= int(*maxWorkloads) {
+			// Only waiting for workloads to finish can make the flavor available.
+			reject(rejection{
+				flavor:  flvQuotas.Name,
+				reason:  MaxConcurrentWorkloadsReached,
+				message: fmt.Sprintf("flavor %s reached the maximum of %d concurrent workloads", flvQuotas.Name, *maxWorkloads),
+			})
 			continue
 		}
 
 		assignments := make(ResourceAssignment, len(requests))
-		// Calculate representativeMode for this assignment as the worst mode among all requests.
-		representativeMode := Fit
-		for rName, val := range requests {
+		// Calculate the rank for this assignment, with the worst mode among all
+		// requests.
+		rank := flavorRank{
+			mode:             Fit,
+			deprecated:       flavor.Spec.Deprecated,
+			warming:          wlCtx.warmingFlavors.Has(flvQuotas.Name),
+			preferred:        wlCtx.tolerance.prefers(flavor),
+			splitsColocation: colocated && flavor.Spec.NodeLabels[colocationKey] != colocationValue,
+		}
+		if mostFree {
+			rank.free = math.Inf(1)
+		}
+		// outranked means that the flavor can't be preferred over the best
+		// assignment so far.
+		outranked := false
+		// The resources are visited in order, so that the rejections recorded
+		// before the evaluation stops don't depend on the map iteration order.
+		for _, rName := range sets.List(sets.KeySet(requests)) {
 			resQuota := flvQuotas.Resources[rName]
+			val := requests[rName]
+			if resQuota != nil {
+				val = resQuota.RoundUp(val)
+			}
+			if mostFree && resQuota != nil && resQuota.Nominal > 0 {
+				used := cq.Usage[flvQuotas.Name][rName] + a.usage[flvQuotas.Name][rName] + val
+				rank.free = math.Min(rank.free, float64(resQuota.Nominal-used)/float64(resQuota.Nominal))
+			}
 			// Check considering the flavor usage by previous pod sets.
-			mode, borrow, s := fitsResourceQuota(flvQuotas.Name, rName, val+a.usage[flvQuotas.Name][rName], cq, resQuota)
+			mode, borrow, s := fitsResourceQuota(flvQuotas.Name, rName, val+a.usage[flvQuotas.Name][rName], cq, resQuota, wlCtx.class, formatName)
 			if s != nil {
-				status.reasons = append(status.reasons, s.reasons...)
+				reject(s.rejections...)
 			}
-			if mode < representativeMode {
-				representativeMode = mode
+			if mode < rank.mode {
+				rank.mode = mode
 			}
-			if representativeMode == NoFit {
+			if rank.mode == NoFit {
 				// The flavor doesn't fit, no need to check other resources.
 				break
 			}
+			if outranked {
+				continue
+			}
 
 			assignments[rName] = &FlavorAssignment{
 				Name:   flvQuotas.Name,
 				Mode:   mode,
 				borrow: borrow,
+				usage:  val,
+			}
+			if borrow > 0 && wlCtx.avoidBorrowing {
+				rank.borrow += borrow
+			}
+			if !rank.betterThan(best) {
+				// The rank can only get worse with the remaining resources, so
+				// there is no need to build their assignments. They are still
+				// checked to record why they don't fit, so that the reasons don't
+				// depend on the order in which the flavors are evaluated.
+				outranked = true
 			}
 		}
 
-		if representativeMode > bestAssignmentMode {
+		if !outranked && rank.mode != NoFit && rank.betterThan(best) {
 			bestAssignment = assignments
-			bestAssignmentMode = representativeMode
-			if bestAssignmentMode == Fit {
-				// All the resources fit in the cohort, no need to check more flavors.
+			bestFlavor = flvQuotas.Name
+			best = rank
+			if !mostFree && best == (flavorRank{mode: Fit, preferred: true}) {
+				// All the resources fit without borrowing in a preferred flavor,
+				// no need to check more flavors.
 				return bestAssignment, nil
 			}
 		}
 	}
+	if best.mode == Fit {
+		status = nil
+	}
+	if bestAssignment != nil && best.deprecated {
+		if status == nil {
+			status = &Status{}
+		}
+		status.infos = append(status.infos, fmt.Sprintf("flavor %s is deprecated", bestFlavor))
+	}
 	return bestAssignment, status
 }
 
+// flavorRank describes how good a flavor is for a resource group.
+type flavorRank struct {
+	mode FlavorAssignmentMode
+	// splitsColocation means that the flavor doesn't have the value of the
+	// colocation label of the flavors assigned to the other resource groups.
+	splitsColocation bool
+	deprecated       bool
+	warming          bool
+	// borrow is the total quota borrowed from the cohort for the requested
+	// resources. It's only set if the workload avoids borrowing.
+	borrow    int64
+	preferred bool
+	// free is the smallest fraction of the nominal quota of the requested
+	// resources that remains unused after the assignment. It's only set for
+	// the MostFree flavor selection policy.
+	free float64
+}
+
+// betterThan returns whether the flavor with this rank should be chosen over
+// the one with the other rank: it has a better mode or, among flavors with the
+// same mode, it keeps the pod set colocated or, among those, it isn't
+// deprecated or, among those, it isn't warming up or,
+// among those, it borrows less, to preserve the cohort capacity for others,
+// or, among those, it's preferred by the workload or, among
+// those, it has more free quota.
+func (r flavorRank) betterThan(o flavorRank) bool {
+	if r.mode != o.mode {
+		return r.mode > o.mode
+	}
+	if r.splitsColocation != o.splitsColocation {
+		return !r.splitsColocation
+	}
+	if r.deprecated != o.deprecated {
+		return !r.deprecated
+	}
+	if r.warming != o.warming {
+		return !r.warming
+	}
+	if r.borrow != o.borrow {
+		return r.borrow < o.borrow
+	}
+	if r.preferred != o.preferred {
+		return r.preferred
+	}
+	return r.free > o.free
+}
+
+// interruptionTolerance is the preference of a workload for spot flavors,
+// which have taints, or on-demand flavors, which don't.
+type interruptionTolerance int
+
+const (
+	// noInterruptionPreference means that flavors are chosen in order.
+	noInterruptionPreference interruptionTolerance = iota
+	// interruptionTolerant means that spot flavors are preferred.
+	interruptionTolerant
+	// interruptionIntolerant means that on-demand flavors are preferred.
+	interruptionIntolerant
+)
+
+func interruptionToleranceOf(wl *kueue.Workload) interruptionTolerance {
+	switch wl.Labels[kueue.InterruptionTolerantLabel] {
+	case "true":
+		return interruptionTolerant
+	case "false":
+		return interruptionIntolerant
+	}
+	return noInterruptionPreference
+}
+
+func (t interruptionTolerance) prefers(flavor *kueue.ResourceFlavor) bool {
+	spot := len(flavor.Spec.NodeTaints) > 0
+	switch t {
+	case interruptionTolerant:
+		return spot
+	case interruptionIntolerant:
+		return !spot
+	}
+	return true
+}
+
+// podSchedulerName returns the name of the scheduler for the pods with the
+// spec.
+func podSchedulerName(spec *corev1.PodSpec) string {
+	if spec.SchedulerName == "" {
+		return corev1.DefaultSchedulerName
+	}
+	return spec.SchedulerName
+}
+
+// DetectSelectorConflict returns an error if the node selector of the pod
+// spec sets any of the node labels of the flavor to a different value, in
+// which case injecting the labels of the flavor into the pods would make them
+// unschedulable.
+func DetectSelectorConflict(spec *corev1.PodSpec, flavor *kueue.ResourceFlavor) error {
+	var conflicts []string
+	for k, v := range flavor.Spec.NodeLabels {
+		if podV, found := spec.NodeSelector[k]; found && podV != v {
+			conflicts = append(conflicts, fmt.Sprintf("%s=%s conflicts with %s=%s", k, podV, k, v))
+		}
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+	sort.Strings(conflicts)
+	return fmt.Errorf("node selector conflicts with the labels of flavor %s: %s", flavor.Name, strings.Join(conflicts, ", "))
+}
+
+// MatchingFlavors returns the flavors of the resource group, in order, whose
+// node labels satisfy the node affinity of the pod spec and whose taints are
+// tolerated by it, regardless of the available quota.
+func MatchingFlavors(spec *corev1.PodSpec, rg *cache.ResourceGroup, resourceFlavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor) []kueue.ResourceFlavorReference {
+	var matching []kueue.ResourceFlavorReference
+	selector := flavorSelector(spec, rg.LabelKeys)
+	for _, flvQuotas := range rg.Flavors {
+		flavor, exist := resourceFlavors[flvQuotas.Name]
+		if !exist {
+			continue
+		}
+		if _, untolerated := findUntoleratedTaint(flavor, spec, false); untolerated {
+			continue
+		}
+		if !flavor.Spec.IgnoreNodeAffinity {
+			if match, err := selector.Match(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: flavor.Spec.NodeLabels}}); !match || err != nil {
+				continue
+			}
+		}
+		matching = append(matching, flvQuotas.Name)
+	}
+	return matching
+}
+
+// findUntoleratedTaint returns the first taint of the flavor that the pods
+// with the spec don't tolerate, if any.
+func findUntoleratedTaint(flavor *kueue.ResourceFlavor, spec *corev1.PodSpec, running bool) (corev1.Taint, bool) {
+	return corev1helpers.FindMatchingUntoleratedTaint(flavor.Spec.NodeTaints, tolerations.NormalizeTolerations(spec.Tolerations), func(t *corev1.Taint) bool {
+		if running {
+			// The pods are already scheduled, only NoExecute taints would
+			// evict them.
+			return t.Effect == corev1.TaintEffectNoExecute
+		}
+		return t.Effect == corev1.TaintEffectNoSchedule || t.Effect == corev1.TaintEffectNoExecute
+	})
+}
+
 func flavorSelector(spec *corev1.PodSpec, allowedKeys sets.Set[string]) nodeaffinity.RequiredNodeAffinity {
 	// This function generally replicates the implementation of kube-scheduler's NodeAffintiy
 	// Filter plugin as of v1.24.
@@ -436,50 +2059,255 @@ func flavorSelector(spec *corev1.PodSpec, allowedKeys sets.Set[string]) nodeaffi
 // If it fits, also returns any borrowing required.
 // If the flavor doesn't satisfy limits immediately (when waiting or preemption
 // could help), it returns a Status with reasons.
-func fitsResourceQuota(fName kueue.ResourceFlavorReference, rName corev1.ResourceName, val int64, cq *cache.ClusterQueue, rQuota *cache.ResourceQuota) (FlavorAssignmentMode, int64, *Status) {
+// The shares of the quota reserved for classes other than the class of the
+// workload, and not used by them, are considered used. Only the nominal quota
+// is considered in flavors in which the ClusterQueue is not allowed to borrow
+// the resource. The capacity of the cohort is limited by its cap for the
+// resource in the flavor, if any.
+// The resource is named in the messages by formatName.
+func fitsResourceQuota(fName kueue.ResourceFlavorReference, rName corev1.ResourceName, val int64, cq *cache.ClusterQueue, rQuota *cache.ResourceQuota, class string, formatName ResourceNameFormatter) (FlavorAssignmentMode, int64, *Status) {
 	var status Status
-	used := cq.Usage[fName][rName]
+	displayName := formatName.format(rName)
+	// The unused shares of the other classes are reserved for them.
+	used := cq.Usage[fName][rName] + cq.ReservedClassQuota(class, fName, rName)
+	// The overcommitted nominal quota determines whether the request fits, in
+	// the ClusterQueue and in the cohort.
+	nominal := rQuota.OvercommittedNominal()
 	mode := NoFit
-	if val <= rQuota.Nominal {
+	if val <= nominal {
 		// The request can be satisfied by the min quota, assuming quota is
 		// reclaimed from the cohort or assuming all active workloads in the
 		// ClusterQueue are preempted.
 		mode = Preempt
 	}
-	if rQuota.BorrowingLimit != nil && used+val > rQuota.Nominal+*rQuota.BorrowingLimit {
-		status.append(fmt.Sprintf("borrowing limit for %s in flavor %s exceeded", rName, fName))
-		return mode, 0, &status
-	}
-
 	cohortUsed := used
-	cohortAvailable := rQuota.Nominal
+	cohortAvailable := nominal
+	capped := false
 	if cq.Cohort != nil {
 		cohortUsed = cq.Cohort.Usage[fName][rName]
-		cohortAvailable = cq.Cohort.RequestableResources[fName][rName]
+		cohortAvailable = cq.RequestableCohortQuota(fName, rName) + nominal - rQuota.Nominal
+		if cohortCap, found := cq.Cohort.Caps[fName][rName]; found && cohortCap < cohortAvailable {
+			cohortAvailable = cohortCap
+			capped = true
+		}
+	}
+
+	limit := nominal + EffectiveBorrowingLimit(rQuota, cohortAvailable, nominal)
+	if rQuota.BorrowingLimit != nil && used+val > limit {
+		status.reject(rejection{
+			flavor:   fName,
+			resource: rName,
+			reason:   BorrowingLimitExceeded,
+			message:  fmt.Sprintf("borrowing limit for %s in flavor %s exceeded", displayName, fName),
+			lack:     used + val - limit,
+		})
+		return mode, 0, &status
+	}
+	if cq.Cohort != nil && (cq.Cohort.Parent == nil || capped) && used+val > limit {
+		// Without a borrowing limit, the ClusterQueue can't borrow more than
+		// the cohort can lend, even if nothing else was running in the
+		// cohort. Only preempting workloads in the ClusterQueue can help.
+		msg := fmt.Sprintf("insufficient quota for %s in flavor %s in cohort", displayName, fName)
+		if mode == Preempt {
+			lackQuantity := workload.ResourceQuantity(rName, used+val-nominal)
+			msg = fmt.Sprintf("insufficient unused quota for %s in flavor %s, need to preempt %s in ClusterQueue", displayName, fName, &lackQuantity)
+		}
+		status.reject(rejection{
+			flavor:             fName,
+			resource:           rName,
+			reason:             InsufficientQuota,
+			message:            msg,
+			lack:               used + val - nominal,
+			heldByClusterQueue: mode == Preempt,
+		})
+		return mode, 0, &status
+	}
+
+	if cq.Cohort != nil && used+val > nominal && !cq.CanBorrowInFlavor(fName, rName) {
+		// The cohort capacity can't be used in this flavor, so only waiting for
+		// or preempting workloads in the ClusterQueue can help.
+		status.reject(rejection{
+			flavor:             fName,
+			resource:           rName,
+			reason:             InsufficientQuota,
+			message:            fmt.Sprintf("borrowing %s in flavor %s is not allowed for the ClusterQueue", displayName, fName),
+			lack:               used + val - nominal,
+			heldByClusterQueue: mode == Preempt,
+		})
+		return mode, 0, &status
 	}
 
 	lack := cohortUsed + val - cohortAvailable
-	if lack <= 0 {
-		borrow := used + val - rQuota.Nominal
+	// Borrowing from the parent cohort can't exceed the cap of the cohort.
+	if lack <= 0 || (cq.Cohort != nil && !capped && cq.Cohort.ParentCanLend(fName, rName, val)) {
+		borrow := used + val - nominal
 		if borrow < 0 {
 			borrow = 0
 		}
+		if borrow > 0 && cq.Cohort != nil && cq.Cohort.FairSharing {
+			// Borrowing is only free up to the fair share of the ClusterQueue,
+			// beyond it, the ClusterQueue needs to yield to the others.
+			if exceeded := totalUsage(cq, rName) + val - FairShare(cq, rName); exceeded > 0 {
+				status.reject(rejection{
+					flavor:   fName,
+					resource: rName,
+					reason:   FairShareExceeded,
+					message:  fmt.Sprintf("borrowing %s in flavor %s exceeds the fair share of the ClusterQueue", displayName, fName),
+					lack:     exceeded,
+				})
+				return Preempt, 0, &status
+			}
+		}
 		return Fit, borrow, nil
 	}
 
+	inClusterQueue := mode == Preempt && (cq.Cohort == nil || used+val > nominal)
+	if inClusterQueue {
+		// Only the usage of the ClusterQueue above its nominal quota can be
+		// preempted in the ClusterQueue, regardless of what the cohort lent.
+		lack = used + val - nominal
+	}
 	lackQuantity := workload.ResourceQuantity(rName, lack)
-	msg := fmt.Sprintf("insufficient unused quota in cohort for %s in flavor %s, %s more needed", rName, fName, &lackQuantity)
-	if cq.Cohort == nil {
-		if mode == NoFit {
-			msg = fmt.Sprintf("insufficient quota for %s in flavor %s in ClusterQueue", rName, fName)
-		} else {
-			msg = fmt.Sprintf("insufficient unused quota for %s in flavor %s, %s more needed", rName, fName, &lackQuantity)
-		}
+	var msg string
+	switch {
+	case mode == Preempt && !inClusterQueue:
+		// The ClusterQueue is within its nominal quota, the quota needs to be
+		// reclaimed from other ClusterQueues in the cohort.
+		msg = fmt.Sprintf("insufficient unused quota in cohort for %s in flavor %s, need to reclaim %s from cohort", displayName, fName, &lackQuantity)
+	case inClusterQueue:
+		msg = fmt.Sprintf("insufficient unused quota for %s in flavor %s, need to preempt %s in ClusterQueue", displayName, fName, &lackQuantity)
+	case cq.Cohort != nil:
+		msg = fmt.Sprintf("insufficient unused quota in cohort for %s in flavor %s, %s more needed", displayName, fName, &lackQuantity)
+	default:
+		msg = fmt.Sprintf("insufficient quota for %s in flavor %s in ClusterQueue", displayName, fName)
 	}
-	status.append(msg)
+	status.reject(rejection{
+		flavor:             fName,
+		resource:           rName,
+		reason:             InsufficientQuota,
+		message:            msg,
+		lack:               lack,
+		heldByClusterQueue: inClusterQueue,
+	})
 	return mode, 0, &status
 }
 
+// resolveAliases returns the requests with the resources that the ClusterQueue
+// doesn't cover renamed to the resources they are aliased to by any of the
+// flavors in the ClusterQueue, along with the applied aliases. The requests
+// are returned unchanged when no alias applies.
+func resolveAliases(requests workload.Requests, cq *cache.ClusterQueue, resourceFlavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor) (workload.Requests, map[corev1.ResourceName]corev1.ResourceName) {
+	var aliases map[corev1.ResourceName]corev1.ResourceName
+	for rName := range requests {
+		if _, found := cq.RGByResource[rName]; found {
+			continue
+		}
+		if target, found := findAlias(cq, resourceFlavors, rName); found {
+			if aliases == nil {
+				aliases = make(map[corev1.ResourceName]corev1.ResourceName)
+			}
+			aliases[rName] = target
+		}
+	}
+	if len(aliases) == 0 {
+		return requests, nil
+	}
+	resolved := make(workload.Requests, len(requests))
+	for rName, v := range requests {
+		if target, found := aliases[rName]; found {
+			rName = target
+		}
+		resolved[rName] += v
+	}
+	return resolved, aliases
+}
+
+// findAlias returns the resource covered by the ClusterQueue that the first
+// flavor declaring an alias for the resource maps it to.
+func findAlias(cq *cache.ClusterQueue, resourceFlavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor, rName corev1.ResourceName) (corev1.ResourceName, bool) {
+	for _, rg := range cq.ResourceGroups {
+		for _, flvQuotas := range rg.Flavors {
+			flavor, found := resourceFlavors[flvQuotas.Name]
+			if !found {
+				continue
+			}
+			if target, found := flavor.Spec.ResourceAliases[rName]; found && rg.CoveredResources.Has(target) {
+				return target, true
+			}
+		}
+	}
+	return "", false
+}
+
+// missingAlias returns an aliased resource, among the requested ones, that
+// the flavor doesn't alias to the same resource.
+func missingAlias(flavor *kueue.ResourceFlavor, aliases map[corev1.ResourceName]corev1.ResourceName, requests workload.Requests) (corev1.ResourceName, bool) {
+	for rName, target := range aliases {
+		if _, requested := requests[target]; requested && flavor.Spec.ResourceAliases[rName] != target {
+			return rName, true
+		}
+	}
+	return "", false
+}
+
+// belowMinRequest returns the first resource, by name, for which the request
+// of each pod is below the minimum of the flavor, given the total requests of
+// count pods.
+func belowMinRequest(flavor *kueue.ResourceFlavor, requests workload.Requests, count int32) (corev1.ResourceName, bool) {
+	if len(flavor.Spec.MinRequests) == 0 || count <= 0 {
+		return "", false
+	}
+	for _, rName := range sets.List(sets.KeySet(requests)) {
+		minQ, found := flavor.Spec.MinRequests[rName]
+		if found && requests[rName]/int64(count) < workload.ResourceValue(rName, minQ) {
+			return rName, true
+		}
+	}
+	return "", false
+}
+
+// dropZeroRequests returns the requests without the resources requested with
+// a quantity of zero, which don't need a flavor, nor a resource group that
+// covers them. The requests are returned unchanged when there are none.
+func dropZeroRequests(requests workload.Requests) workload.Requests {
+	zeros := 0
+	for _, v := range requests {
+		if v == 0 {
+			zeros++
+		}
+	}
+	if zeros == 0 {
+		return requests
+	}
+	res := make(workload.Requests, len(requests)-zeros)
+	for rName, v := range requests {
+		if v != 0 {
+			res[rName] = v
+		}
+	}
+	return res
+}
+
+// chargedRequests returns the requests with the values rounded up to the
+// granularity of the resources in the assigned flavors, as they are charged
+// as usage, or nil if none of them needs rounding.
+func chargedRequests(requests workload.Requests, flavors ResourceAssignment) workload.Requests {
+	var charged workload.Requests
+	for rName, flvAssignment := range flavors {
+		if flvAssignment.usage <= requests[rName] {
+			continue
+		}
+		if charged == nil {
+			charged = make(workload.Requests, len(requests))
+			for r, v := range requests {
+				charged[r] = v
+			}
+		}
+		charged[rName] = flvAssignment.usage
+	}
+	return charged
+}
+
 func filterRequestedResources(req workload.Requests, allowList sets.Set[corev1.ResourceName]) workload.Requests {
 	filtered := make(workload.Requests)
 	for n, v := range req {
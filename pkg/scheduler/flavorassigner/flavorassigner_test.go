@@ -17,9 +17,14 @@ limitations under the License.
 package flavorassigner
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
 	"testing"
 
+	"github.com/go-logr/logr"
 	"github.com/go-logr/logr/testr"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -30,6 +35,7 @@ import (
 
 	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
 	"sigs.k8s.io/kueue/pkg/cache"
+	cachetesting "sigs.k8s.io/kueue/pkg/cache/testing"
 	"sigs.k8s.io/kueue/pkg/util/pointer"
 	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
 	"sigs.k8s.io/kueue/pkg/workload"
@@ -44,6 +50,9 @@ func TestAssignFlavors(t *testing.T) {
 		"two":   utiltesting.MakeResourceFlavor("two").Label("type", "two").Obj(),
 		"b_one": utiltesting.MakeResourceFlavor("b_one").Label("b_type", "one").Obj(),
 		"b_two": utiltesting.MakeResourceFlavor("b_two").Label("b_type", "two").Obj(),
+		"logical": utiltesting.MakeResourceFlavor("logical").
+			Label("type", "logical").
+			IgnoreNodeAffinity().Obj(),
 		"tainted": utiltesting.MakeResourceFlavor("tainted").
 			Taint(corev1.Taint{
 				Key:    "instance",
@@ -159,7 +168,7 @@ func TestAssignFlavors(t *testing.T) {
 						corev1.ResourceCPU: resource.MustParse("2000m"),
 					},
 					Status: &Status{
-						reasons: []string{"insufficient unused quota for cpu in flavor default, 1 more needed"},
+						reasons: []string{"insufficient unused quota for cpu in flavor default, need to preempt 1 in ClusterQueue"},
 					},
 				}},
 			},
@@ -418,9 +427,9 @@ func TestAssignFlavors(t *testing.T) {
 					},
 					Status: &Status{
 						reasons: []string{
-							"insufficient unused quota in cohort for cpu in flavor one, 1 more needed",
-							"insufficient unused quota in cohort for memory in flavor two, 5Mi more needed",
-							"insufficient unused quota in cohort for example.com/gpu in flavor b_one, 1 more needed",
+							"insufficient quota for cpu in flavor one in cohort",
+							"insufficient unused quota for memory in flavor two, need to preempt 5Mi in ClusterQueue",
+							"insufficient unused quota in cohort for example.com/gpu in flavor b_one, need to reclaim 1 from cohort",
 						},
 					},
 				}},
@@ -693,6 +702,47 @@ func TestAssignFlavors(t *testing.T) {
 				}},
 			},
 		},
+		"multiple flavors, flavor ignoring node affinity": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "1").
+					NodeSelector(map[string]string{"type": "two"}).
+					Obj(),
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{
+					{
+						CoveredResources: sets.New(corev1.ResourceCPU),
+						Flavors: []cache.FlavorQuotas{
+							{
+								Name: "one",
+								Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+									corev1.ResourceCPU: {Nominal: 4000},
+								},
+							},
+							{
+								Name: "logical",
+								Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+									corev1.ResourceCPU: {Nominal: 4000},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantRepMode: Fit,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "logical", Mode: Fit},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("1000m"),
+					},
+				}},
+			},
+		},
 		"multiple flavors, node affinity fits any flavor": {
 			wlPods: []kueue.PodSet{
 				{
@@ -953,6 +1003,171 @@ func TestAssignFlavors(t *testing.T) {
 				},
 			},
 		},
+		"prefer flavor that fits without borrowing": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "2").
+					Obj(),
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{
+						{
+							Name: "one",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 1000},
+							},
+						},
+						{
+							Name: "two",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 2000},
+							},
+						},
+					},
+				}},
+				Cohort: &cache.Cohort{
+					RequestableResources: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 10_000},
+						"two": {corev1.ResourceCPU: 10_000},
+					},
+				},
+			},
+			wantRepMode: Fit,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "two", Mode: Fit},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2000m"),
+					},
+				}},
+			},
+		},
+		"prefer flavor that borrows less": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "2").
+					Obj(),
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{
+						{
+							Name: "one",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 1000},
+							},
+						},
+						{
+							Name: "two",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 1500},
+							},
+						},
+					},
+				}},
+				Cohort: &cache.Cohort{
+					RequestableResources: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 10_000},
+						"two": {corev1.ResourceCPU: 10_000},
+					},
+				},
+			},
+			wantRepMode: Fit,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "two", Mode: Fit},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2000m"),
+					},
+				}},
+				TotalBorrow: cache.FlavorResourceQuantities{
+					"two": {corev1.ResourceCPU: 500},
+				},
+			},
+		},
+		"request rounded up to granularity, borrows": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request("example.com/gpu", "3").
+					Obj(),
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New[corev1.ResourceName]("example.com/gpu"),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							"example.com/gpu": {Nominal: 4, Granularity: pointer.Int64(8)},
+						},
+					}},
+				}},
+				Cohort: &cache.Cohort{
+					RequestableResources: cache.FlavorResourceQuantities{
+						"one": {"example.com/gpu": 16},
+					},
+				},
+			},
+			wantRepMode: Fit,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						"example.com/gpu": {Name: "one", Mode: Fit},
+					},
+					Requests: corev1.ResourceList{
+						"example.com/gpu": resource.MustParse("8"),
+					},
+				}},
+				TotalBorrow: cache.FlavorResourceQuantities{
+					"one": {"example.com/gpu": 4},
+				},
+			},
+		},
+		"request rounded up to granularity, doesn't fit": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request("example.com/gpu", "3").
+					Obj(),
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New[corev1.ResourceName]("example.com/gpu"),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							"example.com/gpu": {Nominal: 16, Granularity: pointer.Int64(8)},
+						},
+					}},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"one": {"example.com/gpu": 10},
+				},
+			},
+			wantRepMode: Preempt,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						"example.com/gpu": {Name: "one", Mode: Preempt},
+					},
+					Requests: corev1.ResourceList{
+						"example.com/gpu": resource.MustParse("8"),
+					},
+					Status: &Status{
+						reasons: []string{"insufficient unused quota for example.com/gpu in flavor one, need to preempt 2 in ClusterQueue"},
+					},
+				}},
+			},
+		},
 		"not enough space to borrow": {
 			wlPods: []kueue.PodSet{
 				*utiltesting.MakePodSet("main", 1).
@@ -1035,7 +1250,7 @@ func TestAssignFlavors(t *testing.T) {
 				}},
 			},
 		},
-		"past min, but can preempt in ClusterQueue": {
+		"past the cohort capacity without borrowing limit, but can preempt in ClusterQueue": {
 			wlPods: []kueue.PodSet{
 				*utiltesting.MakePodSet("main", 1).
 					Request(corev1.ResourceCPU, "2").
@@ -1052,7 +1267,15 @@ func TestAssignFlavors(t *testing.T) {
 					}},
 				}},
 				Usage: cache.FlavorResourceQuantities{
-					"one": {corev1.ResourceCPU: 1_000},
+					"one": {corev1.ResourceCPU: 9_000},
+				},
+				Cohort: &cache.Cohort{
+					RequestableResources: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 10_000},
+					},
+					Usage: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 9_000},
+					},
 				},
 			},
 			wantRepMode: Preempt,
@@ -1066,12 +1289,12 @@ func TestAssignFlavors(t *testing.T) {
 						corev1.ResourceCPU: resource.MustParse("2000m"),
 					},
 					Status: &Status{
-						reasons: []string{"insufficient unused quota for cpu in flavor one, 1 more needed"},
+						reasons: []string{"insufficient unused quota for cpu in flavor one, need to preempt 9 in ClusterQueue"},
 					},
 				}},
 			},
 		},
-		"past min, but can preempt in cohort and ClusterQueue": {
+		"past min, but can preempt in ClusterQueue": {
 			wlPods: []kueue.PodSet{
 				*utiltesting.MakePodSet("main", 1).
 					Request(corev1.ResourceCPU, "2").
@@ -1083,20 +1306,12 @@ func TestAssignFlavors(t *testing.T) {
 					Flavors: []cache.FlavorQuotas{{
 						Name: "one",
 						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
-							corev1.ResourceCPU: {Nominal: 3000},
+							corev1.ResourceCPU: {Nominal: 2000},
 						},
 					}},
 				}},
 				Usage: cache.FlavorResourceQuantities{
-					"one": {corev1.ResourceCPU: 2_000},
-				},
-				Cohort: &cache.Cohort{
-					RequestableResources: cache.FlavorResourceQuantities{
-						"one": {corev1.ResourceCPU: 10_000},
-					},
-					Usage: cache.FlavorResourceQuantities{
-						"one": {corev1.ResourceCPU: 10_000},
-					},
+					"one": {corev1.ResourceCPU: 1_000},
 				},
 			},
 			wantRepMode: Preempt,
@@ -1110,47 +1325,37 @@ func TestAssignFlavors(t *testing.T) {
 						corev1.ResourceCPU: resource.MustParse("2000m"),
 					},
 					Status: &Status{
-						reasons: []string{"insufficient unused quota in cohort for cpu in flavor one, 2 more needed"},
+						reasons: []string{"insufficient unused quota for cpu in flavor one, need to preempt 1 in ClusterQueue"},
 					},
 				}},
 			},
 		},
-		"can only preempt flavors that match affinity": {
+		"past min, but can preempt in cohort and ClusterQueue": {
 			wlPods: []kueue.PodSet{
-				{
-					Count: 1,
-					Name:  "main",
-					Template: corev1.PodTemplateSpec{
-						Spec: corev1.PodSpec{
-							Containers: utiltesting.SingleContainerForRequest(map[corev1.ResourceName]string{
-								corev1.ResourceCPU: "2",
-							}),
-							NodeSelector: map[string]string{"type": "two"},
-						},
-					},
-				},
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "2").
+					Obj(),
 			},
 			clusterQueue: cache.ClusterQueue{
 				ResourceGroups: []cache.ResourceGroup{{
 					CoveredResources: sets.New(corev1.ResourceCPU),
-					Flavors: []cache.FlavorQuotas{
-						{
-							Name: "one",
-							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
-								corev1.ResourceCPU: {Nominal: 4000},
-							},
-						},
-						{
-							Name: "two",
-							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
-								corev1.ResourceCPU: {Nominal: 4000},
-							},
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 3000},
 						},
-					},
+					}},
 				}},
 				Usage: cache.FlavorResourceQuantities{
-					"one": {corev1.ResourceCPU: 3000},
-					"two": {corev1.ResourceCPU: 3000},
+					"one": {corev1.ResourceCPU: 2_000},
+				},
+				Cohort: &cache.Cohort{
+					RequestableResources: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 10_000},
+					},
+					Usage: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 10_000},
+					},
 				},
 			},
 			wantRepMode: Preempt,
@@ -1158,21 +1363,119 @@ func TestAssignFlavors(t *testing.T) {
 				PodSets: []PodSetAssignment{{
 					Name: "main",
 					Flavors: ResourceAssignment{
-						corev1.ResourceCPU: {Name: "two", Mode: Preempt},
+						corev1.ResourceCPU: {Name: "one", Mode: Preempt},
 					},
 					Requests: corev1.ResourceList{
 						corev1.ResourceCPU: resource.MustParse("2000m"),
 					},
 					Status: &Status{
-						reasons: []string{
-							"flavor one doesn't match node affinity",
-							"insufficient unused quota for cpu in flavor two, 1 more needed",
-						},
+						reasons: []string{"insufficient unused quota for cpu in flavor one, need to preempt 1 in ClusterQueue"},
 					},
 				}},
 			},
 		},
-		"each podset requires preemption on a different flavor": {
+		"within min, but needs to reclaim from cohort": {
+			wlPods: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "2").
+					Obj(),
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 3000},
+						},
+					}},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"one": {corev1.ResourceCPU: 1_000},
+				},
+				Cohort: &cache.Cohort{
+					RequestableResources: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 10_000},
+					},
+					Usage: cache.FlavorResourceQuantities{
+						"one": {corev1.ResourceCPU: 9_000},
+					},
+				},
+			},
+			wantRepMode: Preempt,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "one", Mode: Preempt},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2000m"),
+					},
+					Status: &Status{
+						reasons: []string{"insufficient unused quota in cohort for cpu in flavor one, need to reclaim 1 from cohort"},
+					},
+				}},
+			},
+		},
+		"can only preempt flavors that match affinity": {
+			wlPods: []kueue.PodSet{
+				{
+					Count: 1,
+					Name:  "main",
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: utiltesting.SingleContainerForRequest(map[corev1.ResourceName]string{
+								corev1.ResourceCPU: "2",
+							}),
+							NodeSelector: map[string]string{"type": "two"},
+						},
+					},
+				},
+			},
+			clusterQueue: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{
+						{
+							Name: "one",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 4000},
+							},
+						},
+						{
+							Name: "two",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 4000},
+							},
+						},
+					},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"one": {corev1.ResourceCPU: 3000},
+					"two": {corev1.ResourceCPU: 3000},
+				},
+			},
+			wantRepMode: Preempt,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "two", Mode: Preempt},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2000m"),
+					},
+					Status: &Status{
+						reasons: []string{
+							"flavor one doesn't match node affinity",
+							"insufficient unused quota for cpu in flavor two, need to preempt 1 in ClusterQueue",
+						},
+					},
+				}},
+			},
+		},
+		"each podset requires preemption on a different flavor": {
 			wlPods: []kueue.PodSet{
 				*utiltesting.MakePodSet("launcher", 1).
 					Request(corev1.ResourceCPU, "2").
@@ -1223,7 +1526,7 @@ func TestAssignFlavors(t *testing.T) {
 						},
 						Status: &Status{
 							reasons: []string{
-								"insufficient unused quota for cpu in flavor one, 1 more needed",
+								"insufficient unused quota for cpu in flavor one, need to preempt 1 in ClusterQueue",
 								"untolerated taint {instance spot NoSchedule <nil>} in flavor tainted",
 							},
 						},
@@ -1239,7 +1542,7 @@ func TestAssignFlavors(t *testing.T) {
 						Status: &Status{
 							reasons: []string{
 								"insufficient quota for cpu in flavor one in ClusterQueue",
-								"insufficient unused quota for cpu in flavor tainted, 3 more needed",
+								"insufficient unused quota for cpu in flavor tainted, need to preempt 3 in ClusterQueue",
 							},
 						},
 					},
@@ -1386,9 +1689,4334 @@ func TestAssignFlavors(t *testing.T) {
 			if repMode := assignment.RepresentativeMode(); repMode != tc.wantRepMode {
 				t.Errorf("e.assignFlavors(_).RepresentativeMode()=%s, want %s", repMode, tc.wantRepMode)
 			}
-			if diff := cmp.Diff(tc.wantAssignment, assignment, cmpopts.IgnoreUnexported(Assignment{}, FlavorAssignment{})); diff != "" {
+			if diff := cmp.Diff(tc.wantAssignment, assignment, cmpopts.IgnoreUnexported(Assignment{}, PodSetAssignment{}, FlavorAssignment{})); diff != "" {
+				t.Errorf("Unexpected assignment (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsNoPodSets(t *testing.T) {
+	log := testr.NewWithOptions(t, testr.Options{
+		Verbosity: 2,
+	})
+	wlInfo := workload.NewInfo(&kueue.Workload{})
+	cq := cache.ClusterQueue{
+		ResourceGroups: []cache.ResourceGroup{{
+			CoveredResources: sets.New(corev1.ResourceCPU),
+			Flavors: []cache.FlavorQuotas{{
+				Name: "default",
+				Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+					corev1.ResourceCPU: {Nominal: 1000},
+				},
+			}},
+		}},
+	}
+	cq.UpdateRGByResource()
+	assignment := AssignFlavors(log, wlInfo, nil, &cq)
+	if repMode := assignment.RepresentativeMode(); repMode != NoFit {
+		t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, NoFit)
+	}
+	if err := assignment.Err(); !errors.Is(err, ErrNoPodSets) {
+		t.Errorf("AssignFlavors(_).Err()=%v, want %v", err, ErrNoPodSets)
+	}
+	if msg := assignment.Message(); msg != "workload has no pod sets" {
+		t.Errorf("AssignFlavors(_).Message()=%q, want %q", msg, "workload has no pod sets")
+	}
+}
+
+func TestAssignFlavorsNodeName(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"one": utiltesting.MakeResourceFlavor("one").Label("type", "one").Obj(),
+	}
+	podSet := utiltesting.MakePodSet("main", 1).
+		Request(corev1.ResourceCPU, "1").
+		NodeSelector(map[string]string{"type": "two"}).
+		Obj()
+	podSet.Template.Spec.NodeName = "node-1"
+
+	cases := map[string]struct {
+		opts           []Option
+		wantRepMode    FlavorAssignmentMode
+		wantAssignment Assignment
+	}{
+		"affinity checked by default": {
+			wantRepMode: NoFit,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("1"),
+					},
+					Status: &Status{
+						reasons: []string{"flavor one doesn't match node affinity"},
+					},
+				}},
+			},
+		},
+		"rejected": {
+			opts:        []Option{WithNodeNamePolicy(RejectNodeName)},
+			wantRepMode: NoFit,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("1"),
+					},
+					Status: &Status{
+						reasons: []string{"NodeName pinning incompatible with flavor assignment"},
+					},
+				}},
+			},
+		},
+		"ignore affinity": {
+			opts:        []Option{WithNodeNamePolicy(IgnoreAffinityForNodeName)},
+			wantRepMode: Fit,
+			wantAssignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "one", Mode: Fit},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("1"),
+					},
+				}},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			wlInfo := workload.NewInfo(&kueue.Workload{
+				Spec: kueue.WorkloadSpec{
+					PodSets: []kueue.PodSet{*podSet.DeepCopy()},
+				},
+			})
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 4000},
+						},
+					}},
+				}},
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			assignment := AssignFlavors(log, wlInfo, resourceFlavors, &cq, tc.opts...)
+			if repMode := assignment.RepresentativeMode(); repMode != tc.wantRepMode {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, tc.wantRepMode)
+			}
+			if diff := cmp.Diff(tc.wantAssignment, assignment, cmpopts.IgnoreUnexported(Assignment{}, PodSetAssignment{}, FlavorAssignment{})); diff != "" {
 				t.Errorf("Unexpected assignment (-want,+got):\n%s", diff)
 			}
 		})
 	}
 }
+
+func TestAssignFlavorsRunningTaints(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"noschedule": utiltesting.MakeResourceFlavor("noschedule").
+			Taint(corev1.Taint{
+				Key:    "instance",
+				Value:  "spot",
+				Effect: corev1.TaintEffectNoSchedule,
+			}).Obj(),
+		"noexecute": utiltesting.MakeResourceFlavor("noexecute").
+			Taint(corev1.Taint{
+				Key:    "instance",
+				Value:  "spot",
+				Effect: corev1.TaintEffectNoExecute,
+			}).Obj(),
+	}
+	cases := map[string]struct {
+		flavor      kueue.ResourceFlavorReference
+		running     bool
+		wantRepMode FlavorAssignmentMode
+		wantMessage string
+	}{
+		"pending, NoSchedule taint": {
+			flavor:      "noschedule",
+			wantRepMode: NoFit,
+			wantMessage: "couldn't assign flavors to pod set main: untolerated taint {instance spot NoSchedule <nil>} in flavor noschedule",
+		},
+		"pending, NoExecute taint": {
+			flavor:      "noexecute",
+			wantRepMode: NoFit,
+			wantMessage: "couldn't assign flavors to pod set main: untolerated taint {instance spot NoExecute <nil>} in flavor noexecute",
+		},
+		"running, NoSchedule taint": {
+			flavor:      "noschedule",
+			running:     true,
+			wantRepMode: Fit,
+		},
+		"running, NoExecute taint": {
+			flavor:      "noexecute",
+			running:     true,
+			wantRepMode: NoFit,
+			wantMessage: "couldn't assign flavors to pod set main: untolerated taint {instance spot NoExecute <nil>} in flavor noexecute",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			wl := utiltesting.MakeWorkload("wl", "ns").Request(corev1.ResourceCPU, "1")
+			if tc.running {
+				wl.Condition(metav1.Condition{
+					Type:   kueue.WorkloadAdmitted,
+					Status: metav1.ConditionTrue,
+				})
+			}
+			wlInfo := workload.NewInfo(wl.Obj())
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: tc.flavor,
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 4000},
+						},
+					}},
+				}},
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			assignment := AssignFlavors(log, wlInfo, resourceFlavors, &cq)
+			if repMode := assignment.RepresentativeMode(); repMode != tc.wantRepMode {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, tc.wantRepMode)
+			}
+			if msg := assignment.Message(); msg != tc.wantMessage {
+				t.Errorf("AssignFlavors(_).Message()=%q, want %q", msg, tc.wantMessage)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsInterruptionTolerance(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"on-demand": utiltesting.MakeResourceFlavor("on-demand").Obj(),
+		"spot": utiltesting.MakeResourceFlavor("spot").
+			Taint(corev1.Taint{
+				Key:    "instance",
+				Value:  "spot",
+				Effect: corev1.TaintEffectNoSchedule,
+			}).Obj(),
+	}
+	cases := map[string]struct {
+		labels     map[string]string
+		flavors    []kueue.ResourceFlavorReference
+		usage      cache.FlavorResourceQuantities
+		wantFlavor kueue.ResourceFlavorReference
+	}{
+		"no label, first flavor": {
+			flavors:    []kueue.ResourceFlavorReference{"spot", "on-demand"},
+			wantFlavor: "spot",
+		},
+		"tolerant, prefers spot": {
+			labels:     map[string]string{kueue.InterruptionTolerantLabel: "true"},
+			flavors:    []kueue.ResourceFlavorReference{"on-demand", "spot"},
+			wantFlavor: "spot",
+		},
+		"intolerant, prefers on-demand": {
+			labels:     map[string]string{kueue.InterruptionTolerantLabel: "false"},
+			flavors:    []kueue.ResourceFlavorReference{"spot", "on-demand"},
+			wantFlavor: "on-demand",
+		},
+		"tolerant, spot requires borrowing": {
+			labels:  map[string]string{kueue.InterruptionTolerantLabel: "true"},
+			flavors: []kueue.ResourceFlavorReference{"on-demand", "spot"},
+			usage: cache.FlavorResourceQuantities{
+				"spot": {corev1.ResourceCPU: 4000},
+			},
+			wantFlavor: "on-demand",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			wl := utiltesting.MakeWorkload("wl", "ns").
+				Request(corev1.ResourceCPU, "1").
+				Toleration(corev1.Toleration{
+					Key:      "instance",
+					Operator: corev1.TolerationOpEqual,
+					Value:    "spot",
+					Effect:   corev1.TaintEffectNoSchedule,
+				})
+			for k, v := range tc.labels {
+				wl.Label(k, v)
+			}
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+				}},
+				Usage: tc.usage,
+				Cohort: &cache.Cohort{
+					RequestableResources: cache.FlavorResourceQuantities{
+						"on-demand": {corev1.ResourceCPU: 8000},
+						"spot":      {corev1.ResourceCPU: 8000},
+					},
+					Usage: tc.usage,
+				},
+			}
+			for _, f := range tc.flavors {
+				cq.ResourceGroups[0].Flavors = append(cq.ResourceGroups[0].Flavors, cache.FlavorQuotas{
+					Name: f,
+					Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+						corev1.ResourceCPU: {Nominal: 4000},
+					},
+				})
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			assignment := AssignFlavors(log, workload.NewInfo(wl.Obj()), resourceFlavors, &cq)
+			if repMode := assignment.RepresentativeMode(); repMode != Fit {
+				t.Fatalf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, Fit)
+			}
+			got := assignment.PodSets[0].Flavors[corev1.ResourceCPU].Name
+			if got != tc.wantFlavor {
+				t.Errorf("AssignFlavors(_) assigned flavor %s, want %s", got, tc.wantFlavor)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsSchedulerName(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"default": utiltesting.MakeResourceFlavor("default").Obj(),
+		"custom":  utiltesting.MakeResourceFlavor("custom").SchedulerName("custom-scheduler").Obj(),
+	}
+	cases := map[string]struct {
+		schedulerName string
+		flavor        kueue.ResourceFlavorReference
+		wantRepMode   FlavorAssignmentMode
+		wantMessage   string
+	}{
+		"flavor without scheduler": {
+			schedulerName: "custom-scheduler",
+			flavor:        "default",
+			wantRepMode:   Fit,
+		},
+		"matching scheduler": {
+			schedulerName: "custom-scheduler",
+			flavor:        "custom",
+			wantRepMode:   Fit,
+		},
+		"mismatched scheduler": {
+			schedulerName: "other-scheduler",
+			flavor:        "custom",
+			wantRepMode:   NoFit,
+			wantMessage:   "couldn't assign flavors to pod set main: flavor custom is for scheduler custom-scheduler, pods use other-scheduler",
+		},
+		"default scheduler": {
+			flavor:      "custom",
+			wantRepMode: NoFit,
+			wantMessage: "couldn't assign flavors to pod set main: flavor custom is for scheduler custom-scheduler, pods use default-scheduler",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			wl := utiltesting.MakeWorkload("wl", "ns").Request(corev1.ResourceCPU, "1").Obj()
+			wl.Spec.PodSets[0].Template.Spec.SchedulerName = tc.schedulerName
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: tc.flavor,
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 4_000},
+						},
+					}},
+				}},
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			assignment := AssignFlavors(log, workload.NewInfo(wl), resourceFlavors, &cq)
+			if repMode := assignment.RepresentativeMode(); repMode != tc.wantRepMode {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, tc.wantRepMode)
+			}
+			if msg := assignment.Message(); msg != tc.wantMessage {
+				t.Errorf("AssignFlavors(_).Message()=%q, want %q", msg, tc.wantMessage)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsResourceAliases(t *testing.T) {
+	const (
+		gpu     corev1.ResourceName = "nvidia.com/gpu"
+		gpuA100 corev1.ResourceName = "nvidia.com/gpu-a100"
+	)
+	cases := map[string]struct {
+		flavors      []*kueue.ResourceFlavor
+		a100Quota    int64
+		wantRepMode  FlavorAssignmentMode
+		wantFlavor   kueue.ResourceFlavorReference
+		wantRequests corev1.ResourceList
+		wantMessage  string
+	}{
+		"request matches generic quota via alias": {
+			flavors: []*kueue.ResourceFlavor{
+				utiltesting.MakeResourceFlavor("t4").Obj(),
+				utiltesting.MakeResourceFlavor("a100").ResourceAlias(gpuA100, gpu).Obj(),
+			},
+			a100Quota:   4,
+			wantRepMode: Fit,
+			wantFlavor:  "a100",
+			wantRequests: corev1.ResourceList{
+				gpu: resource.MustParse("2"),
+			},
+		},
+		"no flavor declares the alias": {
+			flavors: []*kueue.ResourceFlavor{
+				utiltesting.MakeResourceFlavor("t4").Obj(),
+				utiltesting.MakeResourceFlavor("a100").Obj(),
+			},
+			a100Quota:   4,
+			wantRepMode: NoFit,
+			wantRequests: corev1.ResourceList{
+				gpuA100: resource.MustParse("2"),
+			},
+			wantMessage: "couldn't assign flavors to pod set main: resource nvidia.com/gpu-a100 unavailable in ClusterQueue",
+		},
+		"aliasing flavor without enough quota": {
+			flavors: []*kueue.ResourceFlavor{
+				utiltesting.MakeResourceFlavor("t4").Obj(),
+				utiltesting.MakeResourceFlavor("a100").ResourceAlias(gpuA100, gpu).Obj(),
+			},
+			a100Quota:   1,
+			wantRepMode: NoFit,
+			wantRequests: corev1.ResourceList{
+				gpu: resource.MustParse("2"),
+			},
+			wantMessage: "couldn't assign flavors to pod set main: flavor t4 doesn't alias nvidia.com/gpu-a100 to nvidia.com/gpu, insufficient quota for nvidia.com/gpu in flavor a100 in ClusterQueue",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			resourceFlavors := make(map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor, len(tc.flavors))
+			for _, rf := range tc.flavors {
+				resourceFlavors[kueue.ResourceFlavorReference(rf.Name)] = rf
+			}
+			wl := utiltesting.MakeWorkload("wl", "ns").Request(gpuA100, "2").Obj()
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(gpu),
+					Flavors: []cache.FlavorQuotas{
+						{
+							Name: "t4",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								gpu: {Nominal: 8},
+							},
+						},
+						{
+							Name: "a100",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								gpu: {Nominal: tc.a100Quota},
+							},
+						},
+					},
+				}},
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			assignment := AssignFlavors(log, workload.NewInfo(wl), resourceFlavors, &cq)
+			if repMode := assignment.RepresentativeMode(); repMode != tc.wantRepMode {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, tc.wantRepMode)
+			}
+			if msg := assignment.Message(); msg != tc.wantMessage {
+				t.Errorf("AssignFlavors(_).Message()=%q, want %q", msg, tc.wantMessage)
+			}
+			psa := assignment.PodSets[0]
+			if tc.wantFlavor != "" {
+				if got := psa.Flavors[gpu]; got == nil || got.Name != tc.wantFlavor {
+					t.Errorf("Assigned flavor %v for %s, want %s", got, gpu, tc.wantFlavor)
+				}
+			}
+			if diff := cmp.Diff(tc.wantRequests, psa.Requests); diff != "" {
+				t.Errorf("Unexpected requests (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsDeprecated(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"old": utiltesting.MakeResourceFlavor("old").Deprecated().Obj(),
+		"new": utiltesting.MakeResourceFlavor("new").Obj(),
+	}
+	cases := map[string]struct {
+		newUsage    int64
+		wantFlavor  kueue.ResourceFlavorReference
+		wantRepMode FlavorAssignmentMode
+		wantInfos   []string
+	}{
+		"non-deprecated flavor preferred": {
+			wantFlavor:  "new",
+			wantRepMode: Fit,
+		},
+		"deprecated flavor with a better mode": {
+			newUsage:    4_000,
+			wantFlavor:  "old",
+			wantRepMode: Fit,
+			wantInfos:   []string{"flavor old is deprecated"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			wl := utiltesting.MakeWorkload("wl", "ns").Request(corev1.ResourceCPU, "1").Obj()
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{
+						{
+							Name: "old",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 4_000},
+							},
+						},
+						{
+							Name: "new",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 4_000},
+							},
+						},
+					},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"new": {corev1.ResourceCPU: tc.newUsage},
+				},
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			assignment := AssignFlavors(log, workload.NewInfo(wl), resourceFlavors, &cq)
+			if repMode := assignment.RepresentativeMode(); repMode != tc.wantRepMode {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, tc.wantRepMode)
+			}
+			psa := assignment.PodSets[0]
+			if got := psa.Flavors[corev1.ResourceCPU].Name; got != tc.wantFlavor {
+				t.Errorf("Assigned flavor %s, want %s", got, tc.wantFlavor)
+			}
+			if diff := cmp.Diff(tc.wantInfos, psa.Status.Infos()); diff != "" {
+				t.Errorf("Unexpected infos (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// randomClusterQueue returns a ClusterQueue in a cohort with the given flavors
+// for cpu, memory and gpus, with random quotas and usage.
+func randomClusterQueue(rnd *rand.Rand, flavors []kueue.ResourceFlavorReference) cache.ClusterQueue {
+	cq := cache.ClusterQueue{
+		ResourceGroups: []cache.ResourceGroup{{
+			CoveredResources: sets.New[corev1.ResourceName](corev1.ResourceCPU, corev1.ResourceMemory, "example.com/gpu"),
+		}},
+		Usage: make(cache.FlavorResourceQuantities),
+		Cohort: &cache.Cohort{
+			RequestableResources: make(cache.FlavorResourceQuantities),
+			Usage:                make(cache.FlavorResourceQuantities),
+		},
+	}
+	for _, fName := range flavors {
+		flvQuotas := cache.FlavorQuotas{
+			Name:      fName,
+			Resources: make(map[corev1.ResourceName]*cache.ResourceQuota),
+		}
+		cq.Usage[fName] = make(map[corev1.ResourceName]int64)
+		cq.Cohort.RequestableResources[fName] = make(map[corev1.ResourceName]int64)
+		cq.Cohort.Usage[fName] = make(map[corev1.ResourceName]int64)
+		for _, rName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, "example.com/gpu"} {
+			nominal := rnd.Int63n(10)
+			usage := rnd.Int63n(10)
+			flvQuotas.Resources[rName] = &cache.ResourceQuota{Nominal: nominal}
+			cq.Usage[fName][rName] = usage
+			cq.Cohort.RequestableResources[fName][rName] = nominal + rnd.Int63n(10)
+			cq.Cohort.Usage[fName][rName] = usage + rnd.Int63n(10)
+		}
+		cq.ResourceGroups[0].Flavors = append(cq.ResourceGroups[0].Flavors, flvQuotas)
+	}
+	cq.UpdateRGByResource()
+	return cq
+}
+
+// TestAssignFlavorsMatchesExhaustive checks that the flavor chosen for a
+// resource group, skipping the assignments of flavors that can't be preferred,
+// is the same as the one chosen by evaluating each flavor on its own, and that
+// the reasons recorded when the workload doesn't fit are the same too.
+func TestAssignFlavorsMatchesExhaustive(t *testing.T) {
+	log := testr.NewWithOptions(t, testr.Options{
+		Verbosity: 2,
+	})
+	const numFlavors = 5
+	flavors := make([]kueue.ResourceFlavorReference, numFlavors)
+	resourceFlavors := make(map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor, numFlavors)
+	for i := range flavors {
+		flavors[i] = kueue.ResourceFlavorReference(fmt.Sprintf("f%d", i))
+		resourceFlavors[flavors[i]] = utiltesting.MakeResourceFlavor(string(flavors[i])).Obj()
+	}
+	wlInfo := workload.NewInfo(&kueue.Workload{
+		Spec: kueue.WorkloadSpec{
+			PodSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "5m").
+					Request(corev1.ResourceMemory, "5").
+					Request("example.com/gpu", "5").
+					Obj(),
+			},
+		},
+	})
+	rnd := rand.New(rand.NewSource(0))
+	for i := 0; i < 500; i++ {
+		cq := randomClusterQueue(rnd, flavors)
+		got := AssignFlavors(log, wlInfo, resourceFlavors, &cq)
+
+		wantMode := NoFit
+		var wantBorrow int64
+		var wantFlavor kueue.ResourceFlavorReference
+		wantStatus := &Status{}
+		for _, flvQuotas := range cq.ResourceGroups[0].Flavors {
+			single := cq
+			single.ResourceGroups = []cache.ResourceGroup{{
+				CoveredResources: cq.ResourceGroups[0].CoveredResources,
+				Flavors:          []cache.FlavorQuotas{flvQuotas},
+			}}
+			single.UpdateRGByResource()
+			a := AssignFlavors(log, wlInfo, resourceFlavors, &single)
+			if s := a.PodSets[0].Status; s != nil {
+				wantStatus.reasons = append(wantStatus.reasons, s.reasons...)
+			}
+			mode := a.RepresentativeMode()
+			var borrow int64
+			for _, v := range a.TotalBorrow[flvQuotas.Name] {
+				borrow += v
+			}
+			if mode > wantMode || (mode == wantMode && mode != NoFit && borrow < wantBorrow) {
+				wantMode = mode
+				wantBorrow = borrow
+				wantFlavor = flvQuotas.Name
+			}
+		}
+
+		if gotMode := got.RepresentativeMode(); gotMode != wantMode {
+			t.Fatalf("Iteration %d: got mode %s, want %s", i, gotMode, wantMode)
+		}
+		for rName, flvAssignment := range got.PodSets[0].Flavors {
+			if flvAssignment.Name != wantFlavor {
+				t.Fatalf("Iteration %d: got flavor %s for %s, want %s", i, flvAssignment.Name, rName, wantFlavor)
+			}
+		}
+		// A fitting flavor can end the evaluation before the rest of the
+		// flavors are checked, and the reasons are dropped anyway.
+		if wantMode != Fit && !got.PodSets[0].Status.Equal(wantStatus) {
+			t.Fatalf("Iteration %d: got reasons %q, want %q", i, got.PodSets[0].Status.Message(), wantStatus.Message())
+		}
+	}
+}
+
+// TestAssignFlavorsDeterministicMessage checks that the reasons recorded for
+// a flavor whose evaluation stops early don't depend on the map iteration
+// order of the requests.
+func TestAssignFlavorsDeterministicMessage(t *testing.T) {
+	log := testr.NewWithOptions(t, testr.Options{
+		Verbosity: 2,
+	})
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"one": utiltesting.MakeResourceFlavor("one").Obj(),
+		"two": utiltesting.MakeResourceFlavor("two").Obj(),
+	}
+	cq := cachetesting.NewClusterQueueFromAPI(utiltesting.MakeClusterQueue("cq").
+		ResourceGroup(
+			*utiltesting.MakeFlavorQuotas("one").
+				Resource(corev1.ResourceCPU, "2").
+				Resource(corev1.ResourceMemory, "2Gi").
+				Obj(),
+			*utiltesting.MakeFlavorQuotas("two").
+				Resource(corev1.ResourceCPU, "2").
+				Resource(corev1.ResourceMemory, "2Gi").
+				Obj(),
+		).
+		Obj(), resourceFlavors)
+	for _, fName := range []kueue.ResourceFlavorReference{"one", "two"} {
+		cq.Usage[fName][corev1.ResourceCPU] = 2_000
+		cq.Usage[fName][corev1.ResourceMemory] = 2 * utiltesting.Gi
+	}
+	wl := workload.NewInfo(utiltesting.MakeWorkload("wl", "ns").
+		Request(corev1.ResourceCPU, "1").
+		Request(corev1.ResourceMemory, "1Gi").
+		Obj())
+	want := "couldn't assign flavors to pod set main: " +
+		"insufficient unused quota for cpu in flavor one, need to preempt 1 in ClusterQueue, " +
+		"insufficient unused quota for cpu in flavor two, need to preempt 1 in ClusterQueue, " +
+		"insufficient unused quota for memory in flavor one, need to preempt 1Gi in ClusterQueue, " +
+		"insufficient unused quota for memory in flavor two, need to preempt 1Gi in ClusterQueue"
+	for i := 0; i < 20; i++ {
+		assignment := AssignFlavors(log, wl, resourceFlavors, cq)
+		if msg := assignment.Message(); msg != want {
+			t.Fatalf("AssignFlavors(_).Message()=%q, want %q", msg, want)
+		}
+	}
+}
+
+func BenchmarkAssignFlavorsManyFlavors(b *testing.B) {
+	const numFlavors = 100
+	resourceFlavors := make(map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor, numFlavors)
+	cq := cache.ClusterQueue{
+		ResourceGroups: []cache.ResourceGroup{{
+			CoveredResources: sets.New(corev1.ResourceCPU, corev1.ResourceMemory),
+		}},
+		Usage: make(cache.FlavorResourceQuantities),
+	}
+	for i := 0; i < numFlavors; i++ {
+		fName := kueue.ResourceFlavorReference(fmt.Sprintf("f%d", i))
+		resourceFlavors[fName] = utiltesting.MakeResourceFlavor(string(fName)).Obj()
+		cq.ResourceGroups[0].Flavors = append(cq.ResourceGroups[0].Flavors, cache.FlavorQuotas{
+			Name: fName,
+			Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+				corev1.ResourceCPU:    {Nominal: 4_000},
+				corev1.ResourceMemory: {Nominal: 4 * utiltesting.Gi},
+			},
+		})
+		// All the flavors are fully used, so the workload needs preemption.
+		cq.Usage[fName] = map[corev1.ResourceName]int64{
+			corev1.ResourceCPU:    4_000,
+			corev1.ResourceMemory: 4 * utiltesting.Gi,
+		}
+	}
+	cq.UpdateRGByResource()
+	wlInfo := workload.NewInfo(&kueue.Workload{
+		Spec: kueue.WorkloadSpec{
+			PodSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "1").
+					Request(corev1.ResourceMemory, "1Gi").
+					Obj(),
+			},
+		},
+	})
+	log := logr.Discard()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		AssignFlavors(log, wlInfo, resourceFlavors, &cq)
+	}
+}
+
+func TestAssignFlavorsMaxConcurrentWorkloads(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"one": utiltesting.MakeResourceFlavor("one").Obj(),
+		"two": utiltesting.MakeResourceFlavor("two").Obj(),
+	}
+	admitted := func(name string, flavor kueue.ResourceFlavorReference) *workload.Info {
+		return workload.NewInfo(utiltesting.MakeWorkload(name, "ns").
+			Request(corev1.ResourceCPU, "1").
+			Admit(utiltesting.MakeAdmission("cq").Assignment(corev1.ResourceCPU, flavor, "1").Obj()).
+			Obj())
+	}
+	cases := map[string]struct {
+		workloads   []*workload.Info
+		wantRepMode FlavorAssignmentMode
+		wantMessage string
+	}{
+		"below the limit": {
+			workloads: []*workload.Info{
+				admitted("a", "one"),
+				admitted("b", "two"),
+			},
+			wantRepMode: Fit,
+		},
+		"at the limit": {
+			workloads: []*workload.Info{
+				admitted("a", "one"),
+				admitted("b", "one"),
+			},
+			wantRepMode: NoFit,
+			wantMessage: "couldn't assign flavors to pod set main: flavor one reached the maximum of 2 concurrent workloads",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 10_000},
+						},
+						MaxConcurrentWorkloads: pointer.Int32(2),
+					}},
+				}},
+				WorkloadsByFlavor: make(map[kueue.ResourceFlavorReference]int),
+			}
+			for _, wi := range tc.workloads {
+				for fName := range wi.AssignedFlavors() {
+					cq.WorkloadsByFlavor[fName]++
+				}
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			wlInfo := workload.NewInfo(utiltesting.MakeWorkload("new", "ns").Request(corev1.ResourceCPU, "1").Obj())
+			assignment := AssignFlavors(log, wlInfo, resourceFlavors, &cq)
+			if repMode := assignment.RepresentativeMode(); repMode != tc.wantRepMode {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, tc.wantRepMode)
+			}
+			if msg := assignment.Message(); msg != tc.wantMessage {
+				t.Errorf("AssignFlavors(_).Message()=%q, want %q", msg, tc.wantMessage)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsParentCohort(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"one": utiltesting.MakeResourceFlavor("one").Obj(),
+	}
+	cases := map[string]struct {
+		parent      *cache.Cohort
+		wantRepMode FlavorAssignmentMode
+		wantBorrow  cache.FlavorResourceQuantities
+		wantMessage string
+	}{
+		"no parent": {
+			wantRepMode: NoFit,
+			wantMessage: "couldn't assign flavors to pod set main: insufficient quota for cpu in flavor one in cohort",
+		},
+		"borrowing from the parent": {
+			parent: cachetesting.MakeCohort("parent").
+				Requestable("one", corev1.ResourceCPU, "12").
+				Usage("one", corev1.ResourceCPU, "6").
+				Obj(),
+			wantRepMode: Fit,
+			wantBorrow: cache.FlavorResourceQuantities{
+				"one": {corev1.ResourceCPU: 3_000},
+			},
+		},
+		"parent exhausted": {
+			parent: cachetesting.MakeCohort("parent").
+				Requestable("one", corev1.ResourceCPU, "12").
+				Usage("one", corev1.ResourceCPU, "10").
+				Obj(),
+			wantRepMode: NoFit,
+			wantMessage: "couldn't assign flavors to pod set main: insufficient unused quota in cohort for cpu in flavor one, 3 more needed",
+		},
+		"borrowing from the grandparent": {
+			parent: cachetesting.MakeCohort("parent").
+				Requestable("one", corev1.ResourceCPU, "12").
+				Usage("one", corev1.ResourceCPU, "12").
+				Parent(cachetesting.MakeCohort("grandparent").
+					Requestable("one", corev1.ResourceCPU, "28").
+					Usage("one", corev1.ResourceCPU, "12").
+					Obj()).
+				Obj(),
+			wantRepMode: Fit,
+			wantBorrow: cache.FlavorResourceQuantities{
+				"one": {corev1.ResourceCPU: 3_000},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 2_000},
+						},
+					}},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"one": {corev1.ResourceCPU: 2_000},
+				},
+				Cohort: cachetesting.MakeCohort("cohort").
+					Requestable("one", corev1.ResourceCPU, "4").
+					Usage("one", corev1.ResourceCPU, "4").
+					Parent(tc.parent).
+					Obj(),
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			wlInfo := workload.NewInfo(utiltesting.MakeWorkload("wl", "ns").Request(corev1.ResourceCPU, "3").Obj())
+			assignment := AssignFlavors(log, wlInfo, resourceFlavors, &cq)
+			if repMode := assignment.RepresentativeMode(); repMode != tc.wantRepMode {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, tc.wantRepMode)
+			}
+			if diff := cmp.Diff(tc.wantBorrow, assignment.TotalBorrow); diff != "" {
+				t.Errorf("Unexpected borrow (-want,+got):\n%s", diff)
+			}
+			if msg := assignment.Message(); msg != tc.wantMessage {
+				t.Errorf("AssignFlavors(_).Message()=%q, want %q", msg, tc.wantMessage)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsOvercommit(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"one": utiltesting.MakeResourceFlavor("one").Obj(),
+	}
+	cases := map[string]struct {
+		ratio       *float64
+		request     string
+		wantRepMode FlavorAssignmentMode
+		wantMessage string
+	}{
+		"no overcommit, above nominal": {
+			request:     "6",
+			wantRepMode: NoFit,
+			wantMessage: "couldn't assign flavors to pod set main: insufficient quota for cpu in flavor one in ClusterQueue",
+		},
+		"overcommit, above nominal": {
+			ratio:       pointer.Float64(2.0),
+			request:     "6",
+			wantRepMode: Fit,
+		},
+		"overcommit, above overcommitted nominal": {
+			ratio:       pointer.Float64(2.0),
+			request:     "9",
+			wantRepMode: NoFit,
+			wantMessage: "couldn't assign flavors to pod set main: insufficient quota for cpu in flavor one in ClusterQueue",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 4_000, OvercommitRatio: tc.ratio},
+						},
+					}},
+				}},
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			wlInfo := workload.NewInfo(utiltesting.MakeWorkload("wl", "ns").Request(corev1.ResourceCPU, tc.request).Obj())
+			assignment := AssignFlavors(log, wlInfo, resourceFlavors, &cq)
+			if repMode := assignment.RepresentativeMode(); repMode != tc.wantRepMode {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, tc.wantRepMode)
+			}
+			if msg := assignment.Message(); msg != tc.wantMessage {
+				t.Errorf("AssignFlavors(_).Message()=%q, want %q", msg, tc.wantMessage)
+			}
+			if assignment.Borrows() {
+				t.Errorf("AssignFlavors(_).Borrows()=true, want false")
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsWarmingFlavors(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"scaling": utiltesting.MakeResourceFlavor("scaling").Obj(),
+		"ready":   utiltesting.MakeResourceFlavor("ready").Obj(),
+	}
+	cases := map[string]struct {
+		warming    sets.Set[kueue.ResourceFlavorReference]
+		readyUsage int64
+		wantFlavor kueue.ResourceFlavorReference
+	}{
+		"no warming flavors": {
+			wantFlavor: "scaling",
+		},
+		"warming flavor ranked below ready one": {
+			warming:    sets.New[kueue.ResourceFlavorReference]("scaling"),
+			wantFlavor: "ready",
+		},
+		"warming flavor with a better mode": {
+			warming:    sets.New[kueue.ResourceFlavorReference]("scaling"),
+			readyUsage: 4_000,
+			wantFlavor: "scaling",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			wl := utiltesting.MakeWorkload("wl", "ns").Request(corev1.ResourceCPU, "1").Obj()
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{
+						{
+							Name: "scaling",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 4_000},
+							},
+						},
+						{
+							Name: "ready",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 4_000},
+							},
+						},
+					},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"ready": {corev1.ResourceCPU: tc.readyUsage},
+				},
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			assignment := AssignFlavors(log, workload.NewInfo(wl), resourceFlavors, &cq, WithWarmingFlavors(tc.warming))
+			if repMode := assignment.RepresentativeMode(); repMode != Fit {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, Fit)
+			}
+			if got := assignment.PodSets[0].Flavors[corev1.ResourceCPU].Name; got != tc.wantFlavor {
+				t.Errorf("Assigned flavor %s, want %s", got, tc.wantFlavor)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsBorrowingAvoidanceThreshold(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"first":  utiltesting.MakeResourceFlavor("first").Obj(),
+		"second": utiltesting.MakeResourceFlavor("second").Obj(),
+	}
+	cases := map[string]struct {
+		priority   int32
+		opts       []Option
+		wantFlavor kueue.ResourceFlavorReference
+		wantBorrow bool
+	}{
+		"no threshold": {
+			wantFlavor: "second",
+		},
+		"high priority avoids borrowing": {
+			priority:   200,
+			opts:       []Option{WithBorrowingAvoidanceThreshold(100)},
+			wantFlavor: "second",
+		},
+		"low priority borrows freely": {
+			opts:       []Option{WithBorrowingAvoidanceThreshold(100)},
+			wantFlavor: "first",
+			wantBorrow: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			wl := utiltesting.MakeWorkload("wl", "ns").
+				Priority(tc.priority).
+				Request(corev1.ResourceCPU, "1").
+				Obj()
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{
+						{
+							Name: "first",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 2_000},
+							},
+						},
+						{
+							Name: "second",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 4_000},
+							},
+						},
+					},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"first": {corev1.ResourceCPU: 2_000},
+				},
+				Cohort: cachetesting.MakeCohort("cohort").
+					Requestable("first", corev1.ResourceCPU, "6").
+					Requestable("second", corev1.ResourceCPU, "8").
+					Usage("first", corev1.ResourceCPU, "2").
+					Obj(),
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			assignment := AssignFlavors(log, workload.NewInfo(wl), resourceFlavors, &cq, tc.opts...)
+			if repMode := assignment.RepresentativeMode(); repMode != Fit {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, Fit)
+			}
+			if got := assignment.PodSets[0].Flavors[corev1.ResourceCPU].Name; got != tc.wantFlavor {
+				t.Errorf("Assigned flavor %s, want %s", got, tc.wantFlavor)
+			}
+			if got := assignment.Borrows(); got != tc.wantBorrow {
+				t.Errorf("AssignFlavors(_).Borrows()=%t, want %t", got, tc.wantBorrow)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsColocation(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"cpu-a": utiltesting.MakeResourceFlavor("cpu-a").Label(corev1.LabelTopologyZone, "a").Obj(),
+		"cpu-b": utiltesting.MakeResourceFlavor("cpu-b").Label(corev1.LabelTopologyZone, "b").Obj(),
+		"gpu-b": utiltesting.MakeResourceFlavor("gpu-b").Label(corev1.LabelTopologyZone, "b").Obj(),
+		"gpu-a": utiltesting.MakeResourceFlavor("gpu-a").Label(corev1.LabelTopologyZone, "a").Obj(),
+	}
+	cases := map[string]struct {
+		colocate    bool
+		cpuAUsage   int64
+		cpuBUsage   int64
+		gpuAUsage   int64
+		wantFlavors ResourceAssignment
+	}{
+		"no colocation": {
+			wantFlavors: ResourceAssignment{
+				corev1.ResourceCPU: {Name: "cpu-a", Mode: Fit},
+				"example.com/gpu":  {Name: "gpu-b", Mode: Fit},
+			},
+		},
+		"colocation in the zone of the first flavor": {
+			colocate: true,
+			wantFlavors: ResourceAssignment{
+				corev1.ResourceCPU: {Name: "cpu-a", Mode: Fit},
+				"example.com/gpu":  {Name: "gpu-a", Mode: Fit},
+			},
+		},
+		"colocation in the zone of the flavor with quota": {
+			colocate:  true,
+			cpuAUsage: 4_000,
+			wantFlavors: ResourceAssignment{
+				corev1.ResourceCPU: {Name: "cpu-b", Mode: Fit},
+				"example.com/gpu":  {Name: "gpu-b", Mode: Fit},
+			},
+		},
+		"colocation when the first flavor has no match in the next group": {
+			colocate:  true,
+			gpuAUsage: 4,
+			wantFlavors: ResourceAssignment{
+				corev1.ResourceCPU: {Name: "cpu-b", Mode: Fit},
+				"example.com/gpu":  {Name: "gpu-b", Mode: Fit},
+			},
+		},
+		"split when no zone has quota for both groups": {
+			colocate:  true,
+			cpuBUsage: 4_000,
+			gpuAUsage: 4,
+			wantFlavors: ResourceAssignment{
+				corev1.ResourceCPU: {Name: "cpu-a", Mode: Fit},
+				"example.com/gpu":  {Name: "gpu-b", Mode: Fit},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			wlWrapper := utiltesting.MakeWorkload("wl", "ns").
+				Request(corev1.ResourceCPU, "1").
+				Request("example.com/gpu", "1")
+			if tc.colocate {
+				wlWrapper.Annotation(kueue.ColocationLabelAnnotation, corev1.LabelTopologyZone)
+			}
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{
+					{
+						CoveredResources: sets.New(corev1.ResourceCPU),
+						Flavors: []cache.FlavorQuotas{
+							{
+								Name: "cpu-a",
+								Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+									corev1.ResourceCPU: {Nominal: 4_000},
+								},
+							},
+							{
+								Name: "cpu-b",
+								Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+									corev1.ResourceCPU: {Nominal: 4_000},
+								},
+							},
+						},
+						LabelKeys: sets.New(corev1.LabelTopologyZone),
+					},
+					{
+						CoveredResources: sets.New[corev1.ResourceName]("example.com/gpu"),
+						Flavors: []cache.FlavorQuotas{
+							{
+								Name: "gpu-b",
+								Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+									"example.com/gpu": {Nominal: 4},
+								},
+							},
+							{
+								Name: "gpu-a",
+								Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+									"example.com/gpu": {Nominal: 4},
+								},
+							},
+						},
+						LabelKeys: sets.New(corev1.LabelTopologyZone),
+					},
+				},
+				Usage: cache.FlavorResourceQuantities{
+					"cpu-a": {corev1.ResourceCPU: tc.cpuAUsage},
+					"cpu-b": {corev1.ResourceCPU: tc.cpuBUsage},
+					"gpu-a": {"example.com/gpu": tc.gpuAUsage},
+				},
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			assignment := AssignFlavors(log, workload.NewInfo(wlWrapper.Obj()), resourceFlavors, &cq)
+			if repMode := assignment.RepresentativeMode(); repMode != Fit {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, Fit)
+			}
+			if diff := cmp.Diff(tc.wantFlavors, assignment.PodSets[0].Flavors, cmpopts.IgnoreUnexported(FlavorAssignment{})); diff != "" {
+				t.Errorf("Unexpected flavors (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsGranularityUsage(t *testing.T) {
+	log := testr.NewWithOptions(t, testr.Options{
+		Verbosity: 2,
+	})
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"one": utiltesting.MakeResourceFlavor("one").Obj(),
+	}
+	wl := workload.NewInfo(utiltesting.MakeWorkload("wl", "ns").
+		PodSets(
+			*utiltesting.MakePodSet("driver", 1).Request("example.com/gpu", "1").Obj(),
+			*utiltesting.MakePodSet("workers", 1).Request("example.com/gpu", "1").Obj(),
+		).
+		Obj())
+	cq := cache.ClusterQueue{
+		ResourceGroups: []cache.ResourceGroup{{
+			CoveredResources: sets.New[corev1.ResourceName]("example.com/gpu"),
+			Flavors: []cache.FlavorQuotas{{
+				Name: "one",
+				Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+					"example.com/gpu": {Nominal: 8, Granularity: pointer.Int64(4)},
+				},
+			}},
+		}},
+	}
+	cq.UpdateRGByResource()
+	assignment := AssignFlavors(log, wl, resourceFlavors, &cq)
+	if repMode := assignment.RepresentativeMode(); repMode != Fit {
+		t.Fatalf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, Fit)
+	}
+	wantUsage := cache.FlavorResourceQuantities{
+		"one": {"example.com/gpu": 8},
+	}
+	if diff := cmp.Diff(wantUsage, assignment.Usage()); diff != "" {
+		t.Errorf("Unexpected usage (-want,+got):\n%s", diff)
+	}
+	for _, psa := range assignment.ToAPI() {
+		if got := psa.ResourceUsage["example.com/gpu"]; got.Value() != 4 {
+			t.Errorf("Pod set %s has usage %s, want 4", psa.Name, &got)
+		}
+	}
+}
+
+func TestAssignFlavorsResourceNameFormatter(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"default": utiltesting.MakeResourceFlavor("default").Obj(),
+	}
+	gpuNames := func(rName corev1.ResourceName) string {
+		if rName == "example.com/gpu" {
+			return "GPUs"
+		}
+		return string(rName)
+	}
+	cases := map[string]struct {
+		resource    corev1.ResourceName
+		request     string
+		gpuUsage    int64
+		formatter   ResourceNameFormatter
+		wantMessage string
+	}{
+		"no formatter": {
+			resource:    "example.com/gpu",
+			request:     "3",
+			wantMessage: "couldn't assign flavors to pod set main: insufficient quota for example.com/gpu in flavor default in ClusterQueue",
+		},
+		"insufficient quota": {
+			resource:    "example.com/gpu",
+			request:     "3",
+			formatter:   gpuNames,
+			wantMessage: "couldn't assign flavors to pod set main: insufficient quota for GPUs in flavor default in ClusterQueue",
+		},
+		"preemption needed": {
+			resource:    "example.com/gpu",
+			request:     "2",
+			gpuUsage:    1,
+			formatter:   gpuNames,
+			wantMessage: "couldn't assign flavors to pod set main: insufficient unused quota for GPUs in flavor default, need to preempt 1 in ClusterQueue",
+		},
+		"resource unavailable": {
+			resource:    "example.com/tpu",
+			request:     "1",
+			formatter:   func(rName corev1.ResourceName) string { return "TPUs" },
+			wantMessage: "couldn't assign flavors to pod set main: resource TPUs unavailable in ClusterQueue",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			cq := cachetesting.NewClusterQueueFromAPI(utiltesting.MakeClusterQueue("cq").
+				ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource("example.com/gpu", "2").Obj()).
+				Obj(), resourceFlavors)
+			cq.Usage["default"]["example.com/gpu"] = tc.gpuUsage
+			wl := utiltesting.MakeWorkload("wl", "ns").Request(tc.resource, tc.request).Obj()
+			assignment := AssignFlavors(log, workload.NewInfo(wl), resourceFlavors, cq, WithResourceNameFormatter(tc.formatter))
+			if msg := assignment.Message(); msg != tc.wantMessage {
+				t.Errorf("AssignFlavors(_).Message()=%q, want %q", msg, tc.wantMessage)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsExcluding(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"canary": utiltesting.MakeResourceFlavor("canary").Obj(),
+		"stable": utiltesting.MakeResourceFlavor("stable").Obj(),
+	}
+	cases := map[string]struct {
+		request     string
+		exclude     sets.Set[kueue.ResourceFlavorReference]
+		wantRepMode FlavorAssignmentMode
+		wantFlavors ResourceAssignment
+		wantMessage string
+	}{
+		"no exclusions": {
+			request:     "3",
+			wantRepMode: Fit,
+			wantFlavors: ResourceAssignment{
+				corev1.ResourceCPU: {Name: "canary", Mode: Fit},
+			},
+		},
+		"canary excluded": {
+			request:     "1",
+			exclude:     sets.New[kueue.ResourceFlavorReference]("canary"),
+			wantRepMode: Fit,
+			wantFlavors: ResourceAssignment{
+				corev1.ResourceCPU: {Name: "stable", Mode: Fit},
+			},
+		},
+		"canary excluded, doesn't fit in stable": {
+			request:     "3",
+			exclude:     sets.New[kueue.ResourceFlavorReference]("canary"),
+			wantRepMode: NoFit,
+			wantMessage: "couldn't assign flavors to pod set main: insufficient quota for cpu in flavor stable in ClusterQueue",
+		},
+		"unknown flavor excluded": {
+			request:     "3",
+			exclude:     sets.New[kueue.ResourceFlavorReference]("other"),
+			wantRepMode: Fit,
+			wantFlavors: ResourceAssignment{
+				corev1.ResourceCPU: {Name: "canary", Mode: Fit},
+			},
+		},
+		"all flavors excluded": {
+			request:     "1",
+			exclude:     sets.New[kueue.ResourceFlavorReference]("canary", "stable"),
+			wantRepMode: NoFit,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			cq := cachetesting.NewClusterQueueFromAPI(utiltesting.MakeClusterQueue("cq").
+				ResourceGroup(
+					*utiltesting.MakeFlavorQuotas("canary").Resource(corev1.ResourceCPU, "4").Obj(),
+					*utiltesting.MakeFlavorQuotas("stable").Resource(corev1.ResourceCPU, "2").Obj(),
+				).
+				Obj(), resourceFlavors)
+			wl := utiltesting.MakeWorkload("wl", "ns").Request(corev1.ResourceCPU, tc.request).Obj()
+			assignment := AssignFlavorsExcluding(log, workload.NewInfo(wl), resourceFlavors, cq, tc.exclude)
+			if repMode := assignment.RepresentativeMode(); repMode != tc.wantRepMode {
+				t.Errorf("AssignFlavorsExcluding(_).RepresentativeMode()=%s, want %s", repMode, tc.wantRepMode)
+			}
+			if tc.wantRepMode == Fit {
+				if diff := cmp.Diff(tc.wantFlavors, assignment.PodSets[0].Flavors, cmpopts.IgnoreUnexported(FlavorAssignment{})); diff != "" {
+					t.Errorf("Unexpected flavors (-want,+got):\n%s", diff)
+				}
+			}
+			if tc.wantMessage != "" {
+				if msg := assignment.Message(); msg != tc.wantMessage {
+					t.Errorf("AssignFlavorsExcluding(_).Message()=%q, want %q", msg, tc.wantMessage)
+				}
+			}
+			if got := len(cq.RGByResource[corev1.ResourceCPU].Flavors); got != 2 {
+				t.Errorf("ClusterQueue has %d flavors for cpu after the assignment, want 2", got)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsFairSharing(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"default": utiltesting.MakeResourceFlavor("default").Obj(),
+	}
+	cases := map[string]struct {
+		request     string
+		fairSharing bool
+		wantRepMode FlavorAssignmentMode
+		wantMessage string
+	}{
+		"borrowing within fair share": {
+			request:     "3",
+			fairSharing: true,
+			wantRepMode: Fit,
+		},
+		"borrowing beyond fair share": {
+			request:     "4",
+			fairSharing: true,
+			wantRepMode: Preempt,
+			wantMessage: "couldn't assign flavors to pod set main: borrowing cpu in flavor default exceeds the fair share of the ClusterQueue",
+		},
+		"borrowing beyond fair share without fair sharing": {
+			request:     "4",
+			wantRepMode: Fit,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			cqA := cachetesting.NewClusterQueueFromAPI(utiltesting.MakeClusterQueue("a").
+				ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "1").Obj()).
+				Obj(), resourceFlavors)
+			cqB := cachetesting.NewClusterQueueFromAPI(utiltesting.MakeClusterQueue("b").
+				ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, "7").Obj()).
+				Obj(), resourceFlavors)
+			cqA.Usage["default"][corev1.ResourceCPU] = 1_000
+			cohortWrapper := cachetesting.MakeCohort("cohort").
+				Requestable("default", corev1.ResourceCPU, "8").
+				Usage("default", corev1.ResourceCPU, "1")
+			if tc.fairSharing {
+				cohortWrapper.FairSharing()
+			}
+			cohort := cohortWrapper.Obj()
+			cohort.Members.Insert(cqA, cqB)
+			cqA.Cohort, cqB.Cohort = cohort, cohort
+
+			wl := utiltesting.MakeWorkload("wl", "ns").Request(corev1.ResourceCPU, tc.request).Obj()
+			assignment := AssignFlavors(log, workload.NewInfo(wl), resourceFlavors, cqA)
+			if repMode := assignment.RepresentativeMode(); repMode != tc.wantRepMode {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, tc.wantRepMode)
+			}
+			if msg := assignment.Message(); msg != tc.wantMessage {
+				t.Errorf("AssignFlavors(_).Message()=%q, want %q", msg, tc.wantMessage)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsBorrowableFlavors(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"spot":      utiltesting.MakeResourceFlavor("spot").Obj(),
+		"on-demand": utiltesting.MakeResourceFlavor("on-demand").Obj(),
+	}
+	cases := map[string]struct {
+		onDemandUsageInB    string
+		restricted          bool
+		wantRepMode         FlavorAssignmentMode
+		wantFlavor          kueue.ResourceFlavorReference
+		wantMessage         string
+		wantRejectedFlavors []kueue.ResourceFlavorReference
+	}{
+		"borrowing in an allowed flavor": {
+			onDemandUsageInB: "0",
+			restricted:       true,
+			wantRepMode:      Fit,
+			wantFlavor:       "on-demand",
+		},
+		"only a disallowed flavor has room": {
+			onDemandUsageInB:    "4",
+			restricted:          true,
+			wantRepMode:         NoFit,
+			wantMessage:         "couldn't assign flavors to pod set main: borrowing cpu in flavor spot is not allowed for the ClusterQueue, insufficient unused quota in cohort for cpu in flavor on-demand, 2 more needed",
+			wantRejectedFlavors: []kueue.ResourceFlavorReference{"spot", "on-demand"},
+		},
+		"no restrictions": {
+			onDemandUsageInB: "4",
+			wantRepMode:      Fit,
+			wantFlavor:       "spot",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			cqAWrapper := utiltesting.MakeClusterQueue("a").
+				ResourceGroup(
+					*utiltesting.MakeFlavorQuotas("spot").Resource(corev1.ResourceCPU, "2").Obj(),
+					*utiltesting.MakeFlavorQuotas("on-demand").Resource(corev1.ResourceCPU, "2").Obj(),
+				)
+			if tc.restricted {
+				cqAWrapper.BorrowableFlavors(corev1.ResourceCPU, "on-demand")
+			}
+			cqA := cachetesting.NewClusterQueueFromAPI(cqAWrapper.Obj(), resourceFlavors)
+			cqB := cachetesting.NewClusterQueueFromAPI(utiltesting.MakeClusterQueue("b").
+				ResourceGroup(
+					*utiltesting.MakeFlavorQuotas("spot").Resource(corev1.ResourceCPU, "4").Obj(),
+					*utiltesting.MakeFlavorQuotas("on-demand").Resource(corev1.ResourceCPU, "4").Obj(),
+				).
+				Obj(), resourceFlavors)
+			cohort := cachetesting.MakeCohort("cohort").
+				Requestable("spot", corev1.ResourceCPU, "6").
+				Requestable("on-demand", corev1.ResourceCPU, "6").
+				Usage("on-demand", corev1.ResourceCPU, tc.onDemandUsageInB).
+				Obj()
+			cohort.Members.Insert(cqA, cqB)
+			cqA.Cohort, cqB.Cohort = cohort, cohort
+
+			wl := utiltesting.MakeWorkload("wl", "ns").Request(corev1.ResourceCPU, "4").Obj()
+			assignment := AssignFlavors(log, workload.NewInfo(wl), resourceFlavors, cqA)
+			if repMode := assignment.RepresentativeMode(); repMode != tc.wantRepMode {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, tc.wantRepMode)
+			}
+			if msg := assignment.Message(); msg != tc.wantMessage {
+				t.Errorf("AssignFlavors(_).Message()=%q, want %q", msg, tc.wantMessage)
+			}
+			if tc.wantFlavor != "" {
+				if got := assignment.PodSets[0].Flavors[corev1.ResourceCPU].Name; got != tc.wantFlavor {
+					t.Errorf("Assigned flavor %s, want %s", got, tc.wantFlavor)
+				}
+			}
+			for _, fName := range tc.wantRejectedFlavors {
+				ExpectRejection(t, assignment.PodSets[0].Status, InsufficientQuota, fName)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsCohortCaps(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"default": utiltesting.MakeResourceFlavor("default").Obj(),
+	}
+	cases := map[string]struct {
+		request     string
+		cap         string
+		wantRepMode FlavorAssignmentMode
+		wantMessage string
+	}{
+		"no cap": {
+			request:     "3",
+			wantRepMode: Fit,
+		},
+		"within the cap": {
+			request:     "2",
+			cap:         "6",
+			wantRepMode: Fit,
+		},
+		"cap below the nominal sum": {
+			request:     "3",
+			cap:         "6",
+			wantRepMode: Preempt,
+			wantMessage: "couldn't assign flavors to pod set main: insufficient unused quota in cohort for example.com/gpu in flavor default, need to reclaim 1 from cohort",
+		},
+		"cap above the nominal sum": {
+			request:     "3",
+			cap:         "10",
+			wantRepMode: Fit,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			cqA := cachetesting.NewClusterQueueFromAPI(utiltesting.MakeClusterQueue("a").
+				ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource("example.com/gpu", "4").Obj()).
+				Obj(), resourceFlavors)
+			cqB := cachetesting.NewClusterQueueFromAPI(utiltesting.MakeClusterQueue("b").
+				ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource("example.com/gpu", "4").Obj()).
+				Obj(), resourceFlavors)
+			cohortWrapper := cachetesting.MakeCohort("cohort").
+				Requestable("default", "example.com/gpu", "8").
+				Usage("default", "example.com/gpu", "4")
+			if tc.cap != "" {
+				cohortWrapper.Cap("default", "example.com/gpu", tc.cap)
+			}
+			cohort := cohortWrapper.Obj()
+			cohort.Members.Insert(cqA, cqB)
+			cqA.Cohort, cqB.Cohort = cohort, cohort
+
+			wl := utiltesting.MakeWorkload("wl", "ns").Request("example.com/gpu", tc.request).Obj()
+			assignment := AssignFlavors(log, workload.NewInfo(wl), resourceFlavors, cqA)
+			if repMode := assignment.RepresentativeMode(); repMode != tc.wantRepMode {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, tc.wantRepMode)
+			}
+			if msg := assignment.Message(); msg != tc.wantMessage {
+				t.Errorf("AssignFlavors(_).Message()=%q, want %q", msg, tc.wantMessage)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsClassShares(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"one": utiltesting.MakeResourceFlavor("one").Obj(),
+	}
+	cases := map[string]struct {
+		class       string
+		request     string
+		wantRepMode FlavorAssignmentMode
+		wantMessage string
+	}{
+		"class within its share": {
+			class:       "inference",
+			request:     "1",
+			wantRepMode: Fit,
+		},
+		"class exceeding its share while shared quota remains": {
+			class:       "inference",
+			request:     "2",
+			wantRepMode: Fit,
+		},
+		"class exceeding its share and the shared quota": {
+			class:       "inference",
+			request:     "5",
+			wantRepMode: Preempt,
+			wantMessage: "couldn't assign flavors to pod set main: insufficient unused quota for cpu in flavor one, need to preempt 1 in ClusterQueue",
+		},
+		"class using its own share": {
+			class:       "training",
+			request:     "6",
+			wantRepMode: Fit,
+		},
+		"no class, blocked by the unused shares of the classes": {
+			request:     "4",
+			wantRepMode: Preempt,
+			wantMessage: "couldn't assign flavors to pod set main: insufficient unused quota for cpu in flavor one, need to preempt 1 in ClusterQueue",
+		},
+		"no class": {
+			request:     "3",
+			wantRepMode: Fit,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			// 3 CPUs are reserved for inference, of which 2 are used, and 4 for
+			// training, leaving 3 shared.
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 10_000},
+						},
+						ClassShares: map[string]float64{"inference": 0.3, "training": 0.4},
+					}},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"one": {corev1.ResourceCPU: 2_000},
+				},
+				UsageByClass: map[string]cache.FlavorResourceQuantities{
+					"inference": {"one": {corev1.ResourceCPU: 2_000}},
+				},
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			wl := utiltesting.MakeWorkload("wl", "ns").Request(corev1.ResourceCPU, tc.request)
+			if tc.class != "" {
+				wl.Label(kueue.WorkloadClassLabel, tc.class)
+			}
+			assignment := AssignFlavors(log, workload.NewInfo(wl.Obj()), resourceFlavors, &cq)
+			if repMode := assignment.RepresentativeMode(); repMode != tc.wantRepMode {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, tc.wantRepMode)
+			}
+			if msg := assignment.Message(); msg != tc.wantMessage {
+				t.Errorf("AssignFlavors(_).Message()=%q, want %q", msg, tc.wantMessage)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsBorrowingInfo(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"one": utiltesting.MakeResourceFlavor("one").Obj(),
+	}
+	cases := map[string]struct {
+		request   string
+		wantInfos []string
+	}{
+		"fit without borrowing": {
+			request: "2",
+		},
+		"fit with borrowing": {
+			request:   "6",
+			wantInfos: []string{"borrowing 2 of cpu in flavor one from the cohort"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 4_000},
+						},
+					}},
+				}},
+				Cohort: cachetesting.MakeCohort("cohort").
+					Requestable("one", corev1.ResourceCPU, "8").
+					Obj(),
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			wlInfo := workload.NewInfo(utiltesting.MakeWorkload("wl", "ns").Request(corev1.ResourceCPU, tc.request).Obj())
+			assignment := AssignFlavors(log, wlInfo, resourceFlavors, &cq)
+			if repMode := assignment.RepresentativeMode(); repMode != Fit {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, Fit)
+			}
+			if msg := assignment.Message(); msg != "" {
+				t.Errorf("AssignFlavors(_).Message()=%q, want empty", msg)
+			}
+			if diff := cmp.Diff(tc.wantInfos, assignment.PodSets[0].Status.Infos()); diff != "" {
+				t.Errorf("Unexpected infos (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsMinRequest(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"whole-node": utiltesting.MakeResourceFlavor("whole-node").MinRequest(corev1.ResourceCPU, "4").Obj(),
+		"shared":     utiltesting.MakeResourceFlavor("shared").Obj(),
+	}
+	cases := map[string]struct {
+		request        string
+		wantFlavor     kueue.ResourceFlavorReference
+		wantWhyNotNode string
+	}{
+		"below minimum": {
+			request:        "3",
+			wantFlavor:     "shared",
+			wantWhyNotNode: "request below flavor minimum for cpu in flavor whole-node",
+		},
+		"at minimum": {
+			request:    "4",
+			wantFlavor: "whole-node",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			wl := utiltesting.MakeWorkload("wl", "ns").
+				PodSets(*utiltesting.MakePodSet("main", 2).
+					Request(corev1.ResourceCPU, tc.request).
+					Obj()).
+				Obj()
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{
+						{
+							Name: "whole-node",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 16_000},
+							},
+						},
+						{
+							Name: "shared",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 16_000},
+							},
+						},
+					},
+				}},
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			assignment := AssignFlavors(log, workload.NewInfo(wl), resourceFlavors, &cq)
+			if repMode := assignment.RepresentativeMode(); repMode != Fit {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, Fit)
+			}
+			if got := assignment.PodSets[0].Flavors[corev1.ResourceCPU].Name; got != tc.wantFlavor {
+				t.Errorf("Assigned flavor %s, want %s", got, tc.wantFlavor)
+			}
+			if got := assignment.WhyNotFlavor("main", "whole-node"); got != tc.wantWhyNotNode {
+				t.Errorf("WhyNotFlavor(main, whole-node)=%q, want %q", got, tc.wantWhyNotNode)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsUnschedulable(t *testing.T) {
+	cases := map[string]struct {
+		resourceFlavors map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor
+		wantRepMode     FlavorAssignmentMode
+		wantFlavor      kueue.ResourceFlavorReference
+		wantReason      string
+		wantRejected    []kueue.ResourceFlavorReference
+	}{
+		"schedulable alternative": {
+			resourceFlavors: map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+				"cordoned":    utiltesting.MakeResourceFlavor("cordoned").Unschedulable().Obj(),
+				"schedulable": utiltesting.MakeResourceFlavor("schedulable").Obj(),
+			},
+			wantRepMode: Fit,
+			wantFlavor:  "schedulable",
+		},
+		"no schedulable flavor": {
+			resourceFlavors: map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+				"cordoned":    utiltesting.MakeResourceFlavor("cordoned").Unschedulable().Obj(),
+				"schedulable": utiltesting.MakeResourceFlavor("schedulable").Unschedulable().Obj(),
+			},
+			wantRepMode:  NoFit,
+			wantReason:   string(FlavorUnschedulable),
+			wantRejected: []kueue.ResourceFlavorReference{"cordoned", "schedulable"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			wl := utiltesting.MakeWorkload("wl", "ns").Request(corev1.ResourceCPU, "1").Obj()
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{
+						{
+							Name: "cordoned",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 4_000},
+							},
+						},
+						{
+							Name: "schedulable",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 4_000},
+							},
+						},
+					},
+				}},
+			}
+			cq.UpdateWithFlavors(tc.resourceFlavors)
+			cq.UpdateRGByResource()
+			assignment := AssignFlavors(log, workload.NewInfo(wl), tc.resourceFlavors, &cq)
+			if repMode := assignment.RepresentativeMode(); repMode != tc.wantRepMode {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, tc.wantRepMode)
+			}
+			if tc.wantFlavor != "" {
+				if got := assignment.PodSets[0].Flavors[corev1.ResourceCPU].Name; got != tc.wantFlavor {
+					t.Errorf("Assigned flavor %s, want %s", got, tc.wantFlavor)
+				}
+			}
+			if got, want := assignment.WhyNotFlavor("main", "cordoned"), "flavor cordoned is unschedulable"; got != want {
+				t.Errorf("WhyNotFlavor(main, cordoned)=%q, want %q", got, want)
+			}
+			if tc.wantReason != "" {
+				if got := assignment.ToConditions()[0].Reason; got != tc.wantReason {
+					t.Errorf("Condition reason %s, want %s", got, tc.wantReason)
+				}
+			}
+			for _, fName := range tc.wantRejected {
+				ExpectRejection(t, assignment.PodSets[0].Status, FlavorUnschedulable, fName)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsReplicaGroups(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"one": utiltesting.MakeResourceFlavor("one").Obj(),
+		"two": utiltesting.MakeResourceFlavor("two").Obj(),
+	}
+	cases := map[string]struct {
+		replicaGroup string
+		wantFlavors  map[string]kueue.ResourceFlavorReference
+	}{
+		"no replica group": {
+			wantFlavors: map[string]kueue.ResourceFlavorReference{
+				"rank-0": "one",
+				"rank-1": "one",
+				"rank-2": "two",
+			},
+		},
+		"same replica group": {
+			replicaGroup: "ranks",
+			wantFlavors: map[string]kueue.ResourceFlavorReference{
+				"rank-0": "two",
+				"rank-1": "two",
+				"rank-2": "two",
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			podSets := make([]kueue.PodSet, 3)
+			for i := range podSets {
+				podSets[i] = *utiltesting.MakePodSet(fmt.Sprintf("rank-%d", i), 1).
+					Request(corev1.ResourceCPU, "2").
+					ReplicaGroup(tc.replicaGroup).
+					Obj()
+			}
+			wl := utiltesting.MakeWorkload("wl", "ns").PodSets(podSets...).Obj()
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{
+						{
+							Name: "one",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 5_000},
+							},
+						},
+						{
+							Name: "two",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 8_000},
+							},
+						},
+					},
+				}},
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			assignment := AssignFlavors(log, workload.NewInfo(wl), resourceFlavors, &cq)
+			if repMode := assignment.RepresentativeMode(); repMode != Fit {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, Fit)
+			}
+			gotFlavors := make(map[string]kueue.ResourceFlavorReference, len(assignment.PodSets))
+			for _, psa := range assignment.PodSets {
+				gotFlavors[psa.Name] = psa.Flavors[corev1.ResourceCPU].Name
+			}
+			if diff := cmp.Diff(tc.wantFlavors, gotFlavors); diff != "" {
+				t.Errorf("Unexpected flavors (-want,+got):\n%s", diff)
+			}
+			wantUsage := make(cache.FlavorResourceQuantities)
+			for _, fName := range tc.wantFlavors {
+				if wantUsage[fName] == nil {
+					wantUsage[fName] = make(map[corev1.ResourceName]int64)
+				}
+				wantUsage[fName][corev1.ResourceCPU] += 2_000
+			}
+			if diff := cmp.Diff(wantUsage, assignment.Usage()); diff != "" {
+				t.Errorf("Unexpected usage (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsFlavorSelectionPolicy(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"one": utiltesting.MakeResourceFlavor("one").Obj(),
+		"two": utiltesting.MakeResourceFlavor("two").Obj(),
+	}
+	cases := map[string]struct {
+		policy     kueue.FlavorSelectionPolicy
+		usage      cache.FlavorResourceQuantities
+		wantFlavor kueue.ResourceFlavorReference
+	}{
+		"declaration order": {
+			usage: cache.FlavorResourceQuantities{
+				"one": {corev1.ResourceCPU: 3_000},
+				"two": {corev1.ResourceCPU: 1_000},
+			},
+			wantFlavor: "one",
+		},
+		"explicit declaration order": {
+			policy: kueue.DeclarationOrder,
+			usage: cache.FlavorResourceQuantities{
+				"one": {corev1.ResourceCPU: 3_000},
+				"two": {corev1.ResourceCPU: 1_000},
+			},
+			wantFlavor: "one",
+		},
+		"most free picks the emptier flavor": {
+			policy: kueue.MostFree,
+			usage: cache.FlavorResourceQuantities{
+				"one": {corev1.ResourceCPU: 3_000},
+				"two": {corev1.ResourceCPU: 1_000},
+			},
+			wantFlavor: "two",
+		},
+		"most free considers the nominal quota": {
+			policy: kueue.MostFree,
+			usage: cache.FlavorResourceQuantities{
+				"one": {corev1.ResourceCPU: 3_000},
+				"two": {corev1.ResourceCPU: 3_000},
+			},
+			wantFlavor: "two",
+		},
+		"most free keeps the order on ties": {
+			policy: kueue.MostFree,
+			usage: cache.FlavorResourceQuantities{
+				"two": {corev1.ResourceCPU: 1_000},
+			},
+			wantFlavor: "one",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			wl := utiltesting.MakeWorkload("wl", "ns").Request(corev1.ResourceCPU, "1").Obj()
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{
+						{
+							Name: "one",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 4_000},
+							},
+						},
+						{
+							Name: "two",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 8_000},
+							},
+						},
+					},
+				}},
+				Usage:                 tc.usage,
+				FlavorSelectionPolicy: tc.policy,
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			assignment := AssignFlavors(log, workload.NewInfo(wl), resourceFlavors, &cq)
+			if repMode := assignment.RepresentativeMode(); repMode != Fit {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, Fit)
+			}
+			if got := assignment.PodSets[0].Flavors[corev1.ResourceCPU].Name; got != tc.wantFlavor {
+				t.Errorf("Assigned flavor %s, want %s", got, tc.wantFlavor)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsZeroRequests(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"default": utiltesting.MakeResourceFlavor("default").Obj(),
+		"gpu":     utiltesting.MakeResourceFlavor("gpu").Obj(),
+	}
+	gpuGroup := cache.ResourceGroup{
+		CoveredResources: sets.New[corev1.ResourceName]("example.com/gpu"),
+		Flavors: []cache.FlavorQuotas{{
+			Name: "gpu",
+			Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+				"example.com/gpu": {Nominal: 4},
+			},
+		}},
+	}
+	cpuGroup := cache.ResourceGroup{
+		CoveredResources: sets.New(corev1.ResourceCPU),
+		Flavors: []cache.FlavorQuotas{{
+			Name: "default",
+			Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+				corev1.ResourceCPU: {Nominal: 1_000},
+			},
+		}},
+	}
+	cases := map[string]struct {
+		resourceGroups []cache.ResourceGroup
+		cpuUsage       int64
+	}{
+		"no resource group for cpu": {
+			resourceGroups: []cache.ResourceGroup{gpuGroup},
+		},
+		"cpu quota used up": {
+			resourceGroups: []cache.ResourceGroup{cpuGroup, gpuGroup},
+			cpuUsage:       1_000,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			wl := utiltesting.MakeWorkload("wl", "ns").
+				Request(corev1.ResourceCPU, "0").
+				Request("example.com/gpu", "1").
+				Obj()
+			cq := cache.ClusterQueue{
+				ResourceGroups: tc.resourceGroups,
+				Usage: cache.FlavorResourceQuantities{
+					"default": {corev1.ResourceCPU: tc.cpuUsage},
+				},
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			assignment := AssignFlavors(log, workload.NewInfo(wl), resourceFlavors, &cq)
+			if repMode := assignment.RepresentativeMode(); repMode != Fit {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s (%s)", repMode, Fit, assignment.Message())
+			}
+			wantFlavors := ResourceAssignment{
+				"example.com/gpu": {Name: "gpu", Mode: Fit},
+			}
+			if diff := cmp.Diff(wantFlavors, assignment.PodSets[0].Flavors, cmpopts.IgnoreUnexported(FlavorAssignment{})); diff != "" {
+				t.Errorf("Unexpected flavors (-want,+got):\n%s", diff)
+			}
+			wantUsage := cache.FlavorResourceQuantities{
+				"gpu": {"example.com/gpu": 1},
+			}
+			if diff := cmp.Diff(wantUsage, assignment.Usage()); diff != "" {
+				t.Errorf("Unexpected usage (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsPodCount(t *testing.T) {
+	log := testr.NewWithOptions(t, testr.Options{
+		Verbosity: 2,
+	})
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"default": utiltesting.MakeResourceFlavor("default").Obj(),
+	}
+	wlInfo := workload.NewInfo(&kueue.Workload{
+		Spec: kueue.WorkloadSpec{
+			PodSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("driver", 1).
+					Request(corev1.ResourceCPU, "1").
+					Obj(),
+				*utiltesting.MakePodSet("workers", 4).
+					Request(corev1.ResourceCPU, "1").
+					Obj(),
+			},
+		},
+	})
+	cq := cache.ClusterQueue{
+		ResourceGroups: []cache.ResourceGroup{{
+			CoveredResources: sets.New(corev1.ResourceCPU),
+			Flavors: []cache.FlavorQuotas{{
+				Name: "default",
+				Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+					corev1.ResourceCPU: {Nominal: 10_000},
+				},
+			}},
+		}},
+	}
+	cq.UpdateWithFlavors(resourceFlavors)
+	cq.UpdateRGByResource()
+	assignment := AssignFlavors(log, wlInfo, resourceFlavors, &cq)
+	wantCounts := map[string]int32{
+		"driver":  1,
+		"workers": 4,
+	}
+	gotCounts := make(map[string]int32, len(assignment.PodSets))
+	for i := range assignment.PodSets {
+		ps := &assignment.PodSets[i]
+		gotCounts[ps.Name] = ps.Count()
+		if _, found := ps.Requests[corev1.ResourcePods]; found {
+			t.Errorf("Pod set %s requests include %s, but the ClusterQueue doesn't track it", ps.Name, corev1.ResourcePods)
+		}
+	}
+	if diff := cmp.Diff(wantCounts, gotCounts); diff != "" {
+		t.Errorf("Unexpected pod counts (-want,+got):\n%s", diff)
+	}
+}
+
+// TestAssignFlavorsPodSetOrder checks that the pod sets of the assignment keep
+// the order of the pod sets in the workload spec, as ToAPI relies on it.
+func TestAssignFlavorsPodSetOrder(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"default": utiltesting.MakeResourceFlavor("default").Obj(),
+	}
+	podSets := []kueue.PodSet{
+		*utiltesting.MakePodSet("workers", 4).
+			Request(corev1.ResourceCPU, "1").
+			Obj(),
+		*utiltesting.MakePodSet("driver", 1).
+			Request(corev1.ResourceCPU, "2").
+			Obj(),
+		*utiltesting.MakePodSet("aux", 1).
+			Request(corev1.ResourceCPU, "1").
+			Obj(),
+	}
+	cases := map[string]struct {
+		nominal      int64
+		usage        int64
+		wantRepMode  FlavorAssignmentMode
+		wantPodSets  []string
+		wantFailures []string
+	}{
+		"all pod sets fit": {
+			nominal:     10_000,
+			wantRepMode: Fit,
+			wantPodSets: []string{"workers", "driver", "aux"},
+		},
+		"later pod sets need preemption": {
+			nominal:      8_000,
+			usage:        3_000,
+			wantRepMode:  Preempt,
+			wantPodSets:  []string{"workers", "driver", "aux"},
+			wantFailures: []string{"driver", "aux"},
+		},
+		"first pod set needs preemption": {
+			nominal:      8_000,
+			usage:        6_000,
+			wantRepMode:  Preempt,
+			wantPodSets:  []string{"workers", "driver", "aux"},
+			wantFailures: []string{"workers", "driver", "aux"},
+		},
+		"evaluation stops at the pod set that doesn't fit": {
+			nominal:      5_000,
+			wantRepMode:  NoFit,
+			wantPodSets:  []string{"workers", "driver"},
+			wantFailures: []string{"driver"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			wl := utiltesting.MakeWorkload("wl", "ns").PodSets(podSets...).Obj()
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: tc.nominal},
+						},
+					}},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"default": {corev1.ResourceCPU: tc.usage},
+				},
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			assignment := AssignFlavors(log, workload.NewInfo(wl), resourceFlavors, &cq)
+			if repMode := assignment.RepresentativeMode(); repMode != tc.wantRepMode {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, tc.wantRepMode)
+			}
+			var gotPodSets, gotFailures []string
+			for i, psa := range assignment.PodSets {
+				if specName := wl.Spec.PodSets[i].Name; psa.Name != specName {
+					t.Errorf("Pod set %d of the assignment is %s, want %s", i, psa.Name, specName)
+				}
+				gotPodSets = append(gotPodSets, psa.Name)
+				if psa.Status != nil {
+					gotFailures = append(gotFailures, psa.Name)
+				}
+			}
+			if diff := cmp.Diff(tc.wantPodSets, gotPodSets); diff != "" {
+				t.Errorf("Unexpected pod sets (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantFailures, gotFailures); diff != "" {
+				t.Errorf("Unexpected failed pod sets (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAssignFlavorsReservationOnly(t *testing.T) {
+	log := testr.NewWithOptions(t, testr.Options{
+		Verbosity: 2,
+	})
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"default": utiltesting.MakeResourceFlavor("default").Obj(),
+	}
+	wl := utiltesting.MakeWorkload("placeholder", "ns").
+		ReservationOnly().
+		PodSets(*utiltesting.MakePodSet("main", 4).
+			Request(corev1.ResourceCPU, "1").
+			Obj()).
+		Obj()
+	cq := cache.ClusterQueue{
+		ResourceGroups: []cache.ResourceGroup{{
+			CoveredResources: sets.New(corev1.ResourceCPU),
+			Flavors: []cache.FlavorQuotas{{
+				Name: "default",
+				Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+					corev1.ResourceCPU: {Nominal: 4_000},
+				},
+			}},
+		}},
+	}
+	cq.UpdateWithFlavors(resourceFlavors)
+	cq.UpdateRGByResource()
+	assignment := AssignFlavors(log, workload.NewInfo(wl), resourceFlavors, &cq)
+	if repMode := assignment.RepresentativeMode(); repMode != Fit {
+		t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, Fit)
+	}
+	wantUsage := cache.FlavorResourceQuantities{
+		"default": {corev1.ResourceCPU: 4_000},
+	}
+	if diff := cmp.Diff(wantUsage, assignment.Usage()); diff != "" {
+		t.Errorf("Unexpected usage (-want,+got):\n%s", diff)
+	}
+}
+
+func TestAssignFlavorsPartialAdmission(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"default": utiltesting.MakeResourceFlavor("default").Obj(),
+	}
+	cases := map[string]struct {
+		podSets     []kueue.PodSet
+		nominal     int64
+		usage       int64
+		wantRepMode FlavorAssignmentMode
+		wantCounts  map[string]int32
+	}{
+		"full count fits": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 300).MinCount(100).Request(corev1.ResourceCPU, "1").Obj(),
+			},
+			nominal:     437_000,
+			wantRepMode: Fit,
+			wantCounts:  map[string]int32{"main": 300},
+		},
+		"largest count that fits": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1000).MinCount(100).Request(corev1.ResourceCPU, "1").Obj(),
+			},
+			nominal:     437_000,
+			wantRepMode: Fit,
+			wantCounts:  map[string]int32{"main": 437},
+		},
+		"largest count that needs preemption": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1000).MinCount(100).Request(corev1.ResourceCPU, "1").Obj(),
+			},
+			nominal:     437_000,
+			usage:       100_000,
+			wantRepMode: Preempt,
+			wantCounts:  map[string]int32{"main": 437},
+		},
+		"min count doesn't fit": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1000).MinCount(100).Request(corev1.ResourceCPU, "5").Obj(),
+			},
+			nominal:     437_000,
+			wantRepMode: NoFit,
+			wantCounts:  map[string]int32{"main": 1000},
+		},
+		"without min count": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1000).Request(corev1.ResourceCPU, "1").Obj(),
+			},
+			nominal:     437_000,
+			wantRepMode: NoFit,
+			wantCounts:  map[string]int32{"main": 1000},
+		},
+		"pod sets reduced proportionally": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("driver", 1).Request(corev1.ResourceCPU, "1").Obj(),
+				*utiltesting.MakePodSet("workers", 1000).MinCount(100).Request(corev1.ResourceCPU, "1").Obj(),
+				*utiltesting.MakePodSet("ps", 100).MinCount(10).Request(corev1.ResourceCPU, "1").Obj(),
+			},
+			nominal:     551_000,
+			wantRepMode: Fit,
+			wantCounts:  map[string]int32{"driver": 1, "workers": 500, "ps": 50},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			wlInfo := workload.NewInfo(&kueue.Workload{
+				Spec: kueue.WorkloadSpec{
+					PodSets: tc.podSets,
+				},
+			})
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: tc.nominal},
+						},
+					}},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"default": {corev1.ResourceCPU: tc.usage},
+				},
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			assignment := AssignFlavors(log, wlInfo, resourceFlavors, &cq)
+			if repMode := assignment.RepresentativeMode(); repMode != tc.wantRepMode {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, tc.wantRepMode)
+			}
+			gotCounts := make(map[string]int32, len(assignment.PodSets))
+			for i := range assignment.PodSets {
+				ps := &assignment.PodSets[i]
+				gotCounts[ps.Name] = ps.Count()
+				if tc.wantRepMode != NoFit {
+					wantCPU := resource.NewMilliQuantity(int64(ps.Count())*wlInfo.TotalRequests[i].Requests[corev1.ResourceCPU]/int64(tc.podSets[i].Count), resource.DecimalSI)
+					if got := ps.Requests[corev1.ResourceCPU]; got.Cmp(*wantCPU) != 0 {
+						t.Errorf("Pod set %s requests %s cpu, want %s", ps.Name, got.String(), wantCPU.String())
+					}
+				}
+			}
+			if diff := cmp.Diff(tc.wantCounts, gotCounts); diff != "" {
+				t.Errorf("Unexpected pod counts (-want,+got):\n%s", diff)
+			}
+			if tc.wantRepMode != NoFit {
+				gotAPICounts := make(map[string]int32, len(assignment.PodSets))
+				for _, psa := range assignment.ToAPI() {
+					gotAPICounts[psa.Name] = *psa.Count
+				}
+				if diff := cmp.Diff(tc.wantCounts, gotAPICounts); diff != "" {
+					t.Errorf("Unexpected admitted pod counts (-want,+got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkAssignFlavorsPartialAdmission(b *testing.B) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"default": utiltesting.MakeResourceFlavor("default").Obj(),
+	}
+	cq := cache.ClusterQueue{
+		ResourceGroups: []cache.ResourceGroup{{
+			CoveredResources: sets.New(corev1.ResourceCPU),
+			Flavors: []cache.FlavorQuotas{{
+				Name: "default",
+				Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+					corev1.ResourceCPU: {Nominal: 437_000},
+				},
+			}},
+		}},
+		Usage: make(cache.FlavorResourceQuantities),
+	}
+	cq.UpdateWithFlavors(resourceFlavors)
+	cq.UpdateRGByResource()
+	wlInfo := workload.NewInfo(&kueue.Workload{
+		Spec: kueue.WorkloadSpec{
+			PodSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1000).
+					MinCount(100).
+					Request(corev1.ResourceCPU, "1").
+					Obj(),
+			},
+		},
+	})
+	log := logr.Discard()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		AssignFlavors(log, wlInfo, resourceFlavors, &cq)
+	}
+}
+
+func TestQuickReject(t *testing.T) {
+	cq := &cache.ClusterQueue{
+		ResourceGroups: []cache.ResourceGroup{
+			{
+				CoveredResources: sets.New(corev1.ResourceCPU, corev1.ResourceMemory),
+				Flavors:          []cache.FlavorQuotas{{Name: "default"}},
+			},
+			{
+				CoveredResources: sets.New[corev1.ResourceName]("example.com/gpu"),
+				Flavors:          []cache.FlavorQuotas{{Name: "gpu"}},
+			},
+		},
+	}
+	cq.UpdateRGByResource()
+	cases := map[string]struct {
+		podSets      []kueue.PodSet
+		wantResource corev1.ResourceName
+		wantReject   bool
+	}{
+		"all resources available": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("driver", 1).
+					Request(corev1.ResourceCPU, "1").
+					Request(corev1.ResourceMemory, "1Gi").
+					Obj(),
+				*utiltesting.MakePodSet("workers", 4).
+					Request(corev1.ResourceCPU, "1").
+					Request("example.com/gpu", "1").
+					Obj(),
+			},
+		},
+		"unavailable resource": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("driver", 1).
+					Request(corev1.ResourceCPU, "1").
+					Obj(),
+				*utiltesting.MakePodSet("workers", 4).
+					Request(corev1.ResourceCPU, "1").
+					Request("example.com/tpu", "1").
+					Request("example.com/fpga", "1").
+					Obj(),
+			},
+			wantResource: "example.com/fpga",
+			wantReject:   true,
+		},
+		"zero request of an unavailable resource": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request("example.com/gpu", "1").
+					Request("example.com/tpu", "0").
+					Obj(),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			wl := utiltesting.MakeWorkload("wl", "ns").PodSets(tc.podSets...).Obj()
+			rName, reject := QuickReject(workload.NewInfo(wl), cq)
+			if rName != tc.wantResource || reject != tc.wantReject {
+				t.Errorf("QuickReject()=(%s, %t), want (%s, %t)", rName, reject, tc.wantResource, tc.wantReject)
+			}
+		})
+	}
+}
+
+func TestEffectiveBorrowingLimit(t *testing.T) {
+	cases := map[string]struct {
+		rQuota          *cache.ResourceQuota
+		cohortAvailable int64
+		nominal         int64
+		want            int64
+	}{
+		"nil borrowing limit is capped by the cohort": {
+			rQuota:          &cache.ResourceQuota{Nominal: 2_000},
+			cohortAvailable: 10_000,
+			nominal:         2_000,
+			want:            8_000,
+		},
+		"nil borrowing limit, cohort smaller than nominal": {
+			rQuota:          &cache.ResourceQuota{Nominal: 2_000},
+			cohortAvailable: 1_000,
+			nominal:         2_000,
+			want:            0,
+		},
+		"explicit borrowing limit": {
+			rQuota: &cache.ResourceQuota{
+				Nominal:        2_000,
+				BorrowingLimit: pointer.Int64(3_000),
+			},
+			cohortAvailable: 10_000,
+			nominal:         2_000,
+			want:            3_000,
+		},
+		"explicit borrowing limit larger than the cohort": {
+			rQuota: &cache.ResourceQuota{
+				Nominal:        2_000,
+				BorrowingLimit: pointer.Int64(20_000),
+			},
+			cohortAvailable: 10_000,
+			nominal:         2_000,
+			want:            20_000,
+		},
+		"zero borrowing limit": {
+			rQuota: &cache.ResourceQuota{
+				Nominal:        2_000,
+				BorrowingLimit: pointer.Int64(0),
+			},
+			cohortAvailable: 10_000,
+			nominal:         2_000,
+			want:            0,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := EffectiveBorrowingLimit(tc.rQuota, tc.cohortAvailable, tc.nominal)
+			if got != tc.want {
+				t.Errorf("EffectiveBorrowingLimit(_, %d, %d)=%d, want %d", tc.cohortAvailable, tc.nominal, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCurrentBorrow(t *testing.T) {
+	cases := map[string]struct {
+		cq   cache.ClusterQueue
+		want cache.FlavorResourceQuantities
+	}{
+		"over nominal in one resource, within in another": {
+			cq: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{
+					{
+						CoveredResources: sets.New(corev1.ResourceCPU, corev1.ResourceMemory),
+						Flavors: []cache.FlavorQuotas{
+							{
+								Name: "one",
+								Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+									corev1.ResourceCPU:    {Nominal: 2_000},
+									corev1.ResourceMemory: {Nominal: 4 * utiltesting.Gi},
+								},
+							},
+							{
+								Name: "two",
+								Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+									corev1.ResourceCPU:    {Nominal: 2_000},
+									corev1.ResourceMemory: {Nominal: 4 * utiltesting.Gi},
+								},
+							},
+						},
+					},
+					{
+						CoveredResources: sets.New[corev1.ResourceName]("example.com/gpu"),
+						Flavors: []cache.FlavorQuotas{{
+							Name: "gpu",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								"example.com/gpu": {Nominal: 4},
+							},
+						}},
+					},
+				},
+				Usage: cache.FlavorResourceQuantities{
+					"one": {
+						corev1.ResourceCPU:    5_000,
+						corev1.ResourceMemory: 2 * utiltesting.Gi,
+					},
+					"two": {
+						corev1.ResourceCPU:    2_000,
+						corev1.ResourceMemory: 5 * utiltesting.Gi,
+					},
+					"gpu": {
+						"example.com/gpu": 1,
+					},
+				},
+			},
+			want: cache.FlavorResourceQuantities{
+				"one": {corev1.ResourceCPU: 3_000},
+				"two": {corev1.ResourceMemory: utiltesting.Gi},
+			},
+		},
+		"within nominal": {
+			cq: cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 2_000},
+						},
+					}},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"one": {corev1.ResourceCPU: 2_000},
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := CurrentBorrow(&tc.cq)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Unexpected borrow (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUsageDelta(t *testing.T) {
+	cases := map[string]struct {
+		old     *Assignment
+		new     *Assignment
+		wantAdd cache.FlavorResourceQuantities
+		wantSub cache.FlavorResourceQuantities
+	}{
+		"flavor change": {
+			old: &Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU:    {Name: "one", Mode: Fit},
+						corev1.ResourceMemory: {Name: "one", Mode: Fit},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("2"),
+						corev1.ResourceMemory: resource.MustParse("1Mi"),
+					},
+				}},
+			},
+			new: &Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU:    {Name: "two", Mode: Fit},
+						corev1.ResourceMemory: {Name: "one", Mode: Fit},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("2"),
+						corev1.ResourceMemory: resource.MustParse("1Mi"),
+					},
+				}},
+			},
+			wantAdd: cache.FlavorResourceQuantities{
+				"two": {corev1.ResourceCPU: 2_000},
+			},
+			wantSub: cache.FlavorResourceQuantities{
+				"one": {corev1.ResourceCPU: 2_000},
+			},
+		},
+		"count change": {
+			old: &Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU:  {Name: "one", Mode: Fit},
+						corev1.ResourcePods: {Name: "one", Mode: Fit},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:  resource.MustParse("4"),
+						corev1.ResourcePods: resource.MustParse("4"),
+					},
+					count: 4,
+				}},
+			},
+			new: &Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU:  {Name: "one", Mode: Fit},
+						corev1.ResourcePods: {Name: "one", Mode: Fit},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:  resource.MustParse("1"),
+						corev1.ResourcePods: resource.MustParse("1"),
+					},
+					count: 1,
+				}},
+			},
+			wantSub: cache.FlavorResourceQuantities{
+				"one": {
+					corev1.ResourceCPU:  3_000,
+					corev1.ResourcePods: 3,
+				},
+			},
+		},
+		"new assignment": {
+			new: &Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "one", Mode: Fit},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("1"),
+					},
+				}},
+			},
+			wantAdd: cache.FlavorResourceQuantities{
+				"one": {corev1.ResourceCPU: 1_000},
+			},
+		},
+		"no change": {
+			old: &Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "one", Mode: Fit},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("1"),
+					},
+				}},
+			},
+			new: &Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU: {Name: "one", Mode: Fit},
+					},
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("1"),
+					},
+				}},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			gotAdd, gotSub := UsageDelta(tc.old, tc.new)
+			if diff := cmp.Diff(tc.wantAdd, gotAdd); diff != "" {
+				t.Errorf("Unexpected added usage (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantSub, gotSub); diff != "" {
+				t.Errorf("Unexpected subtracted usage (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDetectSelectorConflict(t *testing.T) {
+	flavor := utiltesting.MakeResourceFlavor("two").
+		Label("type", "two").
+		Label("zone", "a").
+		Obj()
+	cases := map[string]struct {
+		nodeSelector map[string]string
+		wantErr      string
+	}{
+		"no selector": {},
+		"compatible selector": {
+			nodeSelector: map[string]string{
+				"type":  "two",
+				"other": "value",
+			},
+		},
+		"conflicting selector": {
+			nodeSelector: map[string]string{
+				"type": "one",
+				"zone": "a",
+			},
+			wantErr: "node selector conflicts with the labels of flavor two: type=one conflicts with type=two",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			spec := &corev1.PodSpec{NodeSelector: tc.nodeSelector}
+			err := DetectSelectorConflict(spec, flavor)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tc.wantErr {
+				t.Errorf("DetectSelectorConflict()=%q, want %q", gotErr, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchingFlavors(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"zone-a": utiltesting.MakeResourceFlavor("zone-a").Label("zone", "a").Obj(),
+		"zone-b": utiltesting.MakeResourceFlavor("zone-b").Label("zone", "b").Obj(),
+		"zone-c": utiltesting.MakeResourceFlavor("zone-c").Label("zone", "c").Obj(),
+		"spot-a": utiltesting.MakeResourceFlavor("spot-a").
+			Label("zone", "a").
+			Taint(corev1.Taint{
+				Key:    "instance",
+				Value:  "spot",
+				Effect: corev1.TaintEffectNoSchedule,
+			}).
+			Obj(),
+	}
+	rg := &cache.ResourceGroup{
+		CoveredResources: sets.New(corev1.ResourceCPU),
+		Flavors: []cache.FlavorQuotas{
+			{Name: "zone-a"},
+			{Name: "spot-a"},
+			{Name: "zone-b"},
+			{Name: "zone-c"},
+			{Name: "missing"},
+		},
+		LabelKeys: sets.New("zone"),
+	}
+	zoneAffinity := func(zones ...string) *corev1.Affinity {
+		return &corev1.Affinity{
+			NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchExpressions: []corev1.NodeSelectorRequirement{{
+							Key:      "zone",
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   zones,
+						}},
+					}},
+				},
+			},
+		}
+	}
+	cases := map[string]struct {
+		spec corev1.PodSpec
+		want []kueue.ResourceFlavorReference
+	}{
+		"no affinity": {
+			want: []kueue.ResourceFlavorReference{"zone-a", "zone-b", "zone-c"},
+		},
+		"affinity to a subset of zones": {
+			spec: corev1.PodSpec{
+				Affinity: zoneAffinity("a", "c"),
+			},
+			want: []kueue.ResourceFlavorReference{"zone-a", "zone-c"},
+		},
+		"affinity and toleration": {
+			spec: corev1.PodSpec{
+				Affinity: zoneAffinity("a"),
+				Tolerations: []corev1.Toleration{{
+					Key:      "instance",
+					Operator: corev1.TolerationOpEqual,
+					Value:    "spot",
+					Effect:   corev1.TaintEffectNoSchedule,
+				}},
+			},
+			want: []kueue.ResourceFlavorReference{"zone-a", "spot-a"},
+		},
+		"no flavor matches": {
+			spec: corev1.PodSpec{
+				NodeSelector: map[string]string{"zone": "d"},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := MatchingFlavors(&tc.spec, rg, resourceFlavors)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Unexpected flavors (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFormatQuantities(t *testing.T) {
+	cases := map[string]struct {
+		quantities cache.FlavorResourceQuantities
+		want       string
+	}{
+		"empty": {
+			want: "FLAVOR  RESOURCE  QUANTITY\n",
+		},
+		"multiple flavors": {
+			quantities: cache.FlavorResourceQuantities{
+				"spot": {
+					corev1.ResourceMemory: 2 * 1024 * 1024 * 1024,
+					corev1.ResourceCPU:    1_500,
+				},
+				"on-demand": {
+					"example.com/gpu":  2,
+					corev1.ResourceCPU: 4_000,
+				},
+			},
+			want: `FLAVOR     RESOURCE         QUANTITY
+on-demand  cpu              4
+on-demand  example.com/gpu  2
+spot       cpu              1500m
+spot       memory           2Gi
+`,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Map iteration order is random, the output must not depend on it.
+			for i := 0; i < 3; i++ {
+				if diff := cmp.Diff(tc.want, FormatQuantities(tc.quantities)); diff != "" {
+					t.Errorf("Unexpected table (-want,+got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestRemainingAfter(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"one": utiltesting.MakeResourceFlavor("one").Obj(),
+		"two": utiltesting.MakeResourceFlavor("two").Obj(),
+	}
+	cases := map[string]struct {
+		request string
+		want    cache.FlavorResourceQuantities
+	}{
+		"partially consumes the headroom": {
+			request: "1",
+			want: cache.FlavorResourceQuantities{
+				"one": {corev1.ResourceCPU: 2_000},
+				"two": {corev1.ResourceCPU: 4_000},
+			},
+		},
+		"fully consumes the headroom": {
+			request: "3",
+			want: cache.FlavorResourceQuantities{
+				"one": {corev1.ResourceCPU: 0},
+				"two": {corev1.ResourceCPU: 4_000},
+			},
+		},
+		"exceeds the headroom": {
+			request: "4",
+			want: cache.FlavorResourceQuantities{
+				"one": {corev1.ResourceCPU: 3_000},
+				"two": {corev1.ResourceCPU: 0},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{
+						{
+							Name: "one",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 4_000},
+							},
+						},
+						{
+							Name: "two",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 4_000},
+							},
+						},
+					},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"one": {corev1.ResourceCPU: 1_000},
+				},
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			wl := utiltesting.MakeWorkload("wl", "ns").Request(corev1.ResourceCPU, tc.request).Obj()
+			assignment := AssignFlavors(log, workload.NewInfo(wl), resourceFlavors, &cq)
+			if diff := cmp.Diff(tc.want, RemainingAfter(&cq, &assignment)); diff != "" {
+				t.Errorf("Unexpected remaining quota (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStructurallyFits(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"one": utiltesting.MakeResourceFlavor("one").Obj(),
+		"two": utiltesting.MakeResourceFlavor("two").Obj(),
+	}
+	cases := map[string]struct {
+		podSets []kueue.PodSet
+		cohort  *cache.Cohort
+		want    bool
+	}{
+		"within the capacity of a flavor": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "6").
+					Obj(),
+			},
+			want: true,
+		},
+		"exceeds the capacity of every flavor": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "9").
+					Obj(),
+			},
+		},
+		"pod sets fit across flavors": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("driver", 1).
+					Request(corev1.ResourceCPU, "4").
+					Obj(),
+				*utiltesting.MakePodSet("workers", 1).
+					Request(corev1.ResourceCPU, "8").
+					Obj(),
+			},
+			want: true,
+		},
+		"pod sets exceed the total capacity": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("driver", 1).
+					Request(corev1.ResourceCPU, "7").
+					Obj(),
+				*utiltesting.MakePodSet("workers", 1).
+					Request(corev1.ResourceCPU, "8").
+					Obj(),
+			},
+		},
+		"fits by borrowing": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "9").
+					Obj(),
+			},
+			cohort: cachetesting.MakeCohort("cohort").
+				Requestable("one", corev1.ResourceCPU, "12").
+				Requestable("two", corev1.ResourceCPU, "8").
+				Usage("one", corev1.ResourceCPU, "12").
+				Obj(),
+			want: true,
+		},
+		"resource unavailable": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request("example.com/gpu", "1").
+					Obj(),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{
+						{
+							Name: "one",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 6_000},
+							},
+						},
+						{
+							Name: "two",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 8_000},
+							},
+						},
+					},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"one": {corev1.ResourceCPU: 6_000},
+					"two": {corev1.ResourceCPU: 8_000},
+				},
+				Cohort: tc.cohort,
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			wl := utiltesting.MakeWorkload("wl", "ns").PodSets(tc.podSets...).Obj()
+			if got := StructurallyFits(workload.NewInfo(wl), &cq); got != tc.want {
+				t.Errorf("StructurallyFits()=%t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCohortFlows(t *testing.T) {
+	newCQ := func(name string, cpuUsage, memoryUsage int64) *cache.ClusterQueue {
+		return &cache.ClusterQueue{
+			Name: name,
+			ResourceGroups: []cache.ResourceGroup{{
+				CoveredResources: sets.New(corev1.ResourceCPU, corev1.ResourceMemory),
+				Flavors: []cache.FlavorQuotas{{
+					Name: "one",
+					Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+						corev1.ResourceCPU:    {Nominal: 4_000},
+						corev1.ResourceMemory: {Nominal: utiltesting.Gi * 4},
+					},
+				}},
+			}},
+			Usage: cache.FlavorResourceQuantities{
+				"one": {
+					corev1.ResourceCPU:    cpuUsage,
+					corev1.ResourceMemory: memoryUsage,
+				},
+			},
+		}
+	}
+	borrower := newCQ("borrower", 6_000, utiltesting.Gi*4)
+	lender := newCQ("lender", 2_000, utiltesting.Gi)
+	outsider := newCQ("outsider", 8_000, 0)
+	cohort := cachetesting.MakeCohort("cohort").Obj()
+	cohort.Members.Insert(borrower, lender)
+
+	borrowers, lenders := CohortFlows(cohort, []*cache.ClusterQueue{borrower, lender, outsider})
+	wantBorrowers := map[string]cache.FlavorResourceQuantities{
+		"borrower": {"one": {corev1.ResourceCPU: 2_000}},
+	}
+	if diff := cmp.Diff(wantBorrowers, borrowers); diff != "" {
+		t.Errorf("Unexpected borrowers (-want,+got):\n%s", diff)
+	}
+	wantLenders := map[string]cache.FlavorResourceQuantities{
+		"lender": {"one": {
+			corev1.ResourceCPU:    2_000,
+			corev1.ResourceMemory: utiltesting.Gi * 3,
+		}},
+	}
+	if diff := cmp.Diff(wantLenders, lenders); diff != "" {
+		t.Errorf("Unexpected lenders (-want,+got):\n%s", diff)
+	}
+}
+
+func TestUtilization(t *testing.T) {
+	cases := map[string]struct {
+		usage    int64
+		resource corev1.ResourceName
+		flavor   kueue.ResourceFlavorReference
+		ratio    *float64
+		want     float64
+	}{
+		"under nominal": {
+			usage:    1_000,
+			resource: corev1.ResourceCPU,
+			flavor:   "one",
+			want:     0.25,
+		},
+		"at nominal": {
+			usage:    4_000,
+			resource: corev1.ResourceCPU,
+			flavor:   "one",
+			want:     1,
+		},
+		"over nominal": {
+			usage:    6_000,
+			resource: corev1.ResourceCPU,
+			flavor:   "one",
+			want:     1.5,
+		},
+		"overcommitted nominal": {
+			usage:    6_000,
+			resource: corev1.ResourceCPU,
+			flavor:   "one",
+			ratio:    pointer.Float64(2),
+			want:     0.75,
+		},
+		"unknown flavor": {
+			usage:    1_000,
+			resource: corev1.ResourceCPU,
+			flavor:   "two",
+		},
+		"unknown resource": {
+			usage:    1_000,
+			resource: corev1.ResourceMemory,
+			flavor:   "one",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 4_000, OvercommitRatio: tc.ratio},
+						},
+					}},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					tc.flavor: {tc.resource: tc.usage},
+				},
+			}
+			if got := Utilization(&cq, tc.flavor, tc.resource); got != tc.want {
+				t.Errorf("Utilization()=%v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFairShare(t *testing.T) {
+	flavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"one": utiltesting.MakeResourceFlavor("one").Obj(),
+		"two": utiltesting.MakeResourceFlavor("two").Obj(),
+	}
+	cqA := cachetesting.NewClusterQueueFromAPI(utiltesting.MakeClusterQueue("a").
+		ResourceGroup(
+			*utiltesting.MakeFlavorQuotas("one").Resource(corev1.ResourceCPU, "2").Obj(),
+			*utiltesting.MakeFlavorQuotas("two").Resource(corev1.ResourceCPU, "1").Obj(),
+		).
+		Obj(), flavors)
+	cqB := cachetesting.NewClusterQueueFromAPI(utiltesting.MakeClusterQueue("b").
+		ResourceGroup(*utiltesting.MakeFlavorQuotas("one").Resource(corev1.ResourceCPU, "5").Obj()).
+		FairSharingWeight(3).
+		Obj(), flavors)
+	cases := map[string]struct {
+		cq       *cache.ClusterQueue
+		cohort   *cache.Cohort
+		resource corev1.ResourceName
+		want     int64
+	}{
+		"without cohort": {
+			cq:       cqA,
+			resource: corev1.ResourceCPU,
+			want:     3_000,
+		},
+		"default weight": {
+			cq: cqA,
+			cohort: cachetesting.MakeCohort("cohort").
+				Requestable("one", corev1.ResourceCPU, "7").
+				Requestable("two", corev1.ResourceCPU, "1").
+				Obj(),
+			resource: corev1.ResourceCPU,
+			want:     2_000,
+		},
+		"weight 3": {
+			cq: cqB,
+			cohort: cachetesting.MakeCohort("cohort").
+				Requestable("one", corev1.ResourceCPU, "7").
+				Requestable("two", corev1.ResourceCPU, "1").
+				Obj(),
+			resource: corev1.ResourceCPU,
+			want:     6_000,
+		},
+		"resource not in cohort": {
+			cq: cqB,
+			cohort: cachetesting.MakeCohort("cohort").
+				Requestable("one", corev1.ResourceCPU, "7").
+				Obj(),
+			resource: corev1.ResourceMemory,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cqA.Cohort, cqB.Cohort = nil, nil
+			if tc.cohort != nil {
+				tc.cohort.Members.Insert(cqA, cqB)
+				cqA.Cohort, cqB.Cohort = tc.cohort, tc.cohort
+			}
+			if got := FairShare(tc.cq, tc.resource); got != tc.want {
+				t.Errorf("FairShare()=%d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOversizedResources(t *testing.T) {
+	cq := cache.ClusterQueue{
+		ResourceGroups: []cache.ResourceGroup{
+			{
+				CoveredResources: sets.New(corev1.ResourceCPU),
+				Flavors: []cache.FlavorQuotas{
+					{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 4000, BorrowingLimit: pointer.Int64(0)},
+						},
+					},
+					{
+						Name: "two",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 6000, BorrowingLimit: pointer.Int64(2000)},
+						},
+					},
+				},
+			},
+			{
+				CoveredResources: sets.New(corev1.ResourceMemory),
+				Flavors: []cache.FlavorQuotas{{
+					Name: "b_one",
+					Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+						corev1.ResourceMemory: {Nominal: utiltesting.Gi},
+					},
+				}},
+			},
+		},
+		Cohort: &cache.Cohort{
+			RequestableResources: cache.FlavorResourceQuantities{
+				"one":   {corev1.ResourceCPU: 20_000},
+				"two":   {corev1.ResourceCPU: 20_000},
+				"b_one": {corev1.ResourceMemory: 4 * utiltesting.Gi},
+			},
+		},
+	}
+	cq.UpdateRGByResource()
+	cases := map[string]struct {
+		requests []corev1.ResourceList
+		want     []string
+	}{
+		"fits": {
+			requests: []corev1.ResourceList{
+				{
+					corev1.ResourceCPU:    resource.MustParse("8"),
+					corev1.ResourceMemory: resource.MustParse("4Gi"),
+				},
+			},
+		},
+		"oversized cpu": {
+			requests: []corev1.ResourceList{
+				{
+					corev1.ResourceCPU: resource.MustParse("1"),
+				},
+				{
+					corev1.ResourceCPU:    resource.MustParse("9"),
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				},
+			},
+			want: []string{"cpu"},
+		},
+		"oversized cpu and memory": {
+			requests: []corev1.ResourceList{
+				{
+					corev1.ResourceCPU:    resource.MustParse("9"),
+					corev1.ResourceMemory: resource.MustParse("5Gi"),
+				},
+			},
+			want: []string{"cpu", "memory"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var assignment Assignment
+			for i, req := range tc.requests {
+				assignment.PodSets = append(assignment.PodSets, PodSetAssignment{
+					Name:     fmt.Sprintf("ps%d", i),
+					Requests: req,
+				})
+			}
+			got := assignment.OversizedResources(&cq)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Unexpected oversized resources (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAssignmentToConditions(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"default": utiltesting.MakeResourceFlavor("default").Obj(),
+		"tainted": utiltesting.MakeResourceFlavor("tainted").
+			Taint(corev1.Taint{
+				Key:    "instance",
+				Value:  "spot",
+				Effect: corev1.TaintEffectNoSchedule,
+			}).Obj(),
+	}
+	cases := map[string]struct {
+		podSets    []kueue.PodSet
+		flavor     kueue.ResourceFlavorReference
+		usage      int64
+		wantStatus metav1.ConditionStatus
+		wantReason string
+	}{
+		"fit": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).Request(corev1.ResourceCPU, "2").Obj(),
+			},
+			flavor:     "default",
+			wantStatus: metav1.ConditionTrue,
+			wantReason: "Fit",
+		},
+		"preempt": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).Request(corev1.ResourceCPU, "2").Obj(),
+			},
+			flavor:     "default",
+			usage:      3_000,
+			wantStatus: metav1.ConditionFalse,
+			wantReason: "Preemptable",
+		},
+		"no fit, insufficient quota": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).Request(corev1.ResourceCPU, "5").Obj(),
+			},
+			flavor:     "default",
+			wantStatus: metav1.ConditionFalse,
+			wantReason: "InsufficientQuota",
+		},
+		"no fit, untolerated taint": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).Request(corev1.ResourceCPU, "1").Obj(),
+			},
+			flavor:     "tainted",
+			wantStatus: metav1.ConditionFalse,
+			wantReason: "UntoleratedTaint",
+		},
+		"no fit, resource unavailable": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).Request("example.com/gpu", "1").Obj(),
+			},
+			flavor:     "default",
+			wantStatus: metav1.ConditionFalse,
+			wantReason: "ResourceUnavailable",
+		},
+		"no pod sets": {
+			flavor:     "default",
+			wantStatus: metav1.ConditionFalse,
+			wantReason: "AssignmentError",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			wlInfo := workload.NewInfo(&kueue.Workload{
+				Spec: kueue.WorkloadSpec{
+					PodSets: tc.podSets,
+				},
+			})
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: tc.flavor,
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 4_000},
+						},
+					}},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					tc.flavor: {corev1.ResourceCPU: tc.usage},
+				},
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			assignment := AssignFlavors(log, wlInfo, resourceFlavors, &cq)
+			conditions := assignment.ToConditions()
+			var gotTypes []string
+			for _, c := range conditions {
+				gotTypes = append(gotTypes, c.Type)
+				if c.Status != tc.wantStatus {
+					t.Errorf("Condition %s has status %s, want %s", c.Type, c.Status, tc.wantStatus)
+				}
+				if c.Reason != tc.wantReason {
+					t.Errorf("Condition %s has reason %s, want %s", c.Type, c.Reason, tc.wantReason)
+				}
+			}
+			if diff := cmp.Diff([]string{kueue.WorkloadQuotaReserved}, gotTypes); diff != "" {
+				t.Errorf("Unexpected condition types (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAssignmentBottleneck(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"one": utiltesting.MakeResourceFlavor("one").Obj(),
+	}
+	cases := map[string]struct {
+		usage        cache.FlavorResourceQuantities
+		wantResource corev1.ResourceName
+		wantFlavor   kueue.ResourceFlavorReference
+	}{
+		"cpu is the bottleneck": {
+			usage: cache.FlavorResourceQuantities{
+				"one": {
+					corev1.ResourceCPU:    4_000,
+					corev1.ResourceMemory: 3 * utiltesting.Gi,
+				},
+			},
+			wantResource: corev1.ResourceCPU,
+			wantFlavor:   "one",
+		},
+		"memory is the bottleneck": {
+			usage: cache.FlavorResourceQuantities{
+				"one": {
+					corev1.ResourceCPU:    3_000,
+					corev1.ResourceMemory: 4 * utiltesting.Gi,
+				},
+			},
+			wantResource: corev1.ResourceMemory,
+			wantFlavor:   "one",
+		},
+		"fit": {},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU, corev1.ResourceMemory),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "one",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU:    {Nominal: 4_000},
+							corev1.ResourceMemory: {Nominal: 4 * utiltesting.Gi},
+						},
+					}},
+				}},
+				Usage: tc.usage,
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			wlInfo := workload.NewInfo(utiltesting.MakeWorkload("wl", "ns").
+				Request(corev1.ResourceCPU, "2").
+				Request(corev1.ResourceMemory, "2Gi").
+				Obj())
+			assignment := AssignFlavors(log, wlInfo, resourceFlavors, &cq)
+			gotResource, gotFlavor := assignment.Bottleneck()
+			if gotResource != tc.wantResource || gotFlavor != tc.wantFlavor {
+				t.Errorf("Bottleneck()=(%q, %q), want (%q, %q)", gotResource, gotFlavor, tc.wantResource, tc.wantFlavor)
+			}
+		})
+	}
+}
+
+func TestAssignmentCanFitByWaiting(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"default": utiltesting.MakeResourceFlavor("default").Obj(),
+	}
+	cases := map[string]struct {
+		request     string
+		usage       int64
+		cohort      *cache.Cohort
+		wantRepMode FlavorAssignmentMode
+		want        bool
+	}{
+		"fits": {
+			request:     "1",
+			usage:       2_000,
+			wantRepMode: Fit,
+		},
+		"quota used by the ClusterQueue": {
+			request:     "2",
+			usage:       3_000,
+			wantRepMode: Preempt,
+			want:        true,
+		},
+		"quota used by the ClusterQueue in a cohort": {
+			request: "2",
+			usage:   3_000,
+			cohort: cachetesting.MakeCohort("cohort").
+				Requestable("default", corev1.ResourceCPU, "8").
+				Usage("default", corev1.ResourceCPU, "8").
+				Obj(),
+			wantRepMode: Preempt,
+			want:        true,
+		},
+		"cohort exhausted": {
+			request: "2",
+			cohort: cachetesting.MakeCohort("cohort").
+				Requestable("default", corev1.ResourceCPU, "8").
+				Usage("default", corev1.ResourceCPU, "8").
+				Obj(),
+			wantRepMode: Preempt,
+		},
+		"doesn't fit": {
+			request:     "5",
+			wantRepMode: NoFit,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{{
+					CoveredResources: sets.New(corev1.ResourceCPU),
+					Flavors: []cache.FlavorQuotas{{
+						Name: "default",
+						Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+							corev1.ResourceCPU: {Nominal: 4_000},
+						},
+					}},
+				}},
+				Usage: cache.FlavorResourceQuantities{
+					"default": {corev1.ResourceCPU: tc.usage},
+				},
+				Cohort: tc.cohort,
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			wl := utiltesting.MakeWorkload("wl", "ns").Request(corev1.ResourceCPU, tc.request).Obj()
+			assignment := AssignFlavors(log, workload.NewInfo(wl), resourceFlavors, &cq)
+			if repMode := assignment.RepresentativeMode(); repMode != tc.wantRepMode {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, tc.wantRepMode)
+			}
+			if got := assignment.CanFitByWaiting(); got != tc.want {
+				t.Errorf("CanFitByWaiting()=%t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAssignmentPreemptionTargets(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"one": utiltesting.MakeResourceFlavor("one").Obj(),
+		"two": utiltesting.MakeResourceFlavor("two").Obj(),
+	}
+	cases := map[string]struct {
+		podSets     []kueue.PodSet
+		usage       cache.FlavorResourceQuantities
+		wantRepMode FlavorAssignmentMode
+		want        map[kueue.ResourceFlavorReference]corev1.ResourceList
+	}{
+		"fits": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "1").
+					Obj(),
+			},
+			wantRepMode: Fit,
+			want:        map[kueue.ResourceFlavorReference]corev1.ResourceList{},
+		},
+		"single flavor": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "2").
+					Obj(),
+			},
+			usage: cache.FlavorResourceQuantities{
+				"one": {corev1.ResourceCPU: 3_000},
+			},
+			wantRepMode: Preempt,
+			want: map[kueue.ResourceFlavorReference]corev1.ResourceList{
+				"one": {corev1.ResourceCPU: resource.MustParse("1")},
+			},
+		},
+		"multiple flavors": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "2").
+					Request(corev1.ResourceMemory, "3Gi").
+					Obj(),
+			},
+			usage: cache.FlavorResourceQuantities{
+				"one": {corev1.ResourceCPU: 3_000},
+				"two": {corev1.ResourceMemory: utiltesting.Gi * 2},
+			},
+			wantRepMode: Preempt,
+			want: map[kueue.ResourceFlavorReference]corev1.ResourceList{
+				"one": {corev1.ResourceCPU: resource.MustParse("1")},
+				"two": {corev1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+		},
+		"multiple pod sets in the same flavor": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("driver", 1).
+					Request(corev1.ResourceCPU, "1").
+					Obj(),
+				*utiltesting.MakePodSet("workers", 1).
+					Request(corev1.ResourceCPU, "2").
+					Obj(),
+			},
+			usage: cache.FlavorResourceQuantities{
+				"one": {corev1.ResourceCPU: 2_500},
+			},
+			wantRepMode: Preempt,
+			want: map[kueue.ResourceFlavorReference]corev1.ResourceList{
+				"one": {corev1.ResourceCPU: resource.MustParse("1500m")},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			cq := cache.ClusterQueue{
+				ResourceGroups: []cache.ResourceGroup{
+					{
+						CoveredResources: sets.New(corev1.ResourceCPU),
+						Flavors: []cache.FlavorQuotas{{
+							Name: "one",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceCPU: {Nominal: 4_000},
+							},
+						}},
+					},
+					{
+						CoveredResources: sets.New(corev1.ResourceMemory),
+						Flavors: []cache.FlavorQuotas{{
+							Name: "two",
+							Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+								corev1.ResourceMemory: {Nominal: utiltesting.Gi * 4},
+							},
+						}},
+					},
+				},
+				Usage: tc.usage,
+			}
+			cq.UpdateWithFlavors(resourceFlavors)
+			cq.UpdateRGByResource()
+			wl := utiltesting.MakeWorkload("wl", "ns").PodSets(tc.podSets...).Obj()
+			assignment := AssignFlavors(log, workload.NewInfo(wl), resourceFlavors, &cq)
+			if repMode := assignment.RepresentativeMode(); repMode != tc.wantRepMode {
+				t.Errorf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, tc.wantRepMode)
+			}
+			if diff := cmp.Diff(tc.want, assignment.PreemptionTargets()); diff != "" {
+				t.Errorf("Unexpected preemption targets (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAssignmentIsStale(t *testing.T) {
+	cqWrapper := func(name, quota string) *utiltesting.ClusterQueueWrapper {
+		return utiltesting.MakeClusterQueue(name).
+			Cohort("cohort").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").Resource(corev1.ResourceCPU, quota).Obj())
+	}
+	cases := map[string]struct {
+		mutate         func(c *cache.Cache) error
+		mutateSnapshot func(s *cache.Snapshot)
+		wantStale      bool
+	}{
+		"no changes": {
+			mutate: func(*cache.Cache) error { return nil },
+		},
+		"quota changed": {
+			mutate: func(c *cache.Cache) error {
+				return c.UpdateClusterQueue(cqWrapper("cq", "8").Obj())
+			},
+			wantStale: true,
+		},
+		"workload admitted": {
+			mutate: func(c *cache.Cache) error {
+				c.AddOrUpdateWorkload(utiltesting.MakeWorkload("admitted", "ns").
+					Request(corev1.ResourceCPU, "1").
+					Admit(utiltesting.MakeAdmission("cq").Assignment(corev1.ResourceCPU, "default", "1").Obj()).
+					Obj())
+				return nil
+			},
+			wantStale: true,
+		},
+		"workload admitted in another member of the cohort": {
+			mutate: func(c *cache.Cache) error {
+				c.AddOrUpdateWorkload(utiltesting.MakeWorkload("admitted", "ns").
+					Request(corev1.ResourceCPU, "1").
+					Admit(utiltesting.MakeAdmission("other").Assignment(corev1.ResourceCPU, "default", "1").Obj()).
+					Obj())
+				return nil
+			},
+			wantStale: true,
+		},
+		"member added to the cohort": {
+			mutate: func(c *cache.Cache) error {
+				return c.AddClusterQueue(context.Background(), cqWrapper("new", "4").Obj())
+			},
+			wantStale: true,
+		},
+		"member removed from the cohort": {
+			mutate: func(c *cache.Cache) error {
+				c.DeleteClusterQueue(cqWrapper("other", "4").Obj())
+				return nil
+			},
+			wantStale: true,
+		},
+		"workload added to the snapshot": {
+			mutateSnapshot: func(s *cache.Snapshot) {
+				s.AddWorkload(workload.NewInfo(utiltesting.MakeWorkload("added", "ns").
+					Request(corev1.ResourceCPU, "1").
+					Admit(utiltesting.MakeAdmission("cq").Assignment(corev1.ResourceCPU, "default", "1").Obj()).
+					Obj()))
+			},
+			wantStale: true,
+		},
+		"workload removed from the snapshot of another member": {
+			mutateSnapshot: func(s *cache.Snapshot) {
+				s.RemoveWorkload(workload.NewInfo(utiltesting.MakeWorkload("running", "ns").
+					Request(corev1.ResourceCPU, "1").
+					Admit(utiltesting.MakeAdmission("other").Assignment(corev1.ResourceCPU, "default", "1").Obj()).
+					Obj()))
+			},
+			wantStale: true,
+		},
+		"flavor changed": {
+			mutate: func(c *cache.Cache) error {
+				c.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("default").Label("type", "new").Obj())
+				return nil
+			},
+			wantStale: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := testr.NewWithOptions(t, testr.Options{
+				Verbosity: 2,
+			})
+			c := cache.New(utiltesting.NewFakeClient())
+			c.AddOrUpdateResourceFlavor(utiltesting.MakeResourceFlavor("default").Obj())
+			for _, name := range []string{"cq", "other"} {
+				if err := c.AddClusterQueue(context.Background(), cqWrapper(name, "4").Obj()); err != nil {
+					t.Fatalf("Failed adding ClusterQueue: %v", err)
+				}
+			}
+			c.AddOrUpdateWorkload(utiltesting.MakeWorkload("running", "ns").
+				Request(corev1.ResourceCPU, "1").
+				Admit(utiltesting.MakeAdmission("other").Assignment(corev1.ResourceCPU, "default", "1").Obj()).
+				Obj())
+			snapshot := c.Snapshot()
+			wl := utiltesting.MakeWorkload("wl", "ns").Request(corev1.ResourceCPU, "1").Obj()
+			assignment := AssignFlavors(log, workload.NewInfo(wl), snapshot.ResourceFlavors, snapshot.ClusterQueues["cq"])
+			if assignment.IsStale(snapshot.ClusterQueues["cq"]) {
+				t.Error("Assignment is stale for the ClusterQueue it was computed against")
+			}
+
+			if tc.mutateSnapshot != nil {
+				tc.mutateSnapshot(&snapshot)
+			} else {
+				if err := tc.mutate(c); err != nil {
+					t.Fatalf("Failed mutating the cache: %v", err)
+				}
+				snapshot = c.Snapshot()
+			}
+			if got := assignment.IsStale(snapshot.ClusterQueues["cq"]); got != tc.wantStale {
+				t.Errorf("IsStale()=%t, want %t", got, tc.wantStale)
+			}
+		})
+	}
+}
+
+func TestAssignmentModesByPodSet(t *testing.T) {
+	cases := map[string]struct {
+		assignment Assignment
+		want       map[string]FlavorAssignmentMode
+	}{
+		"no pod sets": {
+			want: map[string]FlavorAssignmentMode{},
+		},
+		"fit and preempt": {
+			assignment: Assignment{
+				PodSets: []PodSetAssignment{
+					{
+						Name: "driver",
+						Flavors: ResourceAssignment{
+							corev1.ResourceCPU:    {Name: "one", Mode: Fit},
+							corev1.ResourceMemory: {Name: "one", Mode: Fit},
+						},
+					},
+					{
+						Name: "workers",
+						Flavors: ResourceAssignment{
+							corev1.ResourceCPU:    {Name: "one", Mode: Preempt},
+							corev1.ResourceMemory: {Name: "one", Mode: Fit},
+						},
+						Status: (&Status{}).reject(rejection{
+							flavor:   "one",
+							resource: corev1.ResourceCPU,
+							reason:   InsufficientQuota,
+							message:  "insufficient unused quota for cpu in flavor one, need to preempt 1 in ClusterQueue",
+						}),
+					},
+				},
+			},
+			want: map[string]FlavorAssignmentMode{
+				"driver":  Fit,
+				"workers": Preempt,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, tc.assignment.ModesByPodSet()); diff != "" {
+				t.Errorf("Unexpected modes (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAssignmentShortSummary(t *testing.T) {
+	cases := map[string]struct {
+		assignment Assignment
+		want       string
+	}{
+		"fit": {
+			assignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU:    {Name: "two", Mode: Fit},
+						corev1.ResourceMemory: {Name: "two", Mode: Fit},
+					},
+				}},
+			},
+			want: "Fit: main[cpu=two,memory=two]",
+		},
+		"preempt": {
+			assignment: Assignment{
+				PodSets: []PodSetAssignment{
+					{
+						Name: "driver",
+						Flavors: ResourceAssignment{
+							corev1.ResourceCPU: {Name: "one", Mode: Fit},
+						},
+					},
+					{
+						Name: "workers",
+						Flavors: ResourceAssignment{
+							corev1.ResourceCPU: {Name: "one", Mode: Preempt},
+						},
+						Status: (&Status{}).reject(rejection{
+							flavor:   "one",
+							resource: corev1.ResourceCPU,
+							reason:   InsufficientQuota,
+							message:  "insufficient unused quota for cpu in flavor one, need to preempt 1 in ClusterQueue",
+						}),
+					},
+				},
+			},
+			want: "Preempt: driver[cpu=one], workers[cpu=one]",
+		},
+		"borrowing": {
+			assignment: Assignment{
+				PodSets: []PodSetAssignment{{
+					Name: "main",
+					Flavors: ResourceAssignment{
+						corev1.ResourceCPU:    {Name: "two", Mode: Fit, borrow: 2_000},
+						corev1.ResourceMemory: {Name: "two", Mode: Fit},
+					},
+				}},
+				TotalBorrow: cache.FlavorResourceQuantities{
+					"two": {corev1.ResourceCPU: 2_000},
+				},
+			},
+			want: "Fit: main[cpu=two,memory=two]; borrow cpu=2000",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := tc.assignment.ShortSummary(); got != tc.want {
+				t.Errorf("ShortSummary()=%q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAssignmentEqual(t *testing.T) {
+	base := func() *Assignment {
+		return &Assignment{
+			PodSets: []PodSetAssignment{{
+				Name: "main",
+				Flavors: ResourceAssignment{
+					corev1.ResourceCPU: {Name: "one", Mode: Fit, borrow: 1000},
+				},
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("2"),
+				},
+				count: 1,
+			}},
+			TotalBorrow: cache.FlavorResourceQuantities{
+				"one": {corev1.ResourceCPU: 1000},
+			},
+		}
+	}
+	cases := map[string]struct {
+		modify func(*Assignment)
+		want   bool
+	}{
+		"equal": {
+			modify: func(*Assignment) {},
+			want:   true,
+		},
+		"equal ignoring representative mode and usage": {
+			modify: func(a *Assignment) {
+				a.RepresentativeMode()
+				a.usage = cache.FlavorResourceQuantities{"one": {corev1.ResourceCPU: 2000}}
+			},
+			want: true,
+		},
+		"equivalent requests": {
+			modify: func(a *Assignment) {
+				a.PodSets[0].Requests[corev1.ResourceCPU] = resource.MustParse("2000m")
+			},
+			want: true,
+		},
+		"different flavor": {
+			modify: func(a *Assignment) {
+				a.PodSets[0].Flavors[corev1.ResourceCPU].Name = "two"
+			},
+		},
+		"different mode": {
+			modify: func(a *Assignment) {
+				a.PodSets[0].Flavors[corev1.ResourceCPU].Mode = Preempt
+			},
+		},
+		"different borrow": {
+			modify: func(a *Assignment) {
+				a.PodSets[0].Flavors[corev1.ResourceCPU].borrow = 0
+			},
+		},
+		"different total borrow": {
+			modify: func(a *Assignment) {
+				a.TotalBorrow = nil
+			},
+		},
+		"different status": {
+			modify: func(a *Assignment) {
+				a.PodSets[0].Status = &Status{reasons: []string{"insufficient quota"}}
+			},
+		},
+		"different pod sets": {
+			modify: func(a *Assignment) {
+				a.PodSets = append(a.PodSets, PodSetAssignment{Name: "workers"})
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := base()
+			b := base()
+			tc.modify(b)
+			if got := a.Equal(b); got != tc.want {
+				t.Errorf("a.Equal(b)=%t, want %t", got, tc.want)
+			}
+			if got := b.Equal(a); got != tc.want {
+				t.Errorf("b.Equal(a)=%t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAssignmentMarshalJSON(t *testing.T) {
+	assignment := Assignment{
+		PodSets: []PodSetAssignment{
+			{
+				Name: "driver",
+				Flavors: ResourceAssignment{
+					corev1.ResourceCPU: {Name: "one", Mode: Fit, borrow: 1000},
+				},
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("2"),
+				},
+				count: 1,
+			},
+			{
+				Name: "workers",
+				Flavors: ResourceAssignment{
+					corev1.ResourceCPU: {Name: "one", Mode: Preempt},
+				},
+				Status: &Status{
+					reasons: []string{"insufficient unused quota for cpu in flavor one, need to preempt 1 in ClusterQueue"},
+				},
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("4"),
+				},
+			},
+		},
+		TotalBorrow: cache.FlavorResourceQuantities{
+			"one": {corev1.ResourceCPU: 1000},
+		},
+	}
+	data, err := json.Marshal(&assignment)
+	if err != nil {
+		t.Fatalf("Failed to marshal assignment: %v", err)
+	}
+	var got jsonAssignment
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Failed to unmarshal assignment %s: %v", data, err)
+	}
+	want := jsonAssignment{
+		PodSets: []jsonPodSetAssignment{
+			{
+				Name: "driver",
+				Flavors: map[corev1.ResourceName]jsonFlavorAssignment{
+					corev1.ResourceCPU: {Name: "one", Mode: "Fit", Borrow: 1000},
+				},
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("2"),
+				},
+				Count: 1,
+			},
+			{
+				Name: "workers",
+				Flavors: map[corev1.ResourceName]jsonFlavorAssignment{
+					corev1.ResourceCPU: {Name: "one", Mode: "Preempt"},
+				},
+				Status: "insufficient unused quota for cpu in flavor one, need to preempt 1 in ClusterQueue",
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("4"),
+				},
+			},
+		},
+		TotalBorrow: cache.FlavorResourceQuantities{
+			"one": {corev1.ResourceCPU: 1000},
+		},
+		RepresentativeMode: "Preempt",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected JSON assignment (-want,+got):\n%s", diff)
+	}
+}
+
+func TestWhyNotFlavor(t *testing.T) {
+	resourceFlavors := map[kueue.ResourceFlavorReference]*kueue.ResourceFlavor{
+		"one": utiltesting.MakeResourceFlavor("one").Label("type", "one").Obj(),
+		"two": utiltesting.MakeResourceFlavor("two").Label("type", "two").Obj(),
+		"logical": utiltesting.MakeResourceFlavor("logical").
+			Label("type", "logical").
+			IgnoreNodeAffinity().Obj(),
+		"tainted": utiltesting.MakeResourceFlavor("tainted").
+			Taint(corev1.Taint{
+				Key:    "instance",
+				Value:  "spot",
+				Effect: corev1.TaintEffectNoSchedule,
+			}).Obj(),
+	}
+	cq := cache.ClusterQueue{
+		ResourceGroups: []cache.ResourceGroup{{
+			CoveredResources: sets.New(corev1.ResourceCPU),
+			Flavors: []cache.FlavorQuotas{
+				{
+					Name: "tainted",
+					Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+						corev1.ResourceCPU: {Nominal: 4000},
+					},
+				},
+				{
+					Name: "one",
+					Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+						corev1.ResourceCPU: {Nominal: 4000},
+					},
+				},
+				{
+					Name: "two",
+					Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+						corev1.ResourceCPU: {Nominal: 1000},
+					},
+				},
+				{
+					Name: "missing",
+					Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+						corev1.ResourceCPU: {Nominal: 4000},
+					},
+				},
+				{
+					Name: "logical",
+					Resources: map[corev1.ResourceName]*cache.ResourceQuota{
+						corev1.ResourceCPU: {Nominal: 4000},
+					},
+				},
+			},
+		}},
+	}
+	cq.UpdateWithFlavors(resourceFlavors)
+	cq.UpdateRGByResource()
+	wlInfo := workload.NewInfo(&kueue.Workload{
+		Spec: kueue.WorkloadSpec{
+			PodSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "2").
+					NodeSelector(map[string]string{"type": "two"}).
+					Obj(),
+			},
+		},
+	})
+	log := testr.NewWithOptions(t, testr.Options{
+		Verbosity: 2,
+	})
+	assignment := AssignFlavors(log, wlInfo, resourceFlavors, &cq)
+	if repMode := assignment.RepresentativeMode(); repMode != Fit {
+		t.Fatalf("AssignFlavors(_).RepresentativeMode()=%s, want %s", repMode, Fit)
+	}
+
+	cases := map[string]struct {
+		podSet string
+		flavor kueue.ResourceFlavorReference
+		want   string
+	}{
+		"untolerated taint": {
+			podSet: "main",
+			flavor: "tainted",
+			want:   "untolerated taint {instance spot NoSchedule <nil>} in flavor tainted",
+		},
+		"node affinity mismatch": {
+			podSet: "main",
+			flavor: "one",
+			want:   "flavor one doesn't match node affinity",
+		},
+		"insufficient quota": {
+			podSet: "main",
+			flavor: "two",
+			want:   "insufficient quota for cpu in flavor two in ClusterQueue",
+		},
+		"flavor not found": {
+			podSet: "main",
+			flavor: "missing",
+			want:   "flavor missing not found",
+		},
+		"assigned flavor": {
+			podSet: "main",
+			flavor: "logical",
+		},
+		"unknown pod set": {
+			podSet: "workers",
+			flavor: "tainted",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := assignment.WhyNotFlavor(tc.podSet, tc.flavor); got != tc.want {
+				t.Errorf("WhyNotFlavor(%q, %q)=%q, want %q", tc.podSet, tc.flavor, got, tc.want)
+			}
+		})
+	}
+}
+
+// ExpectRejection reports an error if the status doesn't hold a rejection of
+// the flavor for the given reason.
+func ExpectRejection(t testing.TB, status *Status, reason RejectionReason, flavor kueue.ResourceFlavorReference) {
+	t.Helper()
+	if status == nil {
+		t.Errorf("Got no status, want rejection of flavor %s for %s", flavor, reason)
+		return
+	}
+	got := make([]string, 0, len(status.rejections))
+	for _, r := range status.rejections {
+		if r.reason == reason && r.flavor == flavor {
+			return
+		}
+		got = append(got, fmt.Sprintf("%s(%s)", r.reason, r.flavor))
+	}
+	t.Errorf("Got rejections %v, want rejection of flavor %s for %s", got, flavor, reason)
+}
+
+// recordingTB records whether errors were reported, to test the helpers.
+type recordingTB struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(string, ...interface{}) {
+	r.failed = true
+}
+
+func TestExpectRejection(t *testing.T) {
+	status := (&Status{}).reject(rejection{
+		flavor:  "one",
+		reason:  UntoleratedTaint,
+		message: "untolerated taint in flavor one",
+	})
+	cases := map[string]struct {
+		status     *Status
+		reason     RejectionReason
+		flavor     kueue.ResourceFlavorReference
+		wantFailed bool
+	}{
+		"matching rejection": {
+			status: status,
+			reason: UntoleratedTaint,
+			flavor: "one",
+		},
+		"different reason": {
+			status:     status,
+			reason:     InsufficientQuota,
+			flavor:     "one",
+			wantFailed: true,
+		},
+		"different flavor": {
+			status:     status,
+			reason:     UntoleratedTaint,
+			flavor:     "two",
+			wantFailed: true,
+		},
+		"no status": {
+			reason:     UntoleratedTaint,
+			flavor:     "one",
+			wantFailed: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tb := &recordingTB{TB: t}
+			ExpectRejection(tb, tc.status, tc.reason, tc.flavor)
+			if tb.failed != tc.wantFailed {
+				t.Errorf("ExpectRejection failed=%t, want %t", tb.failed, tc.wantFailed)
+			}
+		})
+	}
+}
@@ -74,13 +74,41 @@ func candidatesOnlyFromQueue(candidates []*workload.Info, clusterQueue string) [
 	return result
 }
 
+// SameWorkload returns whether a and b are the same workload, or workloads
+// controlled by the same owner.
+func SameWorkload(a, b *workload.Info) bool {
+	if a.Obj.UID != "" && a.Obj.UID == b.Obj.UID {
+		return true
+	}
+	if workload.Key(a.Obj) == workload.Key(b.Obj) {
+		return true
+	}
+	ownerA := metav1.GetControllerOf(a.Obj)
+	ownerB := metav1.GetControllerOf(b.Obj)
+	return ownerA != nil && ownerB != nil && ownerA.UID == ownerB.UID
+}
+
+// excludeSameWorkload returns the candidates that are not the same workload
+// as wl, so that the admission of a pod set never preempts another pod set of
+// the same workload.
+func excludeSameWorkload(candidates []*workload.Info, wl *workload.Info) []*workload.Info {
+	result := make([]*workload.Info, 0, len(candidates))
+	for _, candWl := range candidates {
+		if !SameWorkload(candWl, wl) {
+			result = append(result, candWl)
+		}
+	}
+	return result
+}
+
 func (p *Preemptor) Do(ctx context.Context, wl workload.Info, assignment flavorassigner.Assignment, snapshot *cache.Snapshot) (int, error) {
 	log := ctrl.LoggerFrom(ctx)
 
 	resPerFlv := resourcesRequiringPreemption(assignment)
 	cq := snapshot.ClusterQueues[wl.ClusterQueue]
 
-	candidates := findCandidates(wl.Obj, cq, resPerFlv)
+	withinNominal := fitsWithinNominal(totalRequestsForAssignment(&wl, assignment), cq)
+	candidates := excludeSameWorkload(findCandidates(wl.Obj, cq, resPerFlv, withinNominal), &wl)
 	if len(candidates) == 0 {
 		log.V(2).Info("Workload requires preemption, but there are no candidate workloads allowed for preemption", "preemptionReclaimWithinCohort", cq.Preemption.ReclaimWithinCohort, "preemptionWithinClusterQueue", cq.Preemption.WithinClusterQueue)
 		return 0, nil
@@ -166,6 +194,7 @@ func (p *Preemptor) applyPreemptionWithSSA(ctx context.Context, w *kueue.Workloa
 func minimalPreemptions(wl *workload.Info, assignment flavorassigner.Assignment, snapshot *cache.Snapshot, resPerFlv resourcesPerFlavor, candidates []*workload.Info, allowBorrowing bool) []*workload.Info {
 	wlReq := totalRequestsForAssignment(wl, assignment)
 	cq := snapshot.ClusterQueues[wl.ClusterQueue]
+	class := wl.Obj.Labels[kueue.WorkloadClassLabel]
 	// Simulate removing all candidates from the ClusterQueue and cohort.
 	var targets []*workload.Info
 	fits := false
@@ -176,7 +205,7 @@ func minimalPreemptions(wl *workload.Info, assignment flavorassigner.Assignment,
 		}
 		snapshot.RemoveWorkload(candWl)
 		targets = append(targets, candWl)
-		if workloadFits(wlReq, cq, allowBorrowing) {
+		if workloadFits(wlReq, cq, class, allowBorrowing) {
 			fits = true
 			break
 		}
@@ -191,7 +220,7 @@ func minimalPreemptions(wl *workload.Info, assignment flavorassigner.Assignment,
 	// In the reverse order, check if any of the workloads can be added back.
 	for i := len(targets) - 2; i >= 0; i-- {
 		snapshot.AddWorkload(targets[i])
-		if workloadFits(wlReq, cq, allowBorrowing) {
+		if workloadFits(wlReq, cq, class, allowBorrowing) {
 			// O(1) deletion: copy the last element into index i and reduce size.
 			targets[i] = targets[len(targets)-1]
 			targets = targets[:len(targets)-1]
@@ -206,6 +235,72 @@ func minimalPreemptions(wl *workload.Info, assignment flavorassigner.Assignment,
 	return targets
 }
 
+// MinimalPreemptionSet returns a minimal set of candidates whose removal frees
+// at least the shortfall. The candidates are expected to have a lower or equal
+// priority than the preemptor, or to be borrowing quota that the preemptor can
+// reclaim, see CanReclaimFrom, and to be sorted in the order in which they
+// should be preempted, and to exclude the preemptor itself, see
+// SameWorkload. Candidates not using any of the resources in the shortfall are
+// skipped. It returns nil if removing all the candidates doesn't
+// cover the shortfall.
+func MinimalPreemptionSet(shortfall workload.Requests, candidates []*workload.Info) []*workload.Info {
+	freed := make(workload.Requests, len(shortfall))
+	if coversShortfall(freed, shortfall) {
+		return nil
+	}
+	var targets []*workload.Info
+	covered := false
+	for _, candWl := range candidates {
+		if !accumulateRequests(freed, candWl, shortfall, 1) {
+			continue
+		}
+		targets = append(targets, candWl)
+		if coversShortfall(freed, shortfall) {
+			covered = true
+			break
+		}
+	}
+	if !covered {
+		return nil
+	}
+	// In the reverse order, check if any of the workloads can be left running.
+	for i := len(targets) - 2; i >= 0; i-- {
+		accumulateRequests(freed, targets[i], shortfall, -1)
+		if coversShortfall(freed, shortfall) {
+			// Keep the order of the remaining targets.
+			targets = append(targets[:i], targets[i+1:]...)
+		} else {
+			accumulateRequests(freed, targets[i], shortfall, 1)
+		}
+	}
+	return targets
+}
+
+// accumulateRequests adds the requests of the workload for the resources in
+// the shortfall, multiplied by m, into acc. It returns whether the workload
+// requests any of the resources.
+func accumulateRequests(acc workload.Requests, wl *workload.Info, shortfall workload.Requests, m int64) bool {
+	uses := false
+	for _, ps := range wl.TotalRequests {
+		for res, v := range ps.Requests {
+			if _, found := shortfall[res]; found && v > 0 {
+				acc[res] += v * m
+				uses = true
+			}
+		}
+	}
+	return uses
+}
+
+func coversShortfall(freed, shortfall workload.Requests) bool {
+	for res, v := range shortfall {
+		if freed[res] < v {
+			return false
+		}
+	}
+	return true
+}
+
 type resourcesPerFlavor map[kueue.ResourceFlavorReference]sets.Set[corev1.ResourceName]
 
 func resourcesRequiringPreemption(assignment flavorassigner.Assignment) resourcesPerFlavor {
@@ -228,10 +323,15 @@ func resourcesRequiringPreemption(assignment flavorassigner.Assignment) resource
 
 // findCandidates obtains candidates for preemption within the ClusterQueue and
 // cohort that respect the preemption policy and are using a resource that the
-// preempting workload needs.
-func findCandidates(wl *kueue.Workload, cq *cache.ClusterQueue, resPerFlv resourcesPerFlavor) []*workload.Info {
+// preempting workload needs. If the class of the preempting workload has a
+// share of the quota, only workloads of the same class are candidates within
+// the ClusterQueue. withinNominal is whether the preempting workload fits
+// within the nominal quota of the ClusterQueue, see CanReclaimFrom.
+func findCandidates(wl *kueue.Workload, cq *cache.ClusterQueue, resPerFlv resourcesPerFlavor, withinNominal bool) []*workload.Info {
 	var candidates []*workload.Info
 	wlPriority := priority.Priority(wl)
+	class := wl.Labels[kueue.WorkloadClassLabel]
+	sameClass := cq.HasClassShare(class)
 
 	if cq.Preemption.WithinClusterQueue != kueue.PreemptionPolicyNever {
 		considerSamePrio := (cq.Preemption.WithinClusterQueue == kueue.PreemptionPolicyLowerOrNewerEqualPriority)
@@ -247,6 +347,10 @@ func findCandidates(wl *kueue.Workload, cq *cache.ClusterQueue, resPerFlv resour
 				continue
 			}
 
+			if sameClass && candidateWl.Obj.Labels[kueue.WorkloadClassLabel] != class {
+				continue
+			}
+
 			if !workloadUsesResources(candidateWl, resPerFlv) {
 				continue
 			}
@@ -254,7 +358,7 @@ func findCandidates(wl *kueue.Workload, cq *cache.ClusterQueue, resPerFlv resour
 		}
 	}
 
-	if cq.Cohort != nil && cq.Preemption.ReclaimWithinCohort != kueue.PreemptionPolicyNever {
+	if cq.Cohort != nil && (cq.Preemption.ReclaimWithinCohort != kueue.PreemptionPolicyNever || reclaimsBorrowed(cq.Preemption)) {
 		cqs := cq.Cohort.Members
 		cqs.Delete(cq)
 		for cohortCQ := range cqs {
@@ -262,12 +366,8 @@ func findCandidates(wl *kueue.Workload, cq *cache.ClusterQueue, resPerFlv resour
 				// Can't reclaim quota from ClusterQueues that are not borrowing.
 				continue
 			}
-			onlyLowerPrio := true
-			if cq.Preemption.ReclaimWithinCohort == kueue.PreemptionPolicyAny {
-				onlyLowerPrio = false
-			}
 			for _, candidateWl := range cohortCQ.Workloads {
-				if onlyLowerPrio && priority.Priority(candidateWl.Obj) >= priority.Priority(wl) {
+				if !CanReclaimFrom(cq.Preemption, withinNominal, wlPriority, priority.Priority(candidateWl.Obj)) {
 					continue
 				}
 				if !workloadUsesResources(candidateWl, resPerFlv) {
@@ -280,6 +380,36 @@ func findCandidates(wl *kueue.Workload, cq *cache.ClusterQueue, resPerFlv resour
 	return candidates
 }
 
+// CanReclaimFrom returns whether a workload with the preemptor priority can
+// preempt a workload with the candidate priority, that is borrowing in
+// another ClusterQueue of the cohort, according to the preemption policies of
+// the ClusterQueue of the preemptor. Candidates with a higher priority than
+// the preemptor can only be preempted with the Any reclaimWithinCohort policy
+// or the ReclaimBorrowed borrowWithinCohort policy, up to its
+// maxPriorityThreshold. The latter only applies when the preemptor fits
+// within the nominal quota of its ClusterQueue, as reported by withinNominal,
+// so that the quota is reclaimed rather than borrowed at the expense of
+// higher priority workloads.
+func CanReclaimFrom(p kueue.ClusterQueuePreemption, withinNominal bool, preemptorPriority, candidatePriority int32) bool {
+	switch p.ReclaimWithinCohort {
+	case kueue.PreemptionPolicyAny:
+		return true
+	case kueue.PreemptionPolicyLowerPriority:
+		if candidatePriority < preemptorPriority {
+			return true
+		}
+	}
+	if !withinNominal || !reclaimsBorrowed(p) {
+		return false
+	}
+	threshold := p.BorrowWithinCohort.MaxPriorityThreshold
+	return threshold == nil || candidatePriority <= *threshold
+}
+
+func reclaimsBorrowed(p kueue.ClusterQueuePreemption) bool {
+	return p.BorrowWithinCohort != nil && p.BorrowWithinCohort.Policy == kueue.BorrowWithinCohortPolicyReclaimBorrowed
+}
+
 func cqIsBorrowing(cq *cache.ClusterQueue, resPerFlv resourcesPerFlavor) bool {
 	if cq.Cohort == nil {
 		return false
@@ -288,7 +418,7 @@ func cqIsBorrowing(cq *cache.ClusterQueue, resPerFlv resourcesPerFlavor) bool {
 		for _, fQuotas := range rg.Flavors {
 			fUsage := cq.Usage[fQuotas.Name]
 			for rName := range resPerFlv[fQuotas.Name] {
-				if fUsage[rName] > fQuotas.Resources[rName].Nominal {
+				if fUsage[rName] > fQuotas.Resources[rName].OvercommittedNominal() {
 					return true
 				}
 			}
@@ -324,10 +454,26 @@ func totalRequestsForAssignment(wl *workload.Info, assignment flavorassigner.Ass
 	return usage
 }
 
+// fitsWithinNominal returns whether the workload requests fit within the
+// nominal quota of the ClusterQueue, scaled by the overcommit ratio, on top of
+// its current usage.
+func fitsWithinNominal(wlReq cache.FlavorResourceQuantities, cq *cache.ClusterQueue) bool {
+	for _, rg := range cq.ResourceGroups {
+		for _, flvQuotas := range rg.Flavors {
+			for rName, rReq := range wlReq[flvQuotas.Name] {
+				if cq.Usage[flvQuotas.Name][rName]+rReq > flvQuotas.Resources[rName].OvercommittedNominal() {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
 // workloadFits determines if the workload requests would fits given the
 // requestable resources and simulated usage of the ClusterQueue and its cohort,
 // if it belongs to one.
-func workloadFits(wlReq cache.FlavorResourceQuantities, cq *cache.ClusterQueue, allowBorrowing bool) bool {
+func workloadFits(wlReq cache.FlavorResourceQuantities, cq *cache.ClusterQueue, class string, allowBorrowing bool) bool {
 	for _, rg := range cq.ResourceGroups {
 		for _, flvQuotas := range rg.Flavors {
 			flvReq, found := wlReq[flvQuotas.Name]
@@ -336,21 +482,26 @@ func workloadFits(wlReq cache.FlavorResourceQuantities, cq *cache.ClusterQueue,
 				continue
 			}
 			cqResUsage := cq.Usage[flvQuotas.Name]
-			var cohortResUsage, cohortResRequestable map[corev1.ResourceName]int64
+			var cohortResUsage map[corev1.ResourceName]int64
 			if cq.Cohort != nil {
 				cohortResUsage = cq.Cohort.Usage[flvQuotas.Name]
-				cohortResRequestable = cq.Cohort.RequestableResources[flvQuotas.Name]
 			}
 			for rName, rReq := range flvReq {
-				limit := flvQuotas.Resources[rName].Nominal
-				if flvQuotas.Resources[rName].BorrowingLimit != nil && allowBorrowing {
-					limit += *flvQuotas.Resources[rName].BorrowingLimit
+				rQuota := flvQuotas.Resources[rName]
+				nominal := rQuota.OvercommittedNominal()
+				limit := nominal
+				if rQuota.BorrowingLimit != nil && allowBorrowing {
+					limit += *rQuota.BorrowingLimit
 				}
-				if cqResUsage[rName]+rReq > limit {
+				if cqResUsage[rName]+cq.ReservedClassQuota(class, flvQuotas.Name, rName)+rReq > limit {
 					return false
 				}
-				if cq.Cohort != nil && cohortResUsage[rName]+rReq > cohortResRequestable[rName] {
-					return false
+				if cq.Cohort != nil && cohortResUsage[rName]+rReq > cq.RequestableCohortQuota(flvQuotas.Name, rName)+nominal-rQuota.Nominal {
+					// Once the cohort is exhausted, the quota can only be
+					// borrowed from the parent cohort.
+					if !allowBorrowing || !cq.Cohort.ParentCanLend(flvQuotas.Name, rName, rReq) {
+						return false
+					}
 				}
 			}
 		}
@@ -29,6 +29,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -37,6 +38,7 @@ import (
 	"sigs.k8s.io/kueue/pkg/cache"
 	"sigs.k8s.io/kueue/pkg/constants"
 	"sigs.k8s.io/kueue/pkg/scheduler/flavorassigner"
+	"sigs.k8s.io/kueue/pkg/util/pointer"
 	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
 	"sigs.k8s.io/kueue/pkg/workload"
 )
@@ -101,6 +103,44 @@ func TestPreemption(t *testing.T) {
 				ReclaimWithinCohort: kueue.PreemptionPolicyLowerPriority,
 			}).
 			Obj(),
+		utiltesting.MakeClusterQueue("r1").
+			Cohort("reclaim").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").
+				Resource(corev1.ResourceCPU, "6", "6").
+				Obj(),
+			).
+			Preemption(kueue.ClusterQueuePreemption{
+				ReclaimWithinCohort: kueue.PreemptionPolicyLowerPriority,
+				BorrowWithinCohort: &kueue.BorrowWithinCohort{
+					Policy:               kueue.BorrowWithinCohortPolicyReclaimBorrowed,
+					MaxPriorityThreshold: pointer.Int32(5),
+				},
+			}).
+			Obj(),
+		utiltesting.MakeClusterQueue("r2").
+			Cohort("reclaim").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").
+				Resource(corev1.ResourceCPU, "6", "6").
+				Obj(),
+			).
+			Obj(),
+		utiltesting.MakeClusterQueue("h1").
+			Cohort("child").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").
+				Resource(corev1.ResourceCPU, "6", "12").
+				Obj(),
+			).
+			Preemption(kueue.ClusterQueuePreemption{
+				WithinClusterQueue: kueue.PreemptionPolicyLowerPriority,
+			}).
+			Obj(),
+		utiltesting.MakeClusterQueue("f1").
+			Cohort("federation").
+			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").
+				Resource(corev1.ResourceCPU, "6").
+				Obj(),
+			).
+			Obj(),
 		utiltesting.MakeClusterQueue("preventStarvation").
 			ResourceGroup(*utiltesting.MakeFlavorQuotas("default").
 				Resource(corev1.ResourceCPU, "6").
@@ -112,11 +152,13 @@ func TestPreemption(t *testing.T) {
 			Obj(),
 	}
 	cases := map[string]struct {
-		admitted      []kueue.Workload
-		incoming      *kueue.Workload
-		targetCQ      string
-		assignment    flavorassigner.Assignment
-		wantPreempted sets.Set[string]
+		admitted        []kueue.Workload
+		incoming        *kueue.Workload
+		targetCQ        string
+		assignment      flavorassigner.Assignment
+		classShares     map[string]float64
+		overcommitRatio *float64
+		wantPreempted   sets.Set[string]
 	}{
 		"preempt lowest priority": {
 			admitted: []kueue.Workload{
@@ -594,6 +636,87 @@ func TestPreemption(t *testing.T) {
 			}),
 			wantPreempted: sets.New("/c1-1"),
 		},
+		"reclaim borrowed quota from higher priority under the threshold": {
+			admitted: []kueue.Workload{
+				*utiltesting.MakeWorkload("r2-mid", "").
+					Priority(3).
+					Request(corev1.ResourceCPU, "4").
+					Admit(utiltesting.MakeAdmission("r2").Assignment(corev1.ResourceCPU, "default", "4000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("r2-high", "").
+					Priority(8).
+					Request(corev1.ResourceCPU, "8").
+					Admit(utiltesting.MakeAdmission("r2").Assignment(corev1.ResourceCPU, "default", "8000m").Obj()).
+					Obj(),
+			},
+			incoming: utiltesting.MakeWorkload("in", "").
+				Priority(1).
+				Request(corev1.ResourceCPU, "3").
+				Obj(),
+			targetCQ: "r1",
+			assignment: singlePodSetAssignment(flavorassigner.ResourceAssignment{
+				corev1.ResourceCPU: &flavorassigner.FlavorAssignment{
+					Name: "default",
+					Mode: flavorassigner.Preempt,
+				},
+			}),
+			wantPreempted: sets.New("/r2-mid"),
+		},
+		"do not reclaim borrowed quota from higher priority above the threshold": {
+			admitted: []kueue.Workload{
+				*utiltesting.MakeWorkload("r2-mid", "").
+					Priority(6).
+					Request(corev1.ResourceCPU, "4").
+					Admit(utiltesting.MakeAdmission("r2").Assignment(corev1.ResourceCPU, "default", "4000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("r2-high", "").
+					Priority(8).
+					Request(corev1.ResourceCPU, "8").
+					Admit(utiltesting.MakeAdmission("r2").Assignment(corev1.ResourceCPU, "default", "8000m").Obj()).
+					Obj(),
+			},
+			incoming: utiltesting.MakeWorkload("in", "").
+				Priority(1).
+				Request(corev1.ResourceCPU, "3").
+				Obj(),
+			targetCQ: "r1",
+			assignment: singlePodSetAssignment(flavorassigner.ResourceAssignment{
+				corev1.ResourceCPU: &flavorassigner.FlavorAssignment{
+					Name: "default",
+					Mode: flavorassigner.Preempt,
+				},
+			}),
+		},
+		"do not reclaim borrowed quota from higher priority when the preemptor would borrow": {
+			admitted: []kueue.Workload{
+				*utiltesting.MakeWorkload("r1-low", "").
+					Priority(1).
+					Request(corev1.ResourceCPU, "4").
+					Admit(utiltesting.MakeAdmission("r1").Assignment(corev1.ResourceCPU, "default", "4000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("r2-mid", "").
+					Priority(3).
+					Request(corev1.ResourceCPU, "3").
+					Admit(utiltesting.MakeAdmission("r2").Assignment(corev1.ResourceCPU, "default", "3000m").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("r2-high", "").
+					Priority(8).
+					Request(corev1.ResourceCPU, "5").
+					Admit(utiltesting.MakeAdmission("r2").Assignment(corev1.ResourceCPU, "default", "5000m").Obj()).
+					Obj(),
+			},
+			incoming: utiltesting.MakeWorkload("in", "").
+				Priority(1).
+				Request(corev1.ResourceCPU, "3").
+				Obj(),
+			targetCQ: "r1",
+			assignment: singlePodSetAssignment(flavorassigner.ResourceAssignment{
+				corev1.ResourceCPU: &flavorassigner.FlavorAssignment{
+					Name: "default",
+					Mode: flavorassigner.Preempt,
+				},
+			}),
+		},
 		"preempt from all ClusterQueues in cohort": {
 			admitted: []kueue.Workload{
 				*utiltesting.MakeWorkload("c1-low", "").
@@ -742,6 +865,115 @@ func TestPreemption(t *testing.T) {
 			},
 			wantPreempted: sets.New("/wl2"),
 		},
+		"preempt only workloads of the same class": {
+			admitted: []kueue.Workload{
+				*utiltesting.MakeWorkload("low-training", "").
+					Priority(-1).
+					Label(kueue.WorkloadClassLabel, "training").
+					Request(corev1.ResourceCPU, "2").
+					Admit(utiltesting.MakeAdmission("standalone").Assignment(corev1.ResourceCPU, "default", "2").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("mid-inference", "").
+					Label(kueue.WorkloadClassLabel, "inference").
+					Request(corev1.ResourceCPU, "2").
+					Admit(utiltesting.MakeAdmission("standalone").Assignment(corev1.ResourceCPU, "default", "2").Obj()).
+					Obj(),
+			},
+			incoming: utiltesting.MakeWorkload("in", "").
+				Priority(1).
+				Label(kueue.WorkloadClassLabel, "inference").
+				Request(corev1.ResourceCPU, "4").
+				Obj(),
+			targetCQ: "standalone",
+			assignment: singlePodSetAssignment(flavorassigner.ResourceAssignment{
+				corev1.ResourceCPU: &flavorassigner.FlavorAssignment{
+					Name: "default",
+					Mode: flavorassigner.Preempt,
+				},
+			}),
+			classShares:   map[string]float64{"inference": 0.5},
+			wantPreempted: sets.New("/mid-inference"),
+		},
+		"preempt in ClusterQueue to borrow from the parent cohort": {
+			admitted: []kueue.Workload{
+				*utiltesting.MakeWorkload("low", "").
+					Priority(-2).
+					Request(corev1.ResourceCPU, "4").
+					Admit(utiltesting.MakeAdmission("h1").Assignment(corev1.ResourceCPU, "default", "4").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("mid", "").
+					Priority(-1).
+					Request(corev1.ResourceCPU, "2").
+					Admit(utiltesting.MakeAdmission("h1").Assignment(corev1.ResourceCPU, "default", "2").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("other", "").
+					Request(corev1.ResourceCPU, "2").
+					Admit(utiltesting.MakeAdmission("f1").Assignment(corev1.ResourceCPU, "default", "2").Obj()).
+					Obj(),
+			},
+			incoming: utiltesting.MakeWorkload("in", "").
+				Request(corev1.ResourceCPU, "8").
+				Obj(),
+			targetCQ: "h1",
+			assignment: singlePodSetAssignment(flavorassigner.ResourceAssignment{
+				corev1.ResourceCPU: &flavorassigner.FlavorAssignment{
+					Name: "default",
+					Mode: flavorassigner.Preempt,
+				},
+			}),
+			wantPreempted: sets.New("/low"),
+		},
+		"preempt to fit within the nominal quota": {
+			admitted: []kueue.Workload{
+				*utiltesting.MakeWorkload("low", "").
+					Priority(-2).
+					Request(corev1.ResourceCPU, "4").
+					Admit(utiltesting.MakeAdmission("standalone").Assignment(corev1.ResourceCPU, "default", "4").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("mid", "").
+					Priority(-1).
+					Request(corev1.ResourceCPU, "4").
+					Admit(utiltesting.MakeAdmission("standalone").Assignment(corev1.ResourceCPU, "default", "4").Obj()).
+					Obj(),
+			},
+			incoming: utiltesting.MakeWorkload("in", "").
+				Request(corev1.ResourceCPU, "6").
+				Obj(),
+			targetCQ: "standalone",
+			assignment: singlePodSetAssignment(flavorassigner.ResourceAssignment{
+				corev1.ResourceCPU: &flavorassigner.FlavorAssignment{
+					Name: "default",
+					Mode: flavorassigner.Preempt,
+				},
+			}),
+			wantPreempted: sets.New("/low", "/mid"),
+		},
+		"preempt to fit within the overcommitted nominal quota": {
+			admitted: []kueue.Workload{
+				*utiltesting.MakeWorkload("low", "").
+					Priority(-2).
+					Request(corev1.ResourceCPU, "4").
+					Admit(utiltesting.MakeAdmission("standalone").Assignment(corev1.ResourceCPU, "default", "4").Obj()).
+					Obj(),
+				*utiltesting.MakeWorkload("mid", "").
+					Priority(-1).
+					Request(corev1.ResourceCPU, "4").
+					Admit(utiltesting.MakeAdmission("standalone").Assignment(corev1.ResourceCPU, "default", "4").Obj()).
+					Obj(),
+			},
+			incoming: utiltesting.MakeWorkload("in", "").
+				Request(corev1.ResourceCPU, "6").
+				Obj(),
+			targetCQ: "standalone",
+			assignment: singlePodSetAssignment(flavorassigner.ResourceAssignment{
+				corev1.ResourceCPU: &flavorassigner.FlavorAssignment{
+					Name: "default",
+					Mode: flavorassigner.Preempt,
+				},
+			}),
+			overcommitRatio: pointer.Float64(2),
+			wantPreempted:   sets.New("/low"),
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -753,7 +985,7 @@ func TestPreemption(t *testing.T) {
 				WithLists(&kueue.WorkloadList{Items: tc.admitted}).
 				Build()
 
-			cqCache := cache.New(cl)
+			cqCache := cache.New(cl, cache.WithCohortParents(map[string]string{"child": "federation"}))
 			for _, flv := range flavors {
 				cqCache.AddOrUpdateResourceFlavor(flv)
 			}
@@ -777,6 +1009,23 @@ func TestPreemption(t *testing.T) {
 			}
 
 			snapshot := cqCache.Snapshot()
+			if tc.classShares != nil {
+				for i := range snapshot.ClusterQueues[tc.targetCQ].ResourceGroups {
+					rg := &snapshot.ClusterQueues[tc.targetCQ].ResourceGroups[i]
+					for j := range rg.Flavors {
+						rg.Flavors[j].ClassShares = tc.classShares
+					}
+				}
+			}
+			if tc.overcommitRatio != nil {
+				for _, rg := range snapshot.ClusterQueues[tc.targetCQ].ResourceGroups {
+					for _, flvQuotas := range rg.Flavors {
+						for _, rQuota := range flvQuotas.Resources {
+							rQuota.OvercommitRatio = tc.overcommitRatio
+						}
+					}
+				}
+			}
 			wlInfo := workload.NewInfo(tc.incoming)
 			wlInfo.ClusterQueue = tc.targetCQ
 			preempted, err := preemptor.Do(ctx, *wlInfo, tc.assignment, &snapshot)
@@ -793,6 +1042,116 @@ func TestPreemption(t *testing.T) {
 	}
 }
 
+func TestCanReclaimFrom(t *testing.T) {
+	cases := map[string]struct {
+		preemption        kueue.ClusterQueuePreemption
+		borrowing         bool
+		candidatePriority int32
+		want              bool
+	}{
+		"never": {
+			preemption: kueue.ClusterQueuePreemption{
+				ReclaimWithinCohort: kueue.PreemptionPolicyNever,
+			},
+			candidatePriority: -1,
+		},
+		"lower priority": {
+			preemption: kueue.ClusterQueuePreemption{
+				ReclaimWithinCohort: kueue.PreemptionPolicyLowerPriority,
+			},
+			candidatePriority: -1,
+			want:              true,
+		},
+		"higher priority without borrowWithinCohort": {
+			preemption: kueue.ClusterQueuePreemption{
+				ReclaimWithinCohort: kueue.PreemptionPolicyLowerPriority,
+			},
+			candidatePriority: 3,
+		},
+		"higher priority with any": {
+			preemption: kueue.ClusterQueuePreemption{
+				ReclaimWithinCohort: kueue.PreemptionPolicyAny,
+			},
+			candidatePriority: 10,
+			want:              true,
+		},
+		"borrowWithinCohort never": {
+			preemption: kueue.ClusterQueuePreemption{
+				ReclaimWithinCohort: kueue.PreemptionPolicyLowerPriority,
+				BorrowWithinCohort: &kueue.BorrowWithinCohort{
+					Policy: kueue.BorrowWithinCohortPolicyNever,
+				},
+			},
+			candidatePriority: 3,
+		},
+		"higher priority without threshold": {
+			preemption: kueue.ClusterQueuePreemption{
+				BorrowWithinCohort: &kueue.BorrowWithinCohort{
+					Policy: kueue.BorrowWithinCohortPolicyReclaimBorrowed,
+				},
+			},
+			candidatePriority: 10,
+			want:              true,
+		},
+		"higher priority under the threshold": {
+			preemption: kueue.ClusterQueuePreemption{
+				BorrowWithinCohort: &kueue.BorrowWithinCohort{
+					Policy:               kueue.BorrowWithinCohortPolicyReclaimBorrowed,
+					MaxPriorityThreshold: pointer.Int32(5),
+				},
+			},
+			candidatePriority: 3,
+			want:              true,
+		},
+		"higher priority at the threshold": {
+			preemption: kueue.ClusterQueuePreemption{
+				BorrowWithinCohort: &kueue.BorrowWithinCohort{
+					Policy:               kueue.BorrowWithinCohortPolicyReclaimBorrowed,
+					MaxPriorityThreshold: pointer.Int32(5),
+				},
+			},
+			candidatePriority: 5,
+			want:              true,
+		},
+		"higher priority above the threshold": {
+			preemption: kueue.ClusterQueuePreemption{
+				BorrowWithinCohort: &kueue.BorrowWithinCohort{
+					Policy:               kueue.BorrowWithinCohortPolicyReclaimBorrowed,
+					MaxPriorityThreshold: pointer.Int32(5),
+				},
+			},
+			candidatePriority: 6,
+		},
+		"higher priority when the preemptor would borrow": {
+			preemption: kueue.ClusterQueuePreemption{
+				BorrowWithinCohort: &kueue.BorrowWithinCohort{
+					Policy: kueue.BorrowWithinCohortPolicyReclaimBorrowed,
+				},
+			},
+			borrowing:         true,
+			candidatePriority: 3,
+		},
+		"lower priority when the preemptor would borrow": {
+			preemption: kueue.ClusterQueuePreemption{
+				ReclaimWithinCohort: kueue.PreemptionPolicyLowerPriority,
+				BorrowWithinCohort: &kueue.BorrowWithinCohort{
+					Policy: kueue.BorrowWithinCohortPolicyReclaimBorrowed,
+				},
+			},
+			borrowing:         true,
+			candidatePriority: -1,
+			want:              true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := CanReclaimFrom(tc.preemption, !tc.borrowing, 1, tc.candidatePriority); got != tc.want {
+				t.Errorf("CanReclaimFrom(_, %t, 1, %d)=%t, want %t", !tc.borrowing, tc.candidatePriority, got, tc.want)
+			}
+		})
+	}
+}
+
 func TestCandidatesOrdering(t *testing.T) {
 	now := time.Now()
 	candidates := []*workload.Info{
@@ -832,6 +1191,129 @@ func TestCandidatesOrdering(t *testing.T) {
 	}
 }
 
+func TestMinimalPreemptionSet(t *testing.T) {
+	candidates := []*workload.Info{
+		workload.NewInfo(utiltesting.MakeWorkload("small", "").
+			Request(corev1.ResourceCPU, "1").
+			Obj()),
+		workload.NewInfo(utiltesting.MakeWorkload("memory", "").
+			Request(corev1.ResourceMemory, "1Gi").
+			Obj()),
+		workload.NewInfo(utiltesting.MakeWorkload("medium", "").
+			Request(corev1.ResourceCPU, "2").
+			Obj()),
+		workload.NewInfo(utiltesting.MakeWorkload("large", "").
+			Request(corev1.ResourceCPU, "4").
+			Obj()),
+	}
+	cases := map[string]struct {
+		shortfall   workload.Requests
+		candidates  []*workload.Info
+		wantTargets []string
+	}{
+		"exact cover": {
+			shortfall:   workload.Requests{corev1.ResourceCPU: 3_000},
+			candidates:  candidates,
+			wantTargets: []string{"/small", "/medium"},
+		},
+		"over cover drops unnecessary targets": {
+			shortfall:   workload.Requests{corev1.ResourceCPU: 3_000},
+			candidates:  []*workload.Info{candidates[0], candidates[3]},
+			wantTargets: []string{"/large"},
+		},
+		"skips candidates not using the resources": {
+			shortfall:   workload.Requests{corev1.ResourceMemory: utiltesting.Gi},
+			candidates:  candidates,
+			wantTargets: []string{"/memory"},
+		},
+		"not enough candidates": {
+			shortfall:  workload.Requests{corev1.ResourceCPU: 8_000},
+			candidates: candidates,
+		},
+		"no shortfall": {
+			shortfall:  workload.Requests{},
+			candidates: candidates,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			targets := MinimalPreemptionSet(tc.shortfall, tc.candidates)
+			var gotTargets []string
+			for _, wl := range targets {
+				gotTargets = append(gotTargets, workload.Key(wl.Obj))
+			}
+			if diff := cmp.Diff(tc.wantTargets, gotTargets); diff != "" {
+				t.Errorf("Unexpected targets (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSameWorkload(t *testing.T) {
+	owner := metav1.OwnerReference{
+		APIVersion: "batch/v1",
+		Kind:       "Job",
+		Name:       "job",
+		UID:        "job-uid",
+		Controller: pointer.Bool(true),
+	}
+	withOwner := func(name string, uid types.UID, owner metav1.OwnerReference) *workload.Info {
+		wl := utiltesting.MakeWorkload(name, "ns").Obj()
+		wl.UID = uid
+		wl.OwnerReferences = []metav1.OwnerReference{owner}
+		return workload.NewInfo(wl)
+	}
+	otherOwner := owner
+	otherOwner.UID = "other-uid"
+	cases := map[string]struct {
+		a, b *workload.Info
+		want bool
+	}{
+		"same key": {
+			a:    workload.NewInfo(utiltesting.MakeWorkload("a", "ns").Obj()),
+			b:    workload.NewInfo(utiltesting.MakeWorkload("a", "ns").Obj()),
+			want: true,
+		},
+		"different key": {
+			a: workload.NewInfo(utiltesting.MakeWorkload("a", "ns").Obj()),
+			b: workload.NewInfo(utiltesting.MakeWorkload("b", "ns").Obj()),
+		},
+		"same owner": {
+			a:    withOwner("a", "a-uid", owner),
+			b:    withOwner("b", "b-uid", owner),
+			want: true,
+		},
+		"different owners": {
+			a: withOwner("a", "a-uid", owner),
+			b: withOwner("b", "b-uid", otherOwner),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := SameWorkload(tc.a, tc.b); got != tc.want {
+				t.Errorf("SameWorkload()=%t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExcludeSameWorkload(t *testing.T) {
+	preemptor := workload.NewInfo(utiltesting.MakeWorkload("preemptor", "ns").Obj())
+	candidates := []*workload.Info{
+		workload.NewInfo(utiltesting.MakeWorkload("low", "ns").Obj()),
+		workload.NewInfo(utiltesting.MakeWorkload("preemptor", "ns").Obj()),
+		workload.NewInfo(utiltesting.MakeWorkload("other", "ns").Obj()),
+	}
+	var got []string
+	for _, wl := range excludeSameWorkload(candidates, preemptor) {
+		got = append(got, workload.Key(wl.Obj))
+	}
+	want := []string{"ns/low", "ns/other"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected candidates (-want,+got):\n%s", diff)
+	}
+}
+
 func singlePodSetAssignment(assignments flavorassigner.ResourceAssignment) flavorassigner.Assignment {
 	return flavorassigner.Assignment{
 		PodSets: []flavorassigner.PodSetAssignment{{
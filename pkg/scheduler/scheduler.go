@@ -18,6 +18,7 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -25,7 +26,7 @@ import (
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -258,6 +259,8 @@ func (s *Scheduler) nominate(ctx context.Context, workloads []workload.Info, sna
 			e.requeueReason = queue.RequeueReasonNamespaceMismatch
 		} else if err := s.validateResources(&w); err != nil {
 			e.inadmissibleMsg = err.Error()
+		} else if err := w.Validate(); err != nil {
+			e.inadmissibleMsg = err.Error()
 		} else if err := s.validateLimitRange(ctx, &w); err != nil {
 			e.inadmissibleMsg = err.Error()
 		} else {
@@ -358,7 +361,7 @@ func (s *Scheduler) admit(ctx context.Context, e *entry) error {
 		// Ignore errors because the workload or clusterQueue could have been deleted
 		// by an event.
 		_ = s.cache.ForgetWorkload(newWorkload)
-		if errors.IsNotFound(err) {
+		if apierrors.IsNotFound(err) {
 			log.V(2).Info("Workload not admitted because it was deleted")
 			return
 		}
@@ -407,8 +410,14 @@ func (s *Scheduler) requeueAndUpdate(log logr.Logger, ctx context.Context, e ent
 		// Failed after nomination is the only reason why a workload would be requeued downstream.
 		e.requeueReason = queue.RequeueReasonFailedAfterNomination
 	}
-	added := s.queues.RequeueWorkload(ctx, &e.Info, e.requeueReason)
-	log.V(2).Info("Workload re-queued", "workload", klog.KObj(e.Obj), "clusterQueue", klog.KRef("", e.ClusterQueue), "queue", klog.KRef(e.Obj.Namespace, e.Obj.Spec.QueueName), "requeueReason", e.requeueReason, "added", added)
+	if errors.Is(e.assignment.Err(), flavorassigner.ErrNoPodSets) {
+		// The workload can never be admitted as is, so it's not requeued.
+		// It will be added back to the queue if it's updated.
+		log.V(2).Info("Workload rejected", "workload", klog.KObj(e.Obj), "clusterQueue", klog.KRef("", e.ClusterQueue), "reason", e.inadmissibleMsg)
+	} else {
+		added := s.queues.RequeueWorkload(ctx, &e.Info, e.requeueReason)
+		log.V(2).Info("Workload re-queued", "workload", klog.KObj(e.Obj), "clusterQueue", klog.KRef("", e.ClusterQueue), "queue", klog.KRef(e.Obj.Namespace, e.Obj.Spec.QueueName), "requeueReason", e.requeueReason, "added", added)
+	}
 
 	if e.status == notNominated {
 		workload.UnsetAdmissionWithCondition(e.Obj, "Pending", e.inadmissibleMsg)
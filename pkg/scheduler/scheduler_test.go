@@ -33,6 +33,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -209,6 +210,7 @@ func TestSchedule(t *testing.T) {
 							ResourceUsage: corev1.ResourceList{
 								corev1.ResourceCPU: resource.MustParse("10000m"),
 							},
+							Count: pointer.Int32(10),
 						},
 					},
 				},
@@ -287,7 +289,7 @@ func TestSchedule(t *testing.T) {
 					Obj(),
 				*utiltesting.MakeWorkload("new", "eng-alpha").
 					Queue("main").
-					PodSets(*utiltesting.MakePodSet("one", 51 /* Will borrow */).
+					PodSets(*utiltesting.MakePodSet("one", 51 /* Would borrow in on-demand */).
 						Request(corev1.ResourceCPU, "1").
 						Obj()).
 					Obj(),
@@ -304,6 +306,7 @@ func TestSchedule(t *testing.T) {
 							ResourceUsage: corev1.ResourceList{
 								corev1.ResourceCPU: resource.MustParse("1000m"),
 							},
+							Count: pointer.Int32(1),
 						},
 					},
 				},
@@ -313,11 +316,12 @@ func TestSchedule(t *testing.T) {
 						{
 							Name: "one",
 							Flavors: map[corev1.ResourceName]kueue.ResourceFlavorReference{
-								corev1.ResourceCPU: "on-demand",
+								corev1.ResourceCPU: "spot",
 							},
 							ResourceUsage: corev1.ResourceList{
 								corev1.ResourceCPU: resource.MustParse("51000m"),
 							},
+							Count: pointer.Int32(51),
 						},
 					},
 				},
@@ -351,6 +355,7 @@ func TestSchedule(t *testing.T) {
 							ResourceUsage: corev1.ResourceList{
 								corev1.ResourceCPU: resource.MustParse("40000m"),
 							},
+							Count: pointer.Int32(40),
 						},
 					},
 				},
@@ -365,6 +370,7 @@ func TestSchedule(t *testing.T) {
 							ResourceUsage: corev1.ResourceList{
 								corev1.ResourceCPU: resource.MustParse("40000m"),
 							},
+							Count: pointer.Int32(40),
 						},
 					},
 				},
@@ -400,6 +406,7 @@ func TestSchedule(t *testing.T) {
 								corev1.ResourceCPU: resource.MustParse("60000m"),
 								"example.com/gpu":  resource.MustParse("10"),
 							},
+							Count: pointer.Int32(10),
 						},
 						{
 							Name: "two",
@@ -409,6 +416,7 @@ func TestSchedule(t *testing.T) {
 							ResourceUsage: corev1.ResourceList{
 								corev1.ResourceCPU: resource.MustParse("40000m"),
 							},
+							Count: pointer.Int32(40),
 						},
 					},
 				},
@@ -442,6 +450,7 @@ func TestSchedule(t *testing.T) {
 							ResourceUsage: corev1.ResourceList{
 								corev1.ResourceCPU: resource.MustParse("40000m"),
 							},
+							Count: pointer.Int32(40),
 						},
 					},
 				},
@@ -555,6 +564,7 @@ func TestSchedule(t *testing.T) {
 							ResourceUsage: corev1.ResourceList{
 								corev1.ResourceCPU: resource.MustParse("60000m"),
 							},
+							Count: pointer.Int32(60),
 						},
 					},
 				},
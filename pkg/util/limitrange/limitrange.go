@@ -59,7 +59,6 @@ func violateMaxMessage(path *field.Path, keys ...string) string {
 func violateMinMessage(path *field.Path, keys ...string) string {
 	return fmt.Sprintf("the requests of %s[%s] are less than the limits", path.String(), strings.Join(keys, ", "))
 }
-
 func (s Summary) validatePodSpecContainers(containers []corev1.Container, path *field.Path) []string {
 	containerRange, found := s[corev1.LimitTypeContainer]
 	if !found {
@@ -82,6 +81,10 @@ func (s Summary) validatePodSpecContainers(containers []corev1.Container, path *
 
 // TotalRequests computes the total resource requests of a pod.
 // total = sum(max(sum(.containers[].requests), initContainers[].requests), overhead)
+// All the init containers are accounted as regular ones, which run before the
+// main containers. Restartable (sidecar) init containers are declared with a
+// container restartPolicy that the k8s.io/api v0.26 types don't have, so they
+// can't be told apart and their requests are not added to the sum.
 func TotalRequests(ps *corev1.PodSpec) corev1.ResourceList {
 	total := corev1.ResourceList{}
 
@@ -219,6 +219,17 @@ func TestTotalRequest(t *testing.T) {
 				"example.com/gpu":     resource.MustParse("2"),
 			},
 		},
+		"init containers below the main containers": {
+			podSpec: &corev1.PodSpec{
+				InitContainers: containers[1:2],
+				Containers:     containers[:2],
+			},
+			want: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("2500m"),
+				corev1.ResourceMemory: resource.MustParse("2Gi"),
+				"example.com/gpu":     resource.MustParse("2"),
+			},
+		},
 		"adds overhead": {
 			podSpec: &corev1.PodSpec{
 				InitContainers: containers[2:],
@@ -18,6 +18,7 @@ package testing
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -26,6 +27,8 @@ import (
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 
 	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
 	"sigs.k8s.io/kueue/pkg/util/pointer"
@@ -71,10 +74,39 @@ func MakeWorkload(name, ns string) *WorkloadWrapper {
 	}}
 }
 
+// MakeWorkloadWithPodSets creates a wrapper for a Workload with one pod set
+// per entry in counts, with the given number of pods. The pod sets are sorted
+// by name.
+func MakeWorkloadWithPodSets(name, ns string, counts map[string]int32) *WorkloadWrapper {
+	names := make([]string, 0, len(counts))
+	for psName := range counts {
+		names = append(names, psName)
+	}
+	sort.Strings(names)
+	podSets := make([]kueue.PodSet, len(names))
+	for i, psName := range names {
+		podSets[i] = *MakePodSet(psName, int(counts[psName])).Obj()
+	}
+	return &WorkloadWrapper{kueue.Workload{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+		Spec: kueue.WorkloadSpec{
+			PodSets: podSets,
+		},
+	}}
+}
+
 func (w *WorkloadWrapper) Obj() *kueue.Workload {
 	return &w.Workload
 }
 
+// GenerateName sets the prefix for the API server to generate a unique name,
+// clearing any explicit name.
+func (w *WorkloadWrapper) GenerateName(prefix string) *WorkloadWrapper {
+	w.Name = ""
+	w.ObjectMeta.GenerateName = prefix
+	return w
+}
+
 func (w *WorkloadWrapper) Request(r corev1.ResourceName, q string) *WorkloadWrapper {
 	w.Spec.PodSets[0].Template.Spec.Containers[0].Resources.Requests[r] = resource.MustParse(q)
 	return w
@@ -92,6 +124,16 @@ func (w *WorkloadWrapper) Limit(r corev1.ResourceName, q string) *WorkloadWrappe
 	return w
 }
 
+// TotalRequest sets a declared total request of the workload, which is used
+// instead of the sum of the requests of the pod sets.
+func (w *WorkloadWrapper) TotalRequest(r corev1.ResourceName, q string) *WorkloadWrapper {
+	if w.Spec.TotalRequests == nil {
+		w.Spec.TotalRequests = corev1.ResourceList{}
+	}
+	w.Spec.TotalRequests[r] = resource.MustParse(q)
+	return w
+}
+
 func (w *WorkloadWrapper) Queue(q string) *WorkloadWrapper {
 	w.Spec.QueueName = q
 	return w
@@ -109,11 +151,30 @@ func (w *WorkloadWrapper) Admit(a *kueue.Admission) *WorkloadWrapper {
 	return w
 }
 
+// ReserveQuota sets the admission and the QuotaReserved condition, without
+// marking the workload as Admitted.
+func (w *WorkloadWrapper) ReserveQuota(a *kueue.Admission) *WorkloadWrapper {
+	w.Status.Admission = a
+	apimeta.SetStatusCondition(&w.Status.Conditions, metav1.Condition{
+		Type:               kueue.WorkloadQuotaReserved,
+		Status:             metav1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "QuotaReservedByTest",
+		Message:            fmt.Sprintf("Quota reserved in ClusterQueue %s", w.Status.Admission.ClusterQueue),
+	})
+	return w
+}
+
 func (w *WorkloadWrapper) Creation(t time.Time) *WorkloadWrapper {
 	w.CreationTimestamp = metav1.NewTime(t)
 	return w
 }
 
+// Deadline sets the deadline annotation of the workload.
+func (w *WorkloadWrapper) Deadline(t time.Time) *WorkloadWrapper {
+	return w.Annotation(kueue.DeadlineAnnotation, t.Format(time.RFC3339))
+}
+
 func (w *WorkloadWrapper) PriorityClass(priorityClassName string) *WorkloadWrapper {
 	w.Spec.PriorityClassName = priorityClassName
 	return w
@@ -141,6 +202,67 @@ func (w *WorkloadWrapper) Toleration(t corev1.Toleration) *WorkloadWrapper {
 	return w
 }
 
+func (w *WorkloadWrapper) Label(k, v string) *WorkloadWrapper {
+	if w.Labels == nil {
+		w.Labels = make(map[string]string, 1)
+	}
+	w.Labels[k] = v
+	return w
+}
+
+func (w *WorkloadWrapper) Annotation(k, v string) *WorkloadWrapper {
+	if w.Annotations == nil {
+		w.Annotations = make(map[string]string, 1)
+	}
+	w.Annotations[k] = v
+	return w
+}
+
+// ReservationOnly marks the workload as only reserving quota, without pods.
+func (w *WorkloadWrapper) ReservationOnly() *WorkloadWrapper {
+	return w.Label(kueue.ReservationOnlyLabel, "true")
+}
+
+// AdmissionCheck adds or replaces the state of the admission check with the given name.
+func (w *WorkloadWrapper) AdmissionCheck(name string, state kueue.CheckState) *WorkloadWrapper {
+	for i := range w.Status.AdmissionChecks {
+		if w.Status.AdmissionChecks[i].Name == name {
+			w.Status.AdmissionChecks[i].State = state
+			return w
+		}
+	}
+	w.Status.AdmissionChecks = append(w.Status.AdmissionChecks, kueue.AdmissionCheckState{
+		Name:  name,
+		State: state,
+	})
+	return w
+}
+
+// OwnerReference adds an owner reference to the workload, marking the owner as
+// its controller if controller is true.
+func (w *WorkloadWrapper) OwnerReference(gvk schema.GroupVersionKind, name, uid string, controller bool) *WorkloadWrapper {
+	w.OwnerReferences = append(w.OwnerReferences, metav1.OwnerReference{
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Name:       name,
+		UID:        types.UID(uid),
+		Controller: &controller,
+	})
+	return w
+}
+
+// AdmissionCheckAt sets the state of the admission check, as AdmissionCheck,
+// and the time of its last transition.
+func (w *WorkloadWrapper) AdmissionCheckAt(name string, state kueue.CheckState, t time.Time) *WorkloadWrapper {
+	w.AdmissionCheck(name, state)
+	for i := range w.Status.AdmissionChecks {
+		if w.Status.AdmissionChecks[i].Name == name {
+			w.Status.AdmissionChecks[i].LastTransitionTime = metav1.NewTime(t)
+		}
+	}
+	return w
+}
+
 func (w *WorkloadWrapper) NodeSelector(kv map[string]string) *WorkloadWrapper {
 	w.Spec.PodSets[0].Template.Spec.NodeSelector = kv
 	return w
@@ -192,11 +314,26 @@ func (p *PodSetWrapper) Request(r corev1.ResourceName, q string) *PodSetWrapper
 	return p
 }
 
+func (p *PodSetWrapper) MinCount(c int32) *PodSetWrapper {
+	p.PodSet.MinCount = &c
+	return p
+}
+
+func (p *PodSetWrapper) ReplicaGroup(g string) *PodSetWrapper {
+	p.PodSet.ReplicaGroup = g
+	return p
+}
+
 func (p *PodSetWrapper) Toleration(t corev1.Toleration) *PodSetWrapper {
 	p.Template.Spec.Tolerations = append(p.Template.Spec.Tolerations, t)
 	return p
 }
 
+func (p *PodSetWrapper) NodeSelector(kv map[string]string) *PodSetWrapper {
+	p.Template.Spec.NodeSelector = kv
+	return p
+}
+
 // AdmissionWrapper wraps an Admission
 type AdmissionWrapper struct{ kueue.Admission }
 
@@ -238,6 +375,11 @@ func (w *AdmissionWrapper) Assignment(r corev1.ResourceName, f kueue.ResourceFla
 	return w
 }
 
+func (w *AdmissionWrapper) AssignmentPodCount(value int32) *AdmissionWrapper {
+	w.PodSetAssignments[0].Count = &value
+	return w
+}
+
 func (w *AdmissionWrapper) PodSets(podSets ...kueue.PodSetAssignment) *AdmissionWrapper {
 	w.PodSetAssignments = podSets
 	return w
@@ -267,6 +409,12 @@ func (q *LocalQueueWrapper) ClusterQueue(c string) *LocalQueueWrapper {
 	return q
 }
 
+// StopPolicy sets the stop policy of the queue.
+func (q *LocalQueueWrapper) StopPolicy(p kueue.StopPolicy) *LocalQueueWrapper {
+	q.Spec.StopPolicy = p
+	return q
+}
+
 // PendingWorkloads updates the pendingWorkloads in status.
 func (q *LocalQueueWrapper) PendingWorkloads(n int32) *LocalQueueWrapper {
 	q.Status.PendingWorkloads = n
@@ -345,6 +493,28 @@ func (c *ClusterQueueWrapper) Preemption(p kueue.ClusterQueuePreemption) *Cluste
 	return c
 }
 
+// FairSharingWeight sets the weight of the ClusterQueue in its cohort.
+func (c *ClusterQueueWrapper) FairSharingWeight(w int64) *ClusterQueueWrapper {
+	c.Spec.FairSharingWeight = &w
+	return c
+}
+
+// BorrowableFlavors restricts the flavors in which the ClusterQueue can borrow
+// the resource.
+func (c *ClusterQueueWrapper) BorrowableFlavors(r corev1.ResourceName, flavors ...kueue.ResourceFlavorReference) *ClusterQueueWrapper {
+	c.Spec.BorrowableFlavors = append(c.Spec.BorrowableFlavors, kueue.BorrowableFlavors{
+		Name:    r,
+		Flavors: flavors,
+	})
+	return c
+}
+
+// FlavorSelectionPolicy sets how to choose among equally good flavors.
+func (c *ClusterQueueWrapper) FlavorSelectionPolicy(p kueue.FlavorSelectionPolicy) *ClusterQueueWrapper {
+	c.Spec.FlavorSelectionPolicy = p
+	return c
+}
+
 // FlavorQuotasWrapper wraps a FlavorQuotas object.
 type FlavorQuotasWrapper struct{ kueue.FlavorQuotas }
 
@@ -409,6 +579,47 @@ func (rf *ResourceFlavorWrapper) Taint(t corev1.Taint) *ResourceFlavorWrapper {
 	return rf
 }
 
+// IgnoreNodeAffinity sets the ResourceFlavor to ignore the node affinity of
+// the pod sets.
+func (rf *ResourceFlavorWrapper) IgnoreNodeAffinity() *ResourceFlavorWrapper {
+	rf.Spec.IgnoreNodeAffinity = true
+	return rf
+}
+
+// SchedulerName sets the scheduler for the nodes of the ResourceFlavor.
+func (rf *ResourceFlavorWrapper) SchedulerName(name string) *ResourceFlavorWrapper {
+	rf.Spec.SchedulerName = name
+	return rf
+}
+
+func (rf *ResourceFlavorWrapper) ResourceAlias(alias, name corev1.ResourceName) *ResourceFlavorWrapper {
+	if rf.Spec.ResourceAliases == nil {
+		rf.Spec.ResourceAliases = make(map[corev1.ResourceName]corev1.ResourceName, 1)
+	}
+	rf.Spec.ResourceAliases[alias] = name
+	return rf
+}
+
+// MinRequest sets the minimum request of each pod for the resource.
+func (rf *ResourceFlavorWrapper) MinRequest(r corev1.ResourceName, q string) *ResourceFlavorWrapper {
+	if rf.Spec.MinRequests == nil {
+		rf.Spec.MinRequests = make(corev1.ResourceList, 1)
+	}
+	rf.Spec.MinRequests[r] = resource.MustParse(q)
+	return rf
+}
+
+func (rf *ResourceFlavorWrapper) Deprecated() *ResourceFlavorWrapper {
+	rf.Spec.Deprecated = true
+	return rf
+}
+
+// Unschedulable marks the ResourceFlavor as not accepting new pods.
+func (rf *ResourceFlavorWrapper) Unschedulable() *ResourceFlavorWrapper {
+	rf.Spec.Unschedulable = true
+	return rf
+}
+
 // RuntimeClassWrapper wraps a RuntimeClass.
 type RuntimeClassWrapper struct{ nodev1.RuntimeClass }
 
@@ -0,0 +1,54 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+)
+
+func TestMakeWorkloadWithPodSets(t *testing.T) {
+	got := MakeWorkloadWithPodSets("", "ns", map[string]int32{
+		"workers":  10,
+		"driver":   1,
+		"launcher": 2,
+	}).GenerateName("wl-").Obj()
+	want := &kueue.Workload{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: "wl-", Namespace: "ns"},
+		Spec: kueue.WorkloadSpec{
+			PodSets: []kueue.PodSet{
+				*MakePodSet("driver", 1).Obj(),
+				*MakePodSet("launcher", 2).Obj(),
+				*MakePodSet("workers", 10).Obj(),
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected workload (-want,+got):\n%s", diff)
+	}
+}
+
+func TestWorkloadGenerateName(t *testing.T) {
+	got := MakeWorkload("name", "ns").GenerateName("wl-").Obj()
+	if got.Name != "" || got.GenerateName != "wl-" {
+		t.Errorf("Got name %q and generateName %q, want empty name and generateName %q", got.Name, got.GenerateName, "wl-")
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tolerations
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+var validEffects = sets.New(
+	string(corev1.TaintEffectNoSchedule),
+	string(corev1.TaintEffectPreferNoSchedule),
+	string(corev1.TaintEffectNoExecute),
+)
+
+// NormalizeTolerations returns the tolerations with the empty operators set to
+// Equal, which is their default, and without duplicates, keeping the first
+// occurrence of each toleration. If the tolerations are already normalized,
+// they are returned as is, without allocating.
+func NormalizeTolerations(ts []corev1.Toleration) []corev1.Toleration {
+	if isNormalized(ts) {
+		return ts
+	}
+	res := make([]corev1.Toleration, 0, len(ts))
+	for _, t := range ts {
+		if t.Operator == "" {
+			t.Operator = corev1.TolerationOpEqual
+		}
+		duplicate := false
+		for i := range res {
+			if equal(&res[i], &t) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+func isNormalized(ts []corev1.Toleration) bool {
+	for i := range ts {
+		if ts[i].Operator == "" {
+			return false
+		}
+		for j := 0; j < i; j++ {
+			if equal(&ts[j], &ts[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func equal(a, b *corev1.Toleration) bool {
+	if a.Key != b.Key || a.Operator != b.Operator || a.Value != b.Value || a.Effect != b.Effect {
+		return false
+	}
+	if a.TolerationSeconds == nil || b.TolerationSeconds == nil {
+		return a.TolerationSeconds == b.TolerationSeconds
+	}
+	return *a.TolerationSeconds == *b.TolerationSeconds
+}
+
+// ValidateTolerations returns an error for each toleration with an invalid
+// combination of key, operator, value and effect.
+func ValidateTolerations(ts []corev1.Toleration, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, t := range ts {
+		path := path.Index(i)
+		switch t.Operator {
+		case corev1.TolerationOpEqual, "":
+			if t.Key == "" {
+				allErrs = append(allErrs, field.Invalid(path.Child("operator"), t.Operator, "operator must be Exists when key is empty"))
+			}
+		case corev1.TolerationOpExists:
+			if t.Value != "" {
+				allErrs = append(allErrs, field.Invalid(path.Child("value"), t.Value, "value must be empty when operator is Exists"))
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(path.Child("operator"), t.Operator, []string{string(corev1.TolerationOpEqual), string(corev1.TolerationOpExists)}))
+		}
+		if t.Effect != "" && !validEffects.Has(string(t.Effect)) {
+			allErrs = append(allErrs, field.NotSupported(path.Child("effect"), t.Effect, sets.List(validEffects)))
+		}
+		if t.TolerationSeconds != nil && t.Effect != corev1.TaintEffectNoExecute {
+			allErrs = append(allErrs, field.Invalid(path.Child("effect"), t.Effect, "effect must be NoExecute when tolerationSeconds is set"))
+		}
+	}
+	return allErrs
+}
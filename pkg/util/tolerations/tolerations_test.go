@@ -0,0 +1,139 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tolerations
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+)
+
+func TestNormalizeTolerations(t *testing.T) {
+	cases := map[string]struct {
+		tolerations []corev1.Toleration
+		want        []corev1.Toleration
+	}{
+		"nil": {},
+		"duplicates": {
+			tolerations: []corev1.Toleration{
+				{Key: "instance", Value: "spot", Effect: corev1.TaintEffectNoSchedule},
+				{Key: "gpu", Operator: corev1.TolerationOpExists},
+				{Key: "instance", Operator: corev1.TolerationOpEqual, Value: "spot", Effect: corev1.TaintEffectNoSchedule},
+				{Key: "gpu", Operator: corev1.TolerationOpExists},
+			},
+			want: []corev1.Toleration{
+				{Key: "instance", Operator: corev1.TolerationOpEqual, Value: "spot", Effect: corev1.TaintEffectNoSchedule},
+				{Key: "gpu", Operator: corev1.TolerationOpExists},
+			},
+		},
+		"different toleration seconds": {
+			tolerations: []corev1.Toleration{
+				{Key: "unreachable", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute, TolerationSeconds: pointer.Int64(60)},
+				{Key: "unreachable", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute, TolerationSeconds: pointer.Int64(300)},
+				{Key: "unreachable", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute, TolerationSeconds: pointer.Int64(60)},
+			},
+			want: []corev1.Toleration{
+				{Key: "unreachable", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute, TolerationSeconds: pointer.Int64(60)},
+				{Key: "unreachable", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute, TolerationSeconds: pointer.Int64(300)},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := NormalizeTolerations(tc.tolerations)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Unexpected tolerations (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNormalizeTolerationsNormalized(t *testing.T) {
+	ts := []corev1.Toleration{
+		{Key: "instance", Operator: corev1.TolerationOpEqual, Value: "spot", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "gpu", Operator: corev1.TolerationOpExists},
+	}
+	if allocs := testing.AllocsPerRun(10, func() {
+		NormalizeTolerations(ts)
+	}); allocs != 0 {
+		t.Errorf("NormalizeTolerations allocated %v times for normalized tolerations, want 0", allocs)
+	}
+	if got := NormalizeTolerations(ts); &got[0] != &ts[0] {
+		t.Errorf("NormalizeTolerations returned a copy of normalized tolerations")
+	}
+}
+
+func TestValidateTolerations(t *testing.T) {
+	path := field.NewPath("spec", "tolerations")
+	cases := map[string]struct {
+		tolerations []corev1.Toleration
+		wantErr     field.ErrorList
+	}{
+		"valid": {
+			tolerations: []corev1.Toleration{
+				{Key: "instance", Value: "spot", Effect: corev1.TaintEffectNoSchedule},
+				{Operator: corev1.TolerationOpExists},
+				{Key: "unreachable", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute, TolerationSeconds: pointer.Int64(60)},
+			},
+		},
+		"exists with value": {
+			tolerations: []corev1.Toleration{
+				{Key: "instance", Operator: corev1.TolerationOpExists, Value: "spot"},
+			},
+			wantErr: field.ErrorList{
+				field.Invalid(path.Index(0).Child("value"), "spot", ""),
+			},
+		},
+		"equal without key": {
+			tolerations: []corev1.Toleration{
+				{Operator: corev1.TolerationOpEqual, Value: "spot"},
+			},
+			wantErr: field.ErrorList{
+				field.Invalid(path.Index(0).Child("operator"), corev1.TolerationOpEqual, ""),
+			},
+		},
+		"unknown operator and effect": {
+			tolerations: []corev1.Toleration{
+				{Key: "instance", Operator: "In", Effect: "NoRun"},
+			},
+			wantErr: field.ErrorList{
+				field.NotSupported(path.Index(0).Child("operator"), corev1.TolerationOperator("In"), []string(nil)),
+				field.NotSupported(path.Index(0).Child("effect"), corev1.TaintEffect("NoRun"), []string(nil)),
+			},
+		},
+		"toleration seconds without NoExecute": {
+			tolerations: []corev1.Toleration{
+				{Key: "instance", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule, TolerationSeconds: pointer.Int64(60)},
+			},
+			wantErr: field.ErrorList{
+				field.Invalid(path.Index(0).Child("effect"), corev1.TaintEffectNoSchedule, ""),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			gotErr := ValidateTolerations(tc.tolerations, path)
+			if diff := cmp.Diff(tc.wantErr, gotErr, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("ValidateTolerations() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -18,23 +18,32 @@ package workload
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
 	"sigs.k8s.io/kueue/pkg/constants"
 	"sigs.k8s.io/kueue/pkg/util/api"
 	"sigs.k8s.io/kueue/pkg/util/limitrange"
+	"sigs.k8s.io/kueue/pkg/util/pointer"
 )
 
 var (
-	admissionManagedConditions = []string{kueue.WorkloadAdmitted, kueue.WorkloadEvicted}
+	admissionManagedConditions = []string{kueue.WorkloadAdmitted, kueue.WorkloadEvicted, kueue.WorkloadDeactivated}
 )
 
 // Info holds a Workload object and some pre-processing.
@@ -57,12 +66,7 @@ func NewInfo(w *kueue.Workload) *Info {
 	info := &Info{
 		Obj: w,
 	}
-	if w.Status.Admission != nil {
-		info.ClusterQueue = string(w.Status.Admission.ClusterQueue)
-		info.TotalRequests = totalRequestsFromAdmission(w)
-	} else {
-		info.TotalRequests = totalRequestsFromPodSets(w)
-	}
+	info.Refresh()
 	return info
 }
 
@@ -70,6 +74,179 @@ func (i *Info) Update(wl *kueue.Workload) {
 	i.Obj = wl
 }
 
+// Refresh recomputes TotalRequests from the current workload object, for
+// example, after the count of its pod sets changed.
+func (i *Info) Refresh() {
+	if i.Obj.Status.Admission != nil {
+		i.ClusterQueue = string(i.Obj.Status.Admission.ClusterQueue)
+		i.TotalRequests = totalRequestsFromAdmission(i.Obj)
+	} else {
+		i.TotalRequests = totalRequestsFromPodSets(i.Obj)
+	}
+}
+
+// Validate returns an error listing the resources with negative requests in
+// each pod set, which would distort the quota usage.
+func (i *Info) Validate() error {
+	var reasons []string
+	for _, ps := range i.TotalRequests {
+		for _, rName := range sets.List(sets.KeySet(ps.Requests)) {
+			if v := ps.Requests[rName]; v < 0 {
+				q := ResourceQuantity(rName, v)
+				reasons = append(reasons, fmt.Sprintf("negative request for %s in pod set %s: %s", rName, ps.Name, q.String()))
+			}
+		}
+	}
+	if len(reasons) > 0 {
+		return fmt.Errorf("invalid requests: %s", strings.Join(reasons, "; "))
+	}
+	return nil
+}
+
+// PodSetRequests returns the total requests of the pod set with the given
+// name and whether the pod set was found. A workload has at most 8 pod sets,
+// so a linear scan is cheap and needs no index to keep in sync.
+func (i *Info) PodSetRequests(name string) (Requests, bool) {
+	for _, ps := range i.TotalRequests {
+		if ps.Name == name {
+			return ps.Requests, true
+		}
+	}
+	return nil, false
+}
+
+// RequestsExcluding returns the sum of the total requests of all the pod sets
+// except the one with the given name.
+func (i *Info) RequestsExcluding(podSet string) Requests {
+	sum := Requests{}
+	for _, ps := range i.TotalRequests {
+		if ps.Name == podSet {
+			continue
+		}
+		for rName, v := range ps.Requests {
+			sum[rName] += v
+		}
+	}
+	return sum
+}
+
+// ScaledRequests returns the total requests of the pod sets as if their counts
+// were the ones in counts, keyed by pod set name, without modifying the info.
+// The per-pod requests are derived from the current totals and the counts they
+// were computed for: the admitted counts if the workload is admitted, or the
+// counts in the spec otherwise. Pod sets not present in counts keep their
+// current totals.
+func (i *Info) ScaledRequests(counts map[string]int32) []PodSetResources {
+	currentCounts := make(map[string]int32, len(i.Obj.Spec.PodSets))
+	for _, ps := range i.Obj.Spec.PodSets {
+		currentCounts[ps.Name] = ps.Count
+	}
+	if i.Obj.Status.Admission != nil {
+		for _, psa := range i.Obj.Status.Admission.PodSetAssignments {
+			if psa.Count != nil {
+				currentCounts[psa.Name] = *psa.Count
+			}
+		}
+	}
+	res := make([]PodSetResources, 0, len(i.TotalRequests))
+	for _, psr := range i.TotalRequests {
+		scaled := PodSetResources{
+			Name:     psr.Name,
+			Requests: make(Requests, len(psr.Requests)),
+			Flavors:  psr.Flavors,
+		}
+		count, scale := counts[psr.Name]
+		oldCount := currentCounts[psr.Name]
+		for rName, v := range psr.Requests {
+			if scale && oldCount > 0 {
+				v = v / int64(oldCount) * int64(count)
+			}
+			scaled.Requests[rName] = v
+		}
+		res = append(res, scaled)
+	}
+	return res
+}
+
+// podSetSchedulingFields holds the fields of a PodSet that are relevant for
+// its admission.
+type podSetSchedulingFields struct {
+	Count        int32               `json:"count"`
+	Requests     corev1.ResourceList `json:"requests,omitempty"`
+	NodeSelector map[string]string   `json:"nodeSelector,omitempty"`
+	Affinity     *corev1.Affinity    `json:"affinity,omitempty"`
+	Tolerations  []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// PodSetSchedulingHash returns a stable hash of the fields of the PodSet that
+// are relevant for scheduling: count, requests, node selector, affinity and
+// tolerations. Other fields, such as labels or images, don't affect the hash.
+func PodSetSchedulingHash(ps *kueue.PodSet) string {
+	fields := podSetSchedulingFields{
+		Count:        ps.Count,
+		Requests:     limitrange.TotalRequests(&ps.Template.Spec),
+		NodeSelector: ps.Template.Spec.NodeSelector,
+		Affinity:     ps.Template.Spec.Affinity,
+		Tolerations:  ps.Template.Spec.Tolerations,
+	}
+	// Maps are encoded with sorted keys, which makes the encoding stable.
+	data, _ := json.Marshal(fields)
+	h := sha1.Sum(data)
+	return hex.EncodeToString(h[:])
+}
+
+// AssignedFlavors returns the set of flavors assigned to the resources of
+// all the pod sets. It's empty if the workload is not admitted.
+func (i *Info) AssignedFlavors() sets.Set[kueue.ResourceFlavorReference] {
+	flavors := sets.New[kueue.ResourceFlavorReference]()
+	for _, ps := range i.TotalRequests {
+		for _, flv := range ps.Flavors {
+			flavors.Insert(flv)
+		}
+	}
+	return flavors
+}
+
+// Images returns the set of images of the containers and init containers in
+// all the pod sets.
+func (i *Info) Images() sets.Set[string] {
+	images := sets.New[string]()
+	for _, ps := range i.Obj.Spec.PodSets {
+		spec := &ps.Template.Spec
+		for _, c := range spec.InitContainers {
+			if c.Image != "" {
+				images.Insert(c.Image)
+			}
+		}
+		for _, c := range spec.Containers {
+			if c.Image != "" {
+				images.Insert(c.Image)
+			}
+		}
+	}
+	return images
+}
+
+// PerContainerRequests returns the requests of each container, in order, in
+// the pod set with the given name, multiplied by the count of the pod set, or
+// nil if the pod set is not found. Init containers and the pod overhead are
+// not included, so the breakdown might not add up to the total requests.
+func (i *Info) PerContainerRequests(podSet string) []Requests {
+	for _, ps := range i.Obj.Spec.PodSets {
+		if ps.Name != podSet {
+			continue
+		}
+		res := make([]Requests, 0, len(ps.Template.Spec.Containers))
+		for _, c := range ps.Template.Spec.Containers {
+			req := newRequests(c.Resources.Requests)
+			req.scale(int64(ps.Count))
+			res = append(res, req)
+		}
+		return res
+	}
+	return nil
+}
+
 func Key(w *kueue.Workload) string {
 	return fmt.Sprintf("%s/%s", w.Namespace, w.Name)
 }
@@ -88,8 +265,13 @@ func totalRequestsFromPodSets(wl *kueue.Workload) []PodSetResources {
 		setRes := PodSetResources{
 			Name: ps.Name,
 		}
-		setRes.Requests = newRequests(limitrange.TotalRequests(&ps.Template.Spec))
-		setRes.Requests.scale(int64(ps.Count))
+		if wl.Spec.TotalRequests != nil {
+			// The declared totals are only allowed for a single pod set.
+			setRes.Requests = newRequests(wl.Spec.TotalRequests)
+		} else {
+			setRes.Requests = newRequests(limitrange.TotalRequests(&ps.Template.Spec))
+			setRes.Requests.scale(int64(ps.Count))
+		}
 		res = append(res, setRes)
 	}
 	return res
@@ -114,9 +296,37 @@ func totalRequestsFromAdmission(wl *kueue.Workload) []PodSetResources {
 // The following resources calculations are inspired on
 // https://github.com/kubernetes/kubernetes/blob/master/pkg/scheduler/framework/types.go
 
-// Requests maps ResourceName to flavor to value; for CPU it is tracked in MilliCPU.
+// Requests maps ResourceName to flavor to value; for CPU, and any resource
+// registered with RegisterMilliResource, it is tracked in milli-units.
 type Requests map[corev1.ResourceName]int64
 
+// milliResources holds the set of resources tracked in milli-units. The set
+// is never modified once stored, so that it can be read without locking on
+// every request conversion. Registrations replace it with an extended copy.
+var milliResources atomic.Pointer[sets.Set[corev1.ResourceName]]
+
+// milliResourcesMu serializes the registrations.
+var milliResourcesMu sync.Mutex
+
+func init() {
+	names := sets.New(corev1.ResourceCPU)
+	milliResources.Store(&names)
+}
+
+// RegisterMilliResource makes the resource tracked in milli-units, like CPU,
+// so that fractional quantities of it can be accounted. It should be called
+// at startup, before any workload or quota is processed.
+func RegisterMilliResource(name corev1.ResourceName) {
+	milliResourcesMu.Lock()
+	defer milliResourcesMu.Unlock()
+	names := milliResources.Load().Clone().Insert(name)
+	milliResources.Store(&names)
+}
+
+func isMilliResource(name corev1.ResourceName) bool {
+	return milliResources.Load().Has(name)
+}
+
 func newRequests(rl corev1.ResourceList) Requests {
 	r := Requests{}
 	for name, quant := range rl {
@@ -133,19 +343,34 @@ func (r Requests) ToResourceList() corev1.ResourceList {
 	return ret
 }
 
+// ToMilliResourceList returns the requests as quantities in milli-units, for
+// all the resources, not only the ones tracked in milli-units.
+func (r Requests) ToMilliResourceList() corev1.ResourceList {
+	ret := make(corev1.ResourceList, len(r))
+	for k, v := range r {
+		if !isMilliResource(k) {
+			v *= 1000
+		}
+		ret[k] = *resource.NewMilliQuantity(v, resource.DecimalSI)
+	}
+	return ret
+}
+
 // ResourceValue returns the integer value for the resource name.
-// It's milli-units for CPU and absolute units for everything else.
+// It's milli-units for CPU and the resources registered with
+// RegisterMilliResource, and absolute units for everything else.
 func ResourceValue(name corev1.ResourceName, q resource.Quantity) int64 {
-	if name == corev1.ResourceCPU {
+	if isMilliResource(name) {
 		return q.MilliValue()
 	}
 	return q.Value()
 }
 
 func ResourceQuantity(name corev1.ResourceName, v int64) resource.Quantity {
-	switch name {
-	case corev1.ResourceCPU:
+	if isMilliResource(name) {
 		return *resource.NewMilliQuantity(v, resource.DecimalSI)
+	}
+	switch name {
 	case corev1.ResourceMemory, corev1.ResourceEphemeralStorage:
 		return *resource.NewQuantity(v, resource.BinarySI)
 	default:
@@ -156,6 +381,19 @@ func ResourceQuantity(name corev1.ResourceName, v int64) resource.Quantity {
 	}
 }
 
+// Diff returns, for each resource in r or other, the value in r minus the
+// value in other. Resources missing in one of them count as 0.
+func (r Requests) Diff(other Requests) map[corev1.ResourceName]int64 {
+	diff := make(map[corev1.ResourceName]int64, len(r))
+	for name, v := range r {
+		diff[name] = v
+	}
+	for name, v := range other {
+		diff[name] -= v
+	}
+	return diff
+}
+
 func (r Requests) scale(f int64) {
 	for name := range r {
 		r[name] *= f
@@ -256,6 +494,7 @@ func admissionPatch(w *kueue.Workload) *kueue.Workload {
 	wlCopy := BaseSSAWorkload(w)
 
 	wlCopy.Status.Admission = w.Status.Admission.DeepCopy()
+	wlCopy.Status.RequeueState = w.Status.RequeueState.DeepCopy()
 	for _, conditionName := range admissionManagedConditions {
 		if existing := apimeta.FindStatusCondition(w.Status.Conditions, conditionName); existing != nil {
 			wlCopy.Status.Conditions = append(wlCopy.Status.Conditions, *existing.DeepCopy())
@@ -276,15 +515,171 @@ func ApplyAdmissionStatus(ctx context.Context, c client.Client, w *kueue.Workloa
 }
 
 // GetQueueOrderTimestamp return the timestamp to be used by the scheduler. It could
-// be the workload creation time or the last time a PodsReady timeout has occurred.
+// be the workload creation time, the last time a PodsReady timeout has occurred
+// or the last time the workload was preempted, plus a cooldown.
 func GetQueueOrderTimestamp(w *kueue.Workload) *metav1.Time {
-	if c := apimeta.FindStatusCondition(w.Status.Conditions, kueue.WorkloadEvicted); c != nil && c.Status == metav1.ConditionTrue && c.Reason == kueue.WorkloadEvictedByPodsReadyTimeout {
-		return &c.LastTransitionTime
+	if c := apimeta.FindStatusCondition(w.Status.Conditions, kueue.WorkloadEvicted); c != nil && c.Status == metav1.ConditionTrue {
+		switch c.Reason {
+		case kueue.WorkloadEvictedByPodsReadyTimeout:
+			return &c.LastTransitionTime
+		case kueue.WorkloadEvictedByPreemption:
+			t := metav1.NewTime(c.LastTransitionTime.Add(constants.PreemptionRequeueDelay))
+			return &t
+		}
 	}
 	return &w.CreationTimestamp
 }
 
+// Deadline returns the deadline of the workload from its annotation and
+// whether it has a valid one.
+func Deadline(w *kueue.Workload) (time.Time, bool) {
+	v, found := w.Annotations[kueue.DeadlineAnnotation]
+	if !found {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// PendingDuration returns how long the workload has been pending at the given
+// time, starting from the timestamp used to order it in the queue. It's zero
+// if the workload can't be ordered yet, like during the cooldown after a
+// preemption.
+func PendingDuration(w *kueue.Workload, now time.Time) time.Duration {
+	d := now.Sub(GetQueueOrderTimestamp(w).Time)
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// IsActive returns true if the workload is active. Workloads are active
+// unless .spec.active is explicitly set to false.
+func IsActive(w *kueue.Workload) bool {
+	return w.Spec.Active == nil || *w.Spec.Active
+}
+
+// IsReservationOnly returns true if the workload only reserves quota, without
+// running pods.
+func IsReservationOnly(w *kueue.Workload) bool {
+	return w.Labels[kueue.ReservationOnlyLabel] == "true"
+}
+
+// ShouldRetryAdmissionChecks returns true if at least one of the admission
+// checks of the workload asks for a retry and none of them rejected it.
+func ShouldRetryAdmissionChecks(w *kueue.Workload) bool {
+	retry := false
+	for _, check := range w.Status.AdmissionChecks {
+		switch check.State {
+		case kueue.CheckStateRejected:
+			return false
+		case kueue.CheckStateRetry:
+			retry = true
+		}
+	}
+	return retry
+}
+
+// OwnerJobGVK returns the GroupVersionKind of the controller owner of the
+// workload, usually the job it was created for, and whether the workload has
+// a controller owner with a valid apiVersion.
+func OwnerJobGVK(w *kueue.Workload) (schema.GroupVersionKind, bool) {
+	owner := metav1.GetControllerOf(w)
+	if owner == nil {
+		return schema.GroupVersionKind{}, false
+	}
+	gv, err := schema.ParseGroupVersion(owner.APIVersion)
+	if err != nil {
+		return schema.GroupVersionKind{}, false
+	}
+	return gv.WithKind(owner.Kind), true
+}
+
+// AdmissionCheckTimedOut returns true if the admission check with the given
+// name has been pending for at least the timeout at now. Checks in other states
+// already got a response, so they can't time out.
+func AdmissionCheckTimedOut(w *kueue.Workload, name string, timeout time.Duration, now time.Time) bool {
+	for _, check := range w.Status.AdmissionChecks {
+		if check.Name != name {
+			continue
+		}
+		return check.State == kueue.CheckStatePending && !now.Before(check.LastTransitionTime.Add(timeout))
+	}
+	return false
+}
+
+// MaybeDeactivateOnFailureLimit deactivates the workload if it was requeued,
+// after being evicted due to PodsReady timeouts, more times than the limit.
+// It sets .spec.active to false and adds the Deactivated condition, returning
+// true if the workload was deactivated.
+func MaybeDeactivateOnFailureLimit(w *kueue.Workload, limit int) bool {
+	if !IsActive(w) || w.Status.RequeueState == nil || w.Status.RequeueState.Count == nil {
+		return false
+	}
+	count := *w.Status.RequeueState.Count
+	if int(count) <= limit {
+		return false
+	}
+	w.Spec.Active = pointer.Bool(false)
+	apimeta.SetStatusCondition(&w.Status.Conditions, metav1.Condition{
+		Type:    kueue.WorkloadDeactivated,
+		Status:  metav1.ConditionTrue,
+		Reason:  kueue.WorkloadDeactivatedByRequeuingLimit,
+		Message: fmt.Sprintf("The workload was requeued %d times, exceeding the limit of %d", count, limit),
+	})
+	return true
+}
+
+// UpdateRequeueState records a requeue of the workload after being evicted
+// due to a PodsReady timeout, incrementing the count and holding the workload
+// back from the queue for a delay that doubles with each requeue.
+func UpdateRequeueState(w *kueue.Workload, now time.Time) {
+	if w.Status.RequeueState == nil {
+		w.Status.RequeueState = &kueue.RequeueState{}
+	}
+	var count int32 = 1
+	if w.Status.RequeueState.Count != nil {
+		count += *w.Status.RequeueState.Count
+	}
+	delay := constants.PodsReadyTimeoutRequeueBaseDelay
+	for i := int32(1); i < count && delay < constants.PodsReadyTimeoutRequeueMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > constants.PodsReadyTimeoutRequeueMaxDelay {
+		delay = constants.PodsReadyTimeoutRequeueMaxDelay
+	}
+	w.Status.RequeueState.Count = &count
+	w.Status.RequeueState.RequeueAt = &metav1.Time{Time: now.Add(delay)}
+}
+
+// RequeueDelay returns how long the workload is still held back from the
+// queue after being evicted due to a PodsReady timeout, or 0 if it can be
+// queued.
+func RequeueDelay(w *kueue.Workload, now time.Time) time.Duration {
+	if w.Status.RequeueState == nil || w.Status.RequeueState.RequeueAt == nil {
+		return 0
+	}
+	if delay := w.Status.RequeueState.RequeueAt.Sub(now); delay > 0 {
+		return delay
+	}
+	return 0
+}
+
+// IsQueueable returns whether the workload can be in a queue, that is, it's
+// active and not held back after being evicted due to a PodsReady timeout.
+func IsQueueable(w *kueue.Workload, now time.Time) bool {
+	return IsActive(w) && RequeueDelay(w, now) == 0
+}
+
 // IsAdmitted checks if workload is admitted based on conditions
 func IsAdmitted(w *kueue.Workload) bool {
 	return apimeta.IsStatusConditionTrue(w.Status.Conditions, kueue.WorkloadAdmitted)
 }
+
+// HasQuotaReservation checks if workload has quota reserved based on conditions
+func HasQuotaReservation(w *kueue.Workload) bool {
+	return apimeta.IsStatusConditionTrue(w.Status.Conditions, kueue.WorkloadQuotaReserved)
+}
@@ -23,12 +23,17 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	kueue "sigs.k8s.io/kueue/apis/kueue/v1beta1"
+	"sigs.k8s.io/kueue/pkg/constants"
+	"sigs.k8s.io/kueue/pkg/util/pointer"
 	utiltesting "sigs.k8s.io/kueue/pkg/util/testing"
 )
 
@@ -54,6 +59,28 @@ func TestNewInfo(t *testing.T) {
 				},
 			},
 		},
+		"declared total requests": {
+			workload: *utiltesting.MakeWorkload("", "").
+				PodSets(
+					*utiltesting.MakePodSet("workers", 3).
+						Request(corev1.ResourceCPU, "5m").
+						Obj(),
+				).
+				TotalRequest(corev1.ResourceCPU, "2").
+				TotalRequest(corev1.ResourceMemory, "1Gi").
+				Obj(),
+			wantInfo: Info{
+				TotalRequests: []PodSetResources{
+					{
+						Name: "workers",
+						Requests: Requests{
+							corev1.ResourceCPU:    2_000,
+							corev1.ResourceMemory: 1024 * 1024 * 1024,
+						},
+					},
+				},
+			},
+		},
 		"admitted": {
 			workload: *utiltesting.MakeWorkload("", "").
 				PodSets(
@@ -125,6 +152,690 @@ func TestNewInfo(t *testing.T) {
 	}
 }
 
+func TestPodSetRequests(t *testing.T) {
+	info := NewInfo(utiltesting.MakeWorkload("", "").
+		PodSets(
+			*utiltesting.MakePodSet("driver", 1).
+				Request(corev1.ResourceCPU, "10m").
+				Request(corev1.ResourceMemory, "512Ki").
+				Obj(),
+			*utiltesting.MakePodSet("workers", 3).
+				Request(corev1.ResourceCPU, "5m").
+				Request(corev1.ResourceMemory, "1Mi").
+				Request("ex.com/gpu", "1").
+				Obj(),
+		).
+		Obj())
+	cases := map[string]struct {
+		podSet       string
+		wantRequests Requests
+		wantFound    bool
+	}{
+		"workers": {
+			podSet: "workers",
+			wantRequests: Requests{
+				corev1.ResourceCPU:    15,
+				corev1.ResourceMemory: 3 * 1024 * 1024,
+				"ex.com/gpu":          3,
+			},
+			wantFound: true,
+		},
+		"unknown pod set": {
+			podSet: "unknown",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, found := info.PodSetRequests(tc.podSet)
+			if found != tc.wantFound {
+				t.Errorf("PodSetRequests(%q) returned found=%t, want %t", tc.podSet, found, tc.wantFound)
+			}
+			if diff := cmp.Diff(tc.wantRequests, got); diff != "" {
+				t.Errorf("PodSetRequests(%q) returned unexpected requests (-want,+got):\n%s", tc.podSet, diff)
+			}
+		})
+	}
+}
+
+func TestRequestsExcluding(t *testing.T) {
+	info := NewInfo(utiltesting.MakeWorkload("", "").
+		PodSets(
+			*utiltesting.MakePodSet("driver", 1).
+				Request(corev1.ResourceCPU, "10m").
+				Request(corev1.ResourceMemory, "512Ki").
+				Obj(),
+			*utiltesting.MakePodSet("workers", 3).
+				Request(corev1.ResourceCPU, "5m").
+				Request(corev1.ResourceMemory, "1Mi").
+				Request("ex.com/gpu", "1").
+				Obj(),
+		).
+		Obj())
+	cases := map[string]struct {
+		podSet string
+		want   Requests
+	}{
+		"workers": {
+			podSet: "workers",
+			want: Requests{
+				corev1.ResourceCPU:    10,
+				corev1.ResourceMemory: 512 * 1024,
+			},
+		},
+		"driver": {
+			podSet: "driver",
+			want: Requests{
+				corev1.ResourceCPU:    15,
+				corev1.ResourceMemory: 3 * 1024 * 1024,
+				"ex.com/gpu":          3,
+			},
+		},
+		"unknown pod set": {
+			podSet: "unknown",
+			want: Requests{
+				corev1.ResourceCPU:    25,
+				corev1.ResourceMemory: 512*1024 + 3*1024*1024,
+				"ex.com/gpu":          3,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := info.RequestsExcluding(tc.podSet)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("RequestsExcluding(%q) returned unexpected requests (-want,+got):\n%s", tc.podSet, diff)
+			}
+		})
+	}
+}
+
+func TestRequestsDiff(t *testing.T) {
+	cases := map[string]struct {
+		requests Requests
+		other    Requests
+		want     map[corev1.ResourceName]int64
+	}{
+		"overlapping keys": {
+			requests: Requests{
+				corev1.ResourceCPU:    2_000,
+				corev1.ResourceMemory: 1024,
+			},
+			other: Requests{
+				corev1.ResourceCPU:    500,
+				corev1.ResourceMemory: 2048,
+			},
+			want: map[corev1.ResourceName]int64{
+				corev1.ResourceCPU:    1_500,
+				corev1.ResourceMemory: -1024,
+			},
+		},
+		"disjoint keys": {
+			requests: Requests{
+				corev1.ResourceCPU: 2_000,
+			},
+			other: Requests{
+				"example.com/gpu": 2,
+			},
+			want: map[corev1.ResourceName]int64{
+				corev1.ResourceCPU: 2_000,
+				"example.com/gpu":  -2,
+			},
+		},
+		"equal": {
+			requests: Requests{
+				corev1.ResourceCPU: 2_000,
+			},
+			other: Requests{
+				corev1.ResourceCPU: 2_000,
+			},
+			want: map[corev1.ResourceName]int64{
+				corev1.ResourceCPU: 0,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, tc.requests.Diff(tc.other)); diff != "" {
+				t.Errorf("Unexpected differences (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScaledRequests(t *testing.T) {
+	cases := map[string]struct {
+		workload *kueue.Workload
+		counts   map[string]int32
+		want     []PodSetResources
+	}{
+		"pending workload": {
+			workload: utiltesting.MakeWorkload("wl", "ns").
+				PodSets(
+					*utiltesting.MakePodSet("driver", 1).
+						Request(corev1.ResourceCPU, "1").
+						Obj(),
+					*utiltesting.MakePodSet("workers", 3).
+						Request(corev1.ResourceCPU, "2").
+						Request(corev1.ResourceMemory, "1Mi").
+						Obj(),
+				).
+				Obj(),
+			counts: map[string]int32{"workers": 1},
+			want: []PodSetResources{
+				{
+					Name:     "driver",
+					Requests: Requests{corev1.ResourceCPU: 1000},
+				},
+				{
+					Name: "workers",
+					Requests: Requests{
+						corev1.ResourceCPU:    2000,
+						corev1.ResourceMemory: 1024 * 1024,
+					},
+				},
+			},
+		},
+		"admitted workload": {
+			workload: utiltesting.MakeWorkload("wl", "ns").
+				PodSets(
+					*utiltesting.MakePodSet("workers", 3).
+						Request(corev1.ResourceCPU, "2").
+						Obj(),
+				).
+				ReserveQuota(utiltesting.MakeAdmission("cq", "workers").
+					Assignment(corev1.ResourceCPU, "on-demand", "6").
+					Obj()).
+				Obj(),
+			counts: map[string]int32{"workers": 1},
+			want: []PodSetResources{
+				{
+					Name:     "workers",
+					Requests: Requests{corev1.ResourceCPU: 2000},
+					Flavors:  map[corev1.ResourceName]kueue.ResourceFlavorReference{corev1.ResourceCPU: "on-demand"},
+				},
+			},
+		},
+		"partially admitted workload": {
+			workload: utiltesting.MakeWorkload("wl", "ns").
+				PodSets(
+					*utiltesting.MakePodSet("workers", 10).
+						Request(corev1.ResourceCPU, "2").
+						Obj(),
+				).
+				ReserveQuota(utiltesting.MakeAdmission("cq", "workers").
+					Assignment(corev1.ResourceCPU, "on-demand", "10").
+					AssignmentPodCount(5).
+					Obj()).
+				Obj(),
+			counts: map[string]int32{"workers": 1},
+			want: []PodSetResources{
+				{
+					Name:     "workers",
+					Requests: Requests{corev1.ResourceCPU: 2000},
+					Flavors:  map[corev1.ResourceName]kueue.ResourceFlavorReference{corev1.ResourceCPU: "on-demand"},
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			info := NewInfo(tc.workload)
+			original := NewInfo(tc.workload.DeepCopy()).TotalRequests
+			got := info.ScaledRequests(tc.counts)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Unexpected scaled requests (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(original, info.TotalRequests); diff != "" {
+				t.Errorf("ScaledRequests modified the total requests (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := map[string]struct {
+		workload *kueue.Workload
+		wantErr  string
+	}{
+		"valid requests": {
+			workload: utiltesting.MakeWorkload("wl", "ns").PodSets(
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "1").
+					Request(corev1.ResourceMemory, "1Gi").
+					Obj(),
+			).Obj(),
+		},
+		"negative cpu request": {
+			workload: utiltesting.MakeWorkload("wl", "ns").PodSets(
+				*utiltesting.MakePodSet("main", 1).
+					Request(corev1.ResourceCPU, "-500m").
+					Request(corev1.ResourceMemory, "1Gi").
+					Obj(),
+			).Obj(),
+			wantErr: "invalid requests: negative request for cpu in pod set main: -500m",
+		},
+		"negative requests in multiple pod sets": {
+			workload: utiltesting.MakeWorkload("wl", "ns").PodSets(
+				*utiltesting.MakePodSet("driver", 1).
+					Request(corev1.ResourceCPU, "-1").
+					Obj(),
+				*utiltesting.MakePodSet("workers", 2).
+					Request(corev1.ResourceCPU, "1").
+					Request(corev1.ResourceMemory, "-1Gi").
+					Obj(),
+			).Obj(),
+			wantErr: "invalid requests: negative request for cpu in pod set driver: -1; negative request for memory in pod set workers: -2Gi",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := NewInfo(tc.workload).Validate()
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tc.wantErr {
+				t.Errorf("Validate()=%q, want %q", gotErr, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	wl := utiltesting.MakeWorkload("", "").
+		PodSets(
+			*utiltesting.MakePodSet("driver", 1).
+				Request(corev1.ResourceCPU, "10m").
+				Obj(),
+			*utiltesting.MakePodSet("workers", 3).
+				Request(corev1.ResourceCPU, "5m").
+				Obj(),
+		).
+		Obj()
+	info := NewInfo(wl)
+	if _, found := info.PodSetRequests("workers"); !found {
+		t.Fatal("PodSetRequests didn't find pod set workers")
+	}
+
+	wl.Spec.PodSets[1].Count = 5
+	info.Refresh()
+
+	wantTotalRequests := []PodSetResources{
+		{
+			Name: "driver",
+			Requests: Requests{
+				corev1.ResourceCPU: 10,
+			},
+		},
+		{
+			Name: "workers",
+			Requests: Requests{
+				corev1.ResourceCPU: 25,
+			},
+		},
+	}
+	if diff := cmp.Diff(wantTotalRequests, info.TotalRequests); diff != "" {
+		t.Errorf("Unexpected TotalRequests after Refresh (-want,+got):\n%s", diff)
+	}
+	gotWorkers, _ := info.PodSetRequests("workers")
+	if diff := cmp.Diff(wantTotalRequests[1].Requests, gotWorkers); diff != "" {
+		t.Errorf("Unexpected workers requests after Refresh (-want,+got):\n%s", diff)
+	}
+}
+
+func TestResourceValue(t *testing.T) {
+	const want = int64(1_000_000_000)
+	cases := []string{"1e9", "1000000000", "1G"}
+	for _, q := range cases {
+		t.Run(q, func(t *testing.T) {
+			quantity := resource.MustParse(q)
+			got := ResourceValue(corev1.ResourceMemory, quantity)
+			if got != want {
+				t.Errorf("ResourceValue(memory, %s) = %d, want %d", q, got, want)
+			}
+			roundTrip := ResourceQuantity(corev1.ResourceMemory, got)
+			if roundTrip.Cmp(quantity) != 0 {
+				t.Errorf("ResourceQuantity(memory, %d) = %s, want equivalent to %s", got, &roundTrip, q)
+			}
+
+			info := NewInfo(utiltesting.MakeWorkload("", "").
+				PodSets(*utiltesting.MakePodSet("main", 3).
+					Request(corev1.ResourceMemory, q).
+					Obj()).
+				Obj())
+			if got := info.TotalRequests[0].Requests[corev1.ResourceMemory]; got != 3*want {
+				t.Errorf("Total memory requests for %s = %d, want %d", q, got, 3*want)
+			}
+		})
+	}
+}
+
+func TestRegisterMilliResource(t *testing.T) {
+	const name corev1.ResourceName = "example.com/fractional-gpu"
+	previous := milliResources.Load()
+	RegisterMilliResource(name)
+	t.Cleanup(func() {
+		milliResources.Store(previous)
+	})
+	if previous.Has(name) {
+		t.Errorf("Registering %s modified the previous set of milli-resources", name)
+	}
+
+	quantity := resource.MustParse("1500m")
+	got := ResourceValue(name, quantity)
+	if got != 1_500 {
+		t.Errorf("ResourceValue(%s, %s) = %d, want %d", name, &quantity, got, 1_500)
+	}
+	roundTrip := ResourceQuantity(name, got)
+	if roundTrip.Cmp(quantity) != 0 {
+		t.Errorf("ResourceQuantity(%s, %d) = %s, want equivalent to %s", name, got, &roundTrip, &quantity)
+	}
+	if got := ResourceValue("example.com/gpu", quantity); got != 2 {
+		t.Errorf("ResourceValue(example.com/gpu, %s) = %d, want %d", &quantity, got, 2)
+	}
+
+	info := NewInfo(utiltesting.MakeWorkload("", "").
+		PodSets(*utiltesting.MakePodSet("main", 3).
+			Request(name, "500m").
+			Obj()).
+		Obj())
+	if got := info.TotalRequests[0].Requests[name]; got != 1_500 {
+		t.Errorf("Total requests of %s = %d, want %d", name, got, 1_500)
+	}
+}
+
+func TestToMilliResourceList(t *testing.T) {
+	requests := Requests{
+		corev1.ResourceCPU:    1_500,
+		corev1.ResourceMemory: utiltesting.Gi,
+		"example.com/gpu":     2,
+	}
+	got := requests.ToMilliResourceList()
+	wantMilli := map[corev1.ResourceName]int64{
+		corev1.ResourceCPU:    1_500,
+		corev1.ResourceMemory: 1_000 * utiltesting.Gi,
+		"example.com/gpu":     2_000,
+	}
+	gotMilli := make(map[corev1.ResourceName]int64, len(got))
+	for name, q := range got {
+		gotMilli[name] = q.MilliValue()
+		if want := ResourceQuantity(name, requests[name]); q.Cmp(want) != 0 {
+			t.Errorf("Quantity for %s is %s, want equivalent to %s", name, &q, &want)
+		}
+	}
+	if diff := cmp.Diff(wantMilli, gotMilli); diff != "" {
+		t.Errorf("Unexpected milli values (-want,+got):\n%s", diff)
+	}
+}
+
+func TestPodSetSchedulingHash(t *testing.T) {
+	base := func() *utiltesting.PodSetWrapper {
+		return utiltesting.MakePodSet("main", 2).
+			Request(corev1.ResourceCPU, "1").
+			NodeSelector(map[string]string{"instance": "spot"}).
+			Toleration(corev1.Toleration{
+				Key:      "instance",
+				Operator: corev1.TolerationOpEqual,
+				Value:    "spot",
+				Effect:   corev1.TaintEffectNoSchedule,
+			})
+	}
+	withLabels := base().Obj()
+	withLabels.Name = "other"
+	withLabels.Template.Labels = map[string]string{"app": "foo"}
+	withLabels.Template.Spec.Containers[0].Image = "foo:latest"
+
+	cases := map[string]struct {
+		podSet    *kueue.PodSet
+		wantEqual bool
+	}{
+		"same": {
+			podSet:    base().Obj(),
+			wantEqual: true,
+		},
+		"equivalent requests": {
+			podSet: base().
+				Request(corev1.ResourceCPU, "1000m").
+				Obj(),
+			wantEqual: true,
+		},
+		"different name, labels and image": {
+			podSet:    withLabels,
+			wantEqual: true,
+		},
+		"different count": {
+			podSet: func() *kueue.PodSet {
+				ps := base().Obj()
+				ps.Count = 3
+				return ps
+			}(),
+		},
+		"different requests": {
+			podSet: base().
+				Request(corev1.ResourceMemory, "1Gi").
+				Obj(),
+		},
+		"different node selector": {
+			podSet: base().
+				NodeSelector(map[string]string{"instance": "on-demand"}).
+				Obj(),
+		},
+		"different tolerations": {
+			podSet: base().
+				Toleration(corev1.Toleration{
+					Key:      "gpu",
+					Operator: corev1.TolerationOpExists,
+				}).
+				Obj(),
+		},
+		"different affinity": {
+			podSet: func() *kueue.PodSet {
+				ps := base().Obj()
+				ps.Template.Spec.Affinity = &corev1.Affinity{
+					NodeAffinity: &corev1.NodeAffinity{
+						RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+							NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+								MatchExpressions: []corev1.NodeSelectorRequirement{{
+									Key:      "zone",
+									Operator: corev1.NodeSelectorOpIn,
+									Values:   []string{"a"},
+								}},
+							}},
+						},
+					},
+				}
+				return ps
+			}(),
+		},
+	}
+	baseHash := PodSetSchedulingHash(base().Obj())
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			gotEqual := PodSetSchedulingHash(tc.podSet) == baseHash
+			if gotEqual != tc.wantEqual {
+				t.Errorf("PodSetSchedulingHash equal to base: %t, want %t", gotEqual, tc.wantEqual)
+			}
+		})
+	}
+}
+
+func TestAssignedFlavors(t *testing.T) {
+	cases := map[string]struct {
+		workload    *kueue.Workload
+		wantFlavors sets.Set[kueue.ResourceFlavorReference]
+	}{
+		"pending": {
+			workload:    utiltesting.MakeWorkload("", "").Request(corev1.ResourceCPU, "1").Obj(),
+			wantFlavors: sets.New[kueue.ResourceFlavorReference](),
+		},
+		"admitted": {
+			workload: utiltesting.MakeWorkload("", "").
+				PodSets(
+					*utiltesting.MakePodSet("driver", 1).
+						Request(corev1.ResourceCPU, "10m").
+						Request(corev1.ResourceMemory, "512Ki").
+						Obj(),
+					*utiltesting.MakePodSet("workers", 3).
+						Request(corev1.ResourceCPU, "5m").
+						Request("ex.com/gpu", "1").
+						Obj(),
+				).
+				Admit(utiltesting.MakeAdmission("foo").
+					PodSets(
+						kueue.PodSetAssignment{
+							Name: "driver",
+							Flavors: map[corev1.ResourceName]kueue.ResourceFlavorReference{
+								corev1.ResourceCPU:    "on-demand",
+								corev1.ResourceMemory: "on-demand",
+							},
+							ResourceUsage: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("10m"),
+								corev1.ResourceMemory: resource.MustParse("512Ki"),
+							},
+						},
+						kueue.PodSetAssignment{
+							Name: "workers",
+							Flavors: map[corev1.ResourceName]kueue.ResourceFlavorReference{
+								corev1.ResourceCPU: "spot",
+								"ex.com/gpu":       "gpu-a",
+							},
+							ResourceUsage: corev1.ResourceList{
+								corev1.ResourceCPU: resource.MustParse("15m"),
+								"ex.com/gpu":       resource.MustParse("3"),
+							},
+						},
+					).
+					Obj()).
+				Obj(),
+			wantFlavors: sets.New[kueue.ResourceFlavorReference]("on-demand", "spot", "gpu-a"),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := NewInfo(tc.workload).AssignedFlavors()
+			if diff := cmp.Diff(tc.wantFlavors, got); diff != "" {
+				t.Errorf("Unexpected assigned flavors (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestImages(t *testing.T) {
+	cases := map[string]struct {
+		podSets    []kueue.PodSet
+		wantImages sets.Set[string]
+	}{
+		"no images": {
+			podSets: []kueue.PodSet{
+				*utiltesting.MakePodSet("main", 1).Obj(),
+			},
+			wantImages: sets.New[string](),
+		},
+		"multiple containers and pod sets": {
+			podSets: []kueue.PodSet{
+				{
+					Name:  "driver",
+					Count: 1,
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							InitContainers: []corev1.Container{
+								{Name: "setup", Image: "registry.example.com/setup:v1"},
+							},
+							Containers: []corev1.Container{
+								{Name: "driver", Image: "registry.example.com/driver:v1"},
+								{Name: "sidecar", Image: "registry.example.com/sidecar:v2"},
+							},
+						},
+					},
+				},
+				{
+					Name:  "workers",
+					Count: 3,
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{Name: "worker", Image: "registry.example.com/worker:v1"},
+								{Name: "sidecar", Image: "registry.example.com/sidecar:v2"},
+							},
+						},
+					},
+				},
+			},
+			wantImages: sets.New(
+				"registry.example.com/setup:v1",
+				"registry.example.com/driver:v1",
+				"registry.example.com/sidecar:v2",
+				"registry.example.com/worker:v1",
+			),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			wl := utiltesting.MakeWorkload("", "").PodSets(tc.podSets...).Obj()
+			got := NewInfo(wl).Images()
+			if diff := cmp.Diff(tc.wantImages, got); diff != "" {
+				t.Errorf("Unexpected images (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPerContainerRequests(t *testing.T) {
+	info := NewInfo(utiltesting.MakeWorkload("", "").
+		PodSets(kueue.PodSet{
+			Name:  "workers",
+			Count: 2,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "trainer",
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU: resource.MustParse("1"),
+									"example.com/gpu":  resource.MustParse("1"),
+								},
+							},
+						},
+						{
+							Name: "exporter",
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU: resource.MustParse("100m"),
+									"example.com/gpu":  resource.MustParse("1"),
+								},
+							},
+						},
+					},
+				},
+			},
+		}).
+		Obj())
+	cases := map[string]struct {
+		podSet string
+		want   []Requests
+	}{
+		"two containers": {
+			podSet: "workers",
+			want: []Requests{
+				{corev1.ResourceCPU: 2_000, "example.com/gpu": 2},
+				{corev1.ResourceCPU: 200, "example.com/gpu": 2},
+			},
+		},
+		"unknown pod set": {
+			podSet: "unknown",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := info.PerContainerRequests(tc.podSet)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("PerContainerRequests(%q) returned unexpected requests (-want,+got):\n%s", tc.podSet, diff)
+			}
+		})
+	}
+}
+
 var ignoreConditionTimestamps = cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")
 
 func TestUpdateWorkloadStatus(t *testing.T) {
@@ -221,6 +932,18 @@ func TestGetQueueOrderTimestamp(t *testing.T) {
 					Reason:             kueue.WorkloadEvictedByPreemption,
 				}).
 				Obj(),
+			want: metav1.NewTime(conditionTime.Add(constants.PreemptionRequeueDelay)),
+		},
+		"after eviction by preemption": {
+			wl: utiltesting.MakeWorkload("name", "ns").
+				Creation(creationTime.Time).
+				Condition(metav1.Condition{
+					Type:               kueue.WorkloadEvicted,
+					Status:             metav1.ConditionFalse,
+					LastTransitionTime: conditionTime,
+					Reason:             kueue.WorkloadEvictedByPreemption,
+				}).
+				Obj(),
 			want: creationTime,
 		},
 		"evicted by PodsReady timeout": {
@@ -257,3 +980,334 @@ func TestGetQueueOrderTimestamp(t *testing.T) {
 		})
 	}
 }
+
+func TestPendingDuration(t *testing.T) {
+	now := time.Now()
+	cases := map[string]struct {
+		wl   *kueue.Workload
+		want time.Duration
+	}{
+		"freshly created": {
+			wl: utiltesting.MakeWorkload("name", "ns").
+				Creation(now.Add(-time.Minute)).
+				Obj(),
+			want: time.Minute,
+		},
+		"requeued after PodsReady timeout": {
+			wl: utiltesting.MakeWorkload("name", "ns").
+				Creation(now.Add(-time.Hour)).
+				Condition(metav1.Condition{
+					Type:               kueue.WorkloadEvicted,
+					Status:             metav1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Second)),
+					Reason:             kueue.WorkloadEvictedByPodsReadyTimeout,
+				}).
+				Obj(),
+			want: 10 * time.Second,
+		},
+		"within preemption cooldown": {
+			wl: utiltesting.MakeWorkload("name", "ns").
+				Creation(now.Add(-time.Hour)).
+				Condition(metav1.Condition{
+					Type:               kueue.WorkloadEvicted,
+					Status:             metav1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(now),
+					Reason:             kueue.WorkloadEvictedByPreemption,
+				}).
+				Obj(),
+			want: 0,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := PendingDuration(tc.wl, now)
+			if got != tc.want {
+				t.Errorf("PendingDuration()=%v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMaybeDeactivateOnFailureLimit(t *testing.T) {
+	deactivatedCondition := metav1.Condition{
+		Type:    kueue.WorkloadDeactivated,
+		Status:  metav1.ConditionTrue,
+		Reason:  kueue.WorkloadDeactivatedByRequeuingLimit,
+		Message: "The workload was requeued 4 times, exceeding the limit of 3",
+	}
+	cases := map[string]struct {
+		active         *bool
+		requeueState   *kueue.RequeueState
+		wantDeactivate bool
+		wantActive     *bool
+		wantConditions []metav1.Condition
+	}{
+		"never requeued": {},
+		"below the limit": {
+			requeueState: &kueue.RequeueState{Count: pointer.Int32(2)},
+		},
+		"at the limit": {
+			requeueState: &kueue.RequeueState{Count: pointer.Int32(3)},
+		},
+		"above the limit": {
+			requeueState:   &kueue.RequeueState{Count: pointer.Int32(4)},
+			wantDeactivate: true,
+			wantActive:     pointer.Bool(false),
+			wantConditions: []metav1.Condition{deactivatedCondition},
+		},
+		"explicitly active, above the limit": {
+			active:         pointer.Bool(true),
+			requeueState:   &kueue.RequeueState{Count: pointer.Int32(4)},
+			wantDeactivate: true,
+			wantActive:     pointer.Bool(false),
+			wantConditions: []metav1.Condition{deactivatedCondition},
+		},
+		"already inactive": {
+			active:       pointer.Bool(false),
+			requeueState: &kueue.RequeueState{Count: pointer.Int32(4)},
+			wantActive:   pointer.Bool(false),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			wl := utiltesting.MakeWorkload("wl", "ns").Obj()
+			wl.Spec.Active = tc.active
+			wl.Status.RequeueState = tc.requeueState
+			if got := MaybeDeactivateOnFailureLimit(wl, 3); got != tc.wantDeactivate {
+				t.Errorf("MaybeDeactivateOnFailureLimit(_, 3)=%t, want %t", got, tc.wantDeactivate)
+			}
+			if diff := cmp.Diff(tc.wantActive, wl.Spec.Active); diff != "" {
+				t.Errorf("Unexpected .spec.active (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantConditions, wl.Status.Conditions, cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")); diff != "" {
+				t.Errorf("Unexpected conditions (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIsReservationOnly(t *testing.T) {
+	cases := map[string]struct {
+		workload *kueue.Workload
+		want     bool
+	}{
+		"no label": {
+			workload: utiltesting.MakeWorkload("wl", "ns").Obj(),
+		},
+		"reservation-only": {
+			workload: utiltesting.MakeWorkload("wl", "ns").ReservationOnly().Obj(),
+			want:     true,
+		},
+		"label set to false": {
+			workload: utiltesting.MakeWorkload("wl", "ns").Label(kueue.ReservationOnlyLabel, "false").Obj(),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := IsReservationOnly(tc.workload); got != tc.want {
+				t.Errorf("IsReservationOnly()=%t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestShouldRetryAdmissionChecks(t *testing.T) {
+	cases := map[string]struct {
+		workload *kueue.Workload
+		want     bool
+	}{
+		"no checks": {
+			workload: utiltesting.MakeWorkload("wl", "ns").Obj(),
+		},
+		"pending and ready": {
+			workload: utiltesting.MakeWorkload("wl", "ns").
+				AdmissionCheck("check1", kueue.CheckStatePending).
+				AdmissionCheck("check2", kueue.CheckStateReady).
+				Obj(),
+		},
+		"retry": {
+			workload: utiltesting.MakeWorkload("wl", "ns").
+				AdmissionCheck("check1", kueue.CheckStateReady).
+				AdmissionCheck("check2", kueue.CheckStateRetry).
+				Obj(),
+			want: true,
+		},
+		"rejected": {
+			workload: utiltesting.MakeWorkload("wl", "ns").
+				AdmissionCheck("check1", kueue.CheckStateRejected).
+				Obj(),
+		},
+		"retry and rejected": {
+			workload: utiltesting.MakeWorkload("wl", "ns").
+				AdmissionCheck("check1", kueue.CheckStateRetry).
+				AdmissionCheck("check2", kueue.CheckStateRejected).
+				Obj(),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := ShouldRetryAdmissionChecks(tc.workload); got != tc.want {
+				t.Errorf("ShouldRetryAdmissionChecks()=%t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOwnerJobGVK(t *testing.T) {
+	jobGVK := batchv1.SchemeGroupVersion.WithKind("Job")
+	cases := map[string]struct {
+		workload *kueue.Workload
+		wantGVK  schema.GroupVersionKind
+		wantOK   bool
+	}{
+		"owned by a job": {
+			workload: utiltesting.MakeWorkload("wl", "ns").
+				OwnerReference(jobGVK, "job", "job-uid", true).
+				Obj(),
+			wantGVK: schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"},
+			wantOK:  true,
+		},
+		"no owner": {
+			workload: utiltesting.MakeWorkload("wl", "ns").Obj(),
+		},
+		"owner is not the controller": {
+			workload: utiltesting.MakeWorkload("wl", "ns").
+				OwnerReference(jobGVK, "job", "job-uid", false).
+				Obj(),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			gvk, ok := OwnerJobGVK(tc.workload)
+			if ok != tc.wantOK {
+				t.Errorf("OwnerJobGVK() returned ok=%t, want %t", ok, tc.wantOK)
+			}
+			if diff := cmp.Diff(tc.wantGVK, gvk); diff != "" {
+				t.Errorf("OwnerJobGVK() returned unexpected GVK (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdmissionCheckTimedOut(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	cases := map[string]struct {
+		workload *kueue.Workload
+		want     bool
+	}{
+		"no checks": {
+			workload: utiltesting.MakeWorkload("wl", "ns").Obj(),
+		},
+		"timed out": {
+			workload: utiltesting.MakeWorkload("wl", "ns").
+				AdmissionCheckAt("check", kueue.CheckStatePending, now.Add(-2*time.Minute)).
+				Obj(),
+			want: true,
+		},
+		"within timeout": {
+			workload: utiltesting.MakeWorkload("wl", "ns").
+				AdmissionCheckAt("check", kueue.CheckStatePending, now.Add(-30*time.Second)).
+				Obj(),
+		},
+		"other check timed out": {
+			workload: utiltesting.MakeWorkload("wl", "ns").
+				AdmissionCheckAt("other", kueue.CheckStatePending, now.Add(-2*time.Minute)).
+				AdmissionCheckAt("check", kueue.CheckStatePending, now.Add(-30*time.Second)).
+				Obj(),
+		},
+		"ready": {
+			workload: utiltesting.MakeWorkload("wl", "ns").
+				AdmissionCheckAt("check", kueue.CheckStateReady, now.Add(-2*time.Minute)).
+				Obj(),
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := AdmissionCheckTimedOut(tc.workload, "check", time.Minute, now); got != tc.want {
+				t.Errorf("AdmissionCheckTimedOut()=%t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUpdateRequeueState(t *testing.T) {
+	now := time.Now()
+	cases := map[string]struct {
+		requeueState *kueue.RequeueState
+		want         *kueue.RequeueState
+	}{
+		"first requeue": {
+			want: &kueue.RequeueState{
+				Count:     pointer.Int32(1),
+				RequeueAt: &metav1.Time{Time: now.Add(10 * time.Second)},
+			},
+		},
+		"third requeue": {
+			requeueState: &kueue.RequeueState{Count: pointer.Int32(2)},
+			want: &kueue.RequeueState{
+				Count:     pointer.Int32(3),
+				RequeueAt: &metav1.Time{Time: now.Add(40 * time.Second)},
+			},
+		},
+		"delay capped": {
+			requeueState: &kueue.RequeueState{Count: pointer.Int32(20)},
+			want: &kueue.RequeueState{
+				Count:     pointer.Int32(21),
+				RequeueAt: &metav1.Time{Time: now.Add(time.Hour)},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			wl := utiltesting.MakeWorkload("wl", "ns").Obj()
+			wl.Status.RequeueState = tc.requeueState
+			UpdateRequeueState(wl, now)
+			if diff := cmp.Diff(tc.want, wl.Status.RequeueState); diff != "" {
+				t.Errorf("Unexpected requeue state (-want,+got):\n%s", diff)
+			}
+			if got := RequeueDelay(wl, now); got != tc.want.RequeueAt.Sub(now) {
+				t.Errorf("RequeueDelay(_)=%s, want %s", got, tc.want.RequeueAt.Sub(now))
+			}
+			if IsQueueable(wl, now) {
+				t.Errorf("IsQueueable(_)=true for a workload held back from the queue")
+			}
+			if !IsQueueable(wl, tc.want.RequeueAt.Time) {
+				t.Errorf("IsQueueable(_)=false once the requeue time passed")
+			}
+		})
+	}
+}
+
+func TestHasQuotaReservation(t *testing.T) {
+	cases := map[string]struct {
+		wl                   *kueue.Workload
+		wantQuotaReservation bool
+		wantAdmitted         bool
+	}{
+		"pending": {
+			wl: utiltesting.MakeWorkload("name", "ns").Obj(),
+		},
+		"quota reserved": {
+			wl: utiltesting.MakeWorkload("name", "ns").
+				ReserveQuota(utiltesting.MakeAdmission("cq").Obj()).
+				Obj(),
+			wantQuotaReservation: true,
+		},
+		"admitted": {
+			wl: utiltesting.MakeWorkload("name", "ns").
+				Admit(utiltesting.MakeAdmission("cq").Obj()).
+				Obj(),
+			wantAdmitted: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := HasQuotaReservation(tc.wl); got != tc.wantQuotaReservation {
+				t.Errorf("HasQuotaReservation(_) = %t, want %t", got, tc.wantQuotaReservation)
+			}
+			if got := IsAdmitted(tc.wl); got != tc.wantAdmitted {
+				t.Errorf("IsAdmitted(_) = %t, want %t", got, tc.wantAdmitted)
+			}
+		})
+	}
+}
@@ -58,8 +58,8 @@ var _ = ginkgo.Describe("Queue controller", func() {
 	ginkgo.BeforeEach(func() {
 		clusterQueue = testing.MakeClusterQueue("cluster-queue.queue-controller").
 			ResourceGroup(
-				*testing.MakeFlavorQuotas(flavorModelC).Resource(resourceGPU, "5", "5").Obj(),
-				*testing.MakeFlavorQuotas(flavorModelD).Resource(resourceGPU, "5", "5").Obj(),
+				*testing.MakeFlavorQuotas(flavorModelC).Resource(resourceGPU, "5").Obj(),
+				*testing.MakeFlavorQuotas(flavorModelD).Resource(resourceGPU, "5").Obj(),
 			).Obj()
 		queue = testing.MakeLocalQueue("queue", ns.Name).ClusterQueue(clusterQueue.Name).Obj()
 		gomega.Expect(k8sClient.Create(ctx, queue)).To(gomega.Succeed())
@@ -76,7 +76,7 @@ var _ = ginkgo.Describe("Workload controller with scheduler", func() {
 			gomega.Expect(k8sClient.Create(ctx, runtimeClass)).To(gomega.Succeed())
 			clusterQueue = testing.MakeClusterQueue("clusterqueue").
 				ResourceGroup(*testing.MakeFlavorQuotas(onDemandFlavor.Name).
-					Resource(corev1.ResourceCPU, "5", "5").Obj()).
+					Resource(corev1.ResourceCPU, "5").Obj()).
 				Obj()
 			gomega.Expect(k8sClient.Create(ctx, clusterQueue)).To(gomega.Succeed())
 			localQueue = testing.MakeLocalQueue("queue", ns.Name).ClusterQueue(clusterQueue.Name).Obj()
@@ -132,7 +132,7 @@ var _ = ginkgo.Describe("Workload controller with scheduler", func() {
 
 			clusterQueue = testing.MakeClusterQueue("clusterqueue").
 				ResourceGroup(*testing.MakeFlavorQuotas(onDemandFlavor.Name).
-					Resource(corev1.ResourceCPU, "5", "5").Obj()).
+					Resource(corev1.ResourceCPU, "5").Obj()).
 				Obj()
 			gomega.Expect(k8sClient.Create(ctx, clusterQueue)).To(gomega.Succeed())
 			localQueue = testing.MakeLocalQueue("queue", ns.Name).ClusterQueue(clusterQueue.Name).Obj()
@@ -188,7 +188,7 @@ var _ = ginkgo.Describe("Workload controller with scheduler", func() {
 			gomega.Expect(k8sClient.Create(ctx, onDemandFlavor)).To(gomega.Succeed())
 			clusterQueue = testing.MakeClusterQueue("clusterqueue").
 				ResourceGroup(*testing.MakeFlavorQuotas(onDemandFlavor.Name).
-					Resource(corev1.ResourceCPU, "5", "5").Obj()).
+					Resource(corev1.ResourceCPU, "5").Obj()).
 				Obj()
 			gomega.Expect(k8sClient.Create(ctx, clusterQueue)).To(gomega.Succeed())
 			localQueue = testing.MakeLocalQueue("queue", ns.Name).ClusterQueue(clusterQueue.Name).Obj()
@@ -290,7 +290,7 @@ var _ = ginkgo.Describe("Workload controller with scheduler", func() {
 			gomega.Expect(k8sClient.Create(ctx, onDemandFlavor)).To(gomega.Succeed())
 			clusterQueue = testing.MakeClusterQueue("clusterqueue").
 				ResourceGroup(*testing.MakeFlavorQuotas(onDemandFlavor.Name).
-					Resource(corev1.ResourceCPU, "5", "5").Obj()).
+					Resource(corev1.ResourceCPU, "5").Obj()).
 				Obj()
 			gomega.Expect(k8sClient.Create(ctx, clusterQueue)).To(gomega.Succeed())
 			localQueue = testing.MakeLocalQueue("queue", ns.Name).ClusterQueue(clusterQueue.Name).Obj()
@@ -353,7 +353,7 @@ var _ = ginkgo.Describe("Workload controller with scheduler", func() {
 			gomega.Expect(k8sClient.Create(ctx, onDemandFlavor)).To(gomega.Succeed())
 			clusterQueue = testing.MakeClusterQueue("clusterqueue").
 				ResourceGroup(*testing.MakeFlavorQuotas(onDemandFlavor.Name).
-					Resource(corev1.ResourceCPU, "5", "5").Obj()).
+					Resource(corev1.ResourceCPU, "5").Obj()).
 				Obj()
 			gomega.Expect(k8sClient.Create(ctx, clusterQueue)).To(gomega.Succeed())
 			localQueue = testing.MakeLocalQueue("queue", ns.Name).ClusterQueue(clusterQueue.Name).Obj()
@@ -448,7 +448,7 @@ var _ = ginkgo.Describe("Workload controller with scheduler", func() {
 			gomega.Expect(k8sClient.Create(ctx, onDemandFlavor)).To(gomega.Succeed())
 			clusterQueue = testing.MakeClusterQueue("clusterqueue").
 				ResourceGroup(*testing.MakeFlavorQuotas(onDemandFlavor.Name).
-					Resource(corev1.ResourceCPU, "5", "5").Obj()).
+					Resource(corev1.ResourceCPU, "5").Obj()).
 				Obj()
 			gomega.Expect(k8sClient.Create(ctx, clusterQueue)).To(gomega.Succeed())
 			localQueue = testing.MakeLocalQueue("queue", ns.Name).ClusterQueue(clusterQueue.Name).Obj()
@@ -539,7 +539,7 @@ var _ = ginkgo.Describe("Workload controller with scheduler", func() {
 			gomega.Expect(k8sClient.Create(ctx, onDemandFlavor)).To(gomega.Succeed())
 			clusterQueue = testing.MakeClusterQueue("clusterqueue").
 				ResourceGroup(*testing.MakeFlavorQuotas(onDemandFlavor.Name).
-					Resource(corev1.ResourceCPU, "5", "5").Obj()).
+					Resource(corev1.ResourceCPU, "5").Obj()).
 				Obj()
 			gomega.Expect(k8sClient.Create(ctx, clusterQueue)).To(gomega.Succeed())
 			localQueue = testing.MakeLocalQueue("queue", ns.Name).ClusterQueue(clusterQueue.Name).Obj()
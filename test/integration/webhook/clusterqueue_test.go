@@ -164,6 +164,11 @@ var _ = ginkgo.Describe("ClusterQueue Webhook", func() {
 					ResourceGroup(*testing.MakeFlavorQuotas("x86").Resource("2", "-1").Obj()).
 					Obj(),
 				isForbidden),
+			ginkgo.Entry("Should forbid borrowing limit without cohort",
+				testing.MakeClusterQueue("cluster-queue").
+					ResourceGroup(*testing.MakeFlavorQuotas("x86").Resource("cpu", "2", "1").Obj()).
+					Obj(),
+				isForbidden),
 			ginkgo.Entry("Should have non-negative quota value",
 				testing.MakeClusterQueue("cluster-queue").
 					ResourceGroup(*testing.MakeFlavorQuotas("x86").Resource("-1").Obj()).